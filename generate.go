@@ -1,3 +1,3 @@
-//go:generate mockgen -destination=internal/sender/mocks_test.go -package=sender github.com/goverland-labs/goverland-inbox-push/internal/sender UsersFinder,SettingsProvider,CoreDataProvider,DataManipulator,MessageSender,PushManipulator
+//go:generate mockgen -destination=internal/sender/mocks_test.go -package=sender github.com/goverland-labs/goverland-inbox-push/internal/sender SubscriptionsFinder,UsersFinder,SettingsProvider,CoreDataProvider,Storage,MessageSender,PushManipulator,DeadLetterSink,FeedReplayer
 
 package main
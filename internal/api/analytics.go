@@ -0,0 +1,75 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/goverland-labs/goverland-inbox-push/internal/sender"
+)
+
+const defaultAnalyticsPeriod = 30 * 24 * time.Hour
+
+// clickStats returns sends, clicks, CTR and time to click by the group.
+// Query params: group_by (template, action, dao, device, day, variant), from and to in RFC3339,
+// experiment_id to limit stats to the experiment, it is required for grouping by variant.
+func (h *Handler) clickStats(w http.ResponseWriter, r *http.Request) {
+	query := sender.ClickStatsQuery{
+		From:         time.Now().Add(-defaultAnalyticsPeriod),
+		To:           time.Now(),
+		GroupBy:      sender.ClickStatsGroup(r.URL.Query().Get("group_by")),
+		ExperimentID: r.URL.Query().Get("experiment_id"),
+	}
+
+	if query.GroupBy == "" {
+		query.GroupBy = sender.GroupByTemplate
+	}
+
+	if !query.GroupBy.Valid() {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("unsupported group_by: %s", query.GroupBy))
+		return
+	}
+
+	if query.GroupBy == sender.GroupByVariant && query.ExperimentID == "" {
+		writeError(w, http.StatusBadRequest, sender.ErrExperimentRequired)
+		return
+	}
+
+	var err error
+	if query.From, err = parseTime(r, "from", query.From); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if query.To, err = parseTime(r, "to", query.To); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	list, err := h.analytics.ClickStats(r.Context(), query)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"group_by": query.GroupBy,
+		"from":     query.From,
+		"to":       query.To,
+		"items":    list,
+	})
+}
+
+func parseTime(r *http.Request, name string, def time.Time) (time.Time, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return def, nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s: %w", name, err)
+	}
+
+	return parsed, nil
+}
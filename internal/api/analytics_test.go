@@ -0,0 +1,121 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/goverland-labs/goverland-inbox-push/internal/config"
+	"github.com/goverland-labs/goverland-inbox-push/internal/sender"
+)
+
+const testToken = "secret"
+
+type analyticsStub struct {
+	query sender.ClickStatsQuery
+	list  []sender.ClickStats
+}
+
+func (a *analyticsStub) ClickStats(_ context.Context, query sender.ClickStatsQuery) ([]sender.ClickStats, error) {
+	a.query = query
+
+	return a.list, nil
+}
+
+func doRequest(t *testing.T, h *Handler, method, target, token string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	return doRequestWithBody(t, h, method, target, token, "")
+}
+
+func doRequestWithBody(t *testing.T, h *Handler, method, target, token, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	srv := NewServer(config.HTTP{AdminToken: testToken, InternalToken: testToken}, h)
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	rec := httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestClickStats(t *testing.T) {
+	for name, tc := range map[string]struct {
+		target  string
+		token   string
+		status  int
+		groupBy sender.ClickStatsGroup
+	}{
+		"without token": {
+			target: "/admin/analytics/clicks",
+			status: http.StatusUnauthorized,
+		},
+		"wrong token": {
+			target: "/admin/analytics/clicks",
+			token:  "wrong",
+			status: http.StatusUnauthorized,
+		},
+		"default group": {
+			target:  "/admin/analytics/clicks",
+			token:   testToken,
+			status:  http.StatusOK,
+			groupBy: sender.GroupByTemplate,
+		},
+		"group by day": {
+			target:  "/admin/analytics/clicks?group_by=day&from=2024-12-01T00:00:00Z",
+			token:   testToken,
+			status:  http.StatusOK,
+			groupBy: sender.GroupByDay,
+		},
+		"group by variant": {
+			target:  "/admin/analytics/clicks?group_by=variant&experiment_id=exp",
+			token:   testToken,
+			status:  http.StatusOK,
+			groupBy: sender.GroupByVariant,
+		},
+		"group by variant without experiment": {
+			target: "/admin/analytics/clicks?group_by=variant",
+			token:  testToken,
+			status: http.StatusBadRequest,
+		},
+		"unsupported group": {
+			target: "/admin/analytics/clicks?group_by=user",
+			token:  testToken,
+			status: http.StatusBadRequest,
+		},
+		"invalid from": {
+			target: "/admin/analytics/clicks?from=yesterday",
+			token:  testToken,
+			status: http.StatusBadRequest,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			stub := &analyticsStub{
+				list: []sender.ClickStats{{Key: "1", Sends: 10, Clicks: 2, CTR: 0.2}},
+			}
+
+			rec := doRequest(t, NewHandler(stub, nil, nil, nil, nil, nil), http.MethodGet, tc.target, tc.token)
+			require.Equal(t, tc.status, rec.Code)
+			if tc.status != http.StatusOK {
+				return
+			}
+
+			require.Equal(t, tc.groupBy, stub.query.GroupBy)
+
+			var resp struct {
+				Items []sender.ClickStats `json:"items"`
+			}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			require.Equal(t, stub.list, resp.Items)
+		})
+	}
+}
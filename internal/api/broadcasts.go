@@ -0,0 +1,143 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/goverland-labs/goverland-inbox-push/internal/sender"
+)
+
+// createBroadcast stores the broadcast draft and returns its preview with recipients count.
+// The draft is sent only after the confirmation. The all segment includes only users who have
+// received pushes before, users who registered devices but never got a push are not included.
+func (h *Handler) createBroadcast(w http.ResponseWriter, r *http.Request) {
+	var req sender.BroadcastRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+		return
+	}
+
+	preview, err := h.broadcasts.CreateBroadcast(r.Context(), req)
+	if err != nil {
+		writeBroadcastError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, preview)
+}
+
+// broadcastsList returns the latest broadcasts. Query params: limit.
+func (h *Handler) broadcastsList(w http.ResponseWriter, r *http.Request) {
+	var (
+		limit int
+		err   error
+	)
+	if value := r.URL.Query().Get("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid limit: %w", err))
+			return
+		}
+	}
+
+	list, err := h.broadcasts.ListBroadcasts(r.Context(), limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"items": list,
+	})
+}
+
+func (h *Handler) getBroadcast(w http.ResponseWriter, r *http.Request) {
+	id, err := parseBroadcastID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	item, err := h.broadcasts.GetBroadcast(r.Context(), id)
+	if err != nil {
+		writeBroadcastError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, item)
+}
+
+// previewBroadcast returns recipients count of the broadcast with the sample and the note on the segment limitation
+func (h *Handler) previewBroadcast(w http.ResponseWriter, r *http.Request) {
+	id, err := parseBroadcastID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	preview, err := h.broadcasts.PreviewBroadcast(r.Context(), id)
+	if err != nil {
+		writeBroadcastError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, preview)
+}
+
+func (h *Handler) confirmBroadcast(w http.ResponseWriter, r *http.Request) {
+	id, err := parseBroadcastID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	item, err := h.broadcasts.ConfirmBroadcast(r.Context(), id)
+	if err != nil {
+		writeBroadcastError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, item)
+}
+
+func (h *Handler) cancelBroadcast(w http.ResponseWriter, r *http.Request) {
+	id, err := parseBroadcastID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	item, err := h.broadcasts.CancelBroadcast(r.Context(), id)
+	if err != nil {
+		writeBroadcastError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, item)
+}
+
+func writeBroadcastError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, sender.ErrInvalidBroadcast):
+		writeError(w, http.StatusBadRequest, err)
+	case errors.Is(err, sender.ErrBroadcastNotFound):
+		writeError(w, http.StatusNotFound, err)
+	case errors.Is(err, sender.ErrBroadcastState):
+		writeError(w, http.StatusConflict, err)
+	default:
+		writeError(w, http.StatusInternalServerError, err)
+	}
+}
+
+func parseBroadcastID(r *http.Request) (uint, error) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid broadcast id: %w", err)
+	}
+
+	return uint(id), nil
+}
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/goverland-labs/goverland-inbox-push/internal/sender"
+)
+
+type broadcastsStub struct {
+	req       sender.BroadcastRequest
+	id        uint
+	limit     int
+	err       error
+	confirmed bool
+}
+
+func (b *broadcastsStub) CreateBroadcast(_ context.Context, req sender.BroadcastRequest) (*sender.BroadcastPreview, error) {
+	b.req = req
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	return &sender.BroadcastPreview{Broadcast: sender.BroadcastDetails{ID: 1, Status: sender.BroadcastDraft}, Recipients: 2}, nil
+}
+
+func (b *broadcastsStub) PreviewBroadcast(_ context.Context, id uint) (*sender.BroadcastPreview, error) {
+	b.id = id
+
+	return &sender.BroadcastPreview{Broadcast: sender.BroadcastDetails{ID: id}}, b.err
+}
+
+func (b *broadcastsStub) ConfirmBroadcast(_ context.Context, id uint) (*sender.BroadcastDetails, error) {
+	b.id = id
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	b.confirmed = true
+
+	return &sender.BroadcastDetails{ID: id, Status: sender.BroadcastScheduled}, nil
+}
+
+func (b *broadcastsStub) CancelBroadcast(_ context.Context, id uint) (*sender.BroadcastDetails, error) {
+	b.id = id
+
+	return &sender.BroadcastDetails{ID: id, Status: sender.BroadcastCancelled}, b.err
+}
+
+func (b *broadcastsStub) GetBroadcast(_ context.Context, id uint) (*sender.BroadcastDetails, error) {
+	b.id = id
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	return &sender.BroadcastDetails{ID: id}, nil
+}
+
+func (b *broadcastsStub) ListBroadcasts(_ context.Context, limit int) ([]sender.BroadcastDetails, error) {
+	b.limit = limit
+
+	return nil, b.err
+}
+
+func TestCreateBroadcast(t *testing.T) {
+	for name, tc := range map[string]struct {
+		body   string
+		token  string
+		err    error
+		status int
+	}{
+		"without token": {
+			body:   `{}`,
+			status: http.StatusUnauthorized,
+		},
+		"invalid body": {
+			body:   `{`,
+			token:  testToken,
+			status: http.StatusBadRequest,
+		},
+		"invalid broadcast": {
+			body:   `{"title":"Update"}`,
+			token:  testToken,
+			err:    fmt.Errorf("%w: body is required", sender.ErrInvalidBroadcast),
+			status: http.StatusBadRequest,
+		},
+		"created": {
+			body:   `{"title":"Update","body":"New version is available","segment":"all"}`,
+			token:  testToken,
+			status: http.StatusCreated,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			stub := &broadcastsStub{err: tc.err}
+
+			rec := doRequestWithBody(t, NewHandler(nil, nil, nil, stub, nil, nil), http.MethodPost, "/admin/broadcasts", tc.token, tc.body)
+			require.Equal(t, tc.status, rec.Code)
+			if tc.status != http.StatusCreated {
+				return
+			}
+
+			require.Equal(t, sender.BroadcastSegmentAll, stub.req.Segment)
+
+			var preview sender.BroadcastPreview
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &preview))
+			require.Equal(t, 2, preview.Recipients)
+			require.Equal(t, sender.BroadcastDraft, preview.Broadcast.Status)
+		})
+	}
+}
+
+func TestConfirmBroadcast(t *testing.T) {
+	for name, tc := range map[string]struct {
+		target string
+		err    error
+		status int
+	}{
+		"invalid id": {
+			target: "/admin/broadcasts/abc/confirm",
+			status: http.StatusNotFound,
+		},
+		"not found": {
+			target: "/admin/broadcasts/7/confirm",
+			err:    sender.ErrBroadcastNotFound,
+			status: http.StatusNotFound,
+		},
+		"already confirmed": {
+			target: "/admin/broadcasts/7/confirm",
+			err:    fmt.Errorf("%w: %s", sender.ErrBroadcastState, sender.BroadcastScheduled),
+			status: http.StatusConflict,
+		},
+		"confirmed": {
+			target: "/admin/broadcasts/7/confirm",
+			status: http.StatusOK,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			stub := &broadcastsStub{err: tc.err}
+
+			rec := doRequest(t, NewHandler(nil, nil, nil, stub, nil, nil), http.MethodPost, tc.target, testToken)
+			require.Equal(t, tc.status, rec.Code)
+			require.Equal(t, tc.status == http.StatusOK, stub.confirmed)
+			if tc.status == http.StatusOK {
+				require.EqualValues(t, 7, stub.id)
+			}
+		})
+	}
+}
+
+func TestBroadcastsList(t *testing.T) {
+	stub := &broadcastsStub{}
+
+	rec := doRequest(t, NewHandler(nil, nil, nil, stub, nil, nil), http.MethodGet, "/admin/broadcasts?limit=5", testToken)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, 5, stub.limit)
+
+	rec = doRequest(t, NewHandler(nil, nil, nil, stub, nil, nil), http.MethodGet, "/admin/broadcasts?limit=five", testToken)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
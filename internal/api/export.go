@@ -0,0 +1,98 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/goverland-labs/goverland-inbox-push/internal/sender"
+)
+
+var exportContentTypes = map[sender.ExportFormat]string{
+	sender.ExportCSV:   "text/csv",
+	sender.ExportJSONL: "application/x-ndjson",
+}
+
+// exportHistory streams push history rows.
+// Query params: format (csv, jsonl), gzip, user_id, dao_id, template_id, from and to in RFC3339.
+func (h *Handler) exportHistory(w http.ResponseWriter, r *http.Request) {
+	query, err := parseHistoryExportQuery(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	filename := "histories." + string(query.Format)
+	w.Header().Set("Content-Type", exportContentTypes[query.Format])
+	if query.Gzip {
+		filename += ".gz"
+		w.Header().Set("Content-Type", "application/gzip")
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+
+	// the status is already sent, so the client only sees the truncated output
+	if err := h.exporter.ExportHistory(r.Context(), w, query); err != nil {
+		log.Error().Err(err).Msg("export history")
+	}
+}
+
+func parseHistoryExportQuery(r *http.Request) (sender.HistoryExportQuery, error) {
+	values := r.URL.Query()
+	query := sender.HistoryExportQuery{
+		Format: sender.ExportFormat(values.Get("format")),
+		Gzip:   values.Get("gzip") == "true",
+	}
+
+	if query.Format == "" {
+		query.Format = sender.ExportCSV
+	}
+
+	if !query.Format.Valid() {
+		return query, fmt.Errorf("unsupported format: %s", query.Format)
+	}
+
+	var err error
+	if query.UserID, err = parseOptionalUUID(r, "user_id"); err != nil {
+		return query, err
+	}
+
+	if query.DaoID, err = parseOptionalUUID(r, "dao_id"); err != nil {
+		return query, err
+	}
+
+	if value := values.Get("template_id"); value != "" {
+		templateID, err := strconv.Atoi(value)
+		if err != nil {
+			return query, fmt.Errorf("invalid template_id: %w", err)
+		}
+		query.TemplateID = &templateID
+	}
+
+	if query.From, err = parseTime(r, "from", query.From); err != nil {
+		return query, err
+	}
+
+	if query.To, err = parseTime(r, "to", query.To); err != nil {
+		return query, err
+	}
+
+	return query, nil
+}
+
+func parseOptionalUUID(r *http.Request, name string) (*uuid.UUID, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", name, err)
+	}
+
+	return &id, nil
+}
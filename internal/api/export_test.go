@@ -0,0 +1,80 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/goverland-labs/goverland-inbox-push/internal/sender"
+)
+
+type exporterStub struct {
+	query sender.HistoryExportQuery
+}
+
+func (e *exporterStub) ExportHistory(_ context.Context, w io.Writer, query sender.HistoryExportQuery) error {
+	e.query = query
+	_, err := w.Write([]byte("data"))
+
+	return err
+}
+
+func TestExportHistory(t *testing.T) {
+	userID := uuid.New()
+
+	for name, tc := range map[string]struct {
+		target      string
+		token       string
+		status      int
+		contentType string
+		format      sender.ExportFormat
+	}{
+		"without token": {
+			target: "/admin/export/histories",
+			status: http.StatusUnauthorized,
+		},
+		"invalid format": {
+			target: "/admin/export/histories?format=xml",
+			token:  testToken,
+			status: http.StatusBadRequest,
+		},
+		"invalid template": {
+			target: "/admin/export/histories?template_id=one",
+			token:  testToken,
+			status: http.StatusBadRequest,
+		},
+		"csv by default": {
+			target:      "/admin/export/histories?user_id=" + userID.String(),
+			token:       testToken,
+			status:      http.StatusOK,
+			contentType: "text/csv",
+			format:      sender.ExportCSV,
+		},
+		"gzipped jsonl": {
+			target:      "/admin/export/histories?format=jsonl&gzip=true&user_id=" + userID.String(),
+			token:       testToken,
+			status:      http.StatusOK,
+			contentType: "application/gzip",
+			format:      sender.ExportJSONL,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			stub := &exporterStub{}
+
+			rec := doRequest(t, NewHandler(nil, nil, stub, nil, nil, nil), http.MethodGet, tc.target, tc.token)
+			require.Equal(t, tc.status, rec.Code)
+			if tc.status != http.StatusOK {
+				return
+			}
+
+			require.Equal(t, tc.contentType, rec.Header().Get("Content-Type"))
+			require.Equal(t, tc.format, stub.query.Format)
+			require.Equal(t, &userID, stub.query.UserID)
+			require.Equal(t, "data", rec.Body.String())
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"github.com/goverland-labs/goverland-inbox-push/internal/sender"
+)
+
+type markReadRequest struct {
+	// IDs of messages to mark, all user messages are marked if All is set
+	IDs []uuid.UUID `json:"ids"`
+	All bool        `json:"all"`
+}
+
+// notificationsList returns user pushes from the newest one.
+// Query params: cursor from the previous page and limit.
+func (h *Handler) notificationsList(w http.ResponseWriter, r *http.Request) {
+	userID, err := parseUserID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	query := sender.NotificationsQuery{
+		UserID: userID,
+		Cursor: r.URL.Query().Get("cursor"),
+	}
+
+	if value := r.URL.Query().Get("limit"); value != "" {
+		if query.Limit, err = strconv.Atoi(value); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid limit: %w", err))
+			return
+		}
+	}
+
+	page, err := h.notifications.Notifications(r.Context(), query)
+	if errors.Is(err, sender.ErrInvalidCursor) {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, page)
+}
+
+func (h *Handler) unreadNotificationsCount(w http.ResponseWriter, r *http.Request) {
+	userID, err := parseUserID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	count, err := h.notifications.UnreadNotificationsCount(r.Context(), userID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]int64{
+		"count": count,
+	})
+}
+
+func (h *Handler) markNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	userID, err := parseUserID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var req markReadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+		return
+	}
+
+	if len(req.IDs) == 0 && !req.All {
+		writeError(w, http.StatusBadRequest, errors.New("ids or all must be set"))
+		return
+	}
+
+	if req.All {
+		req.IDs = nil
+	}
+
+	if err := h.notifications.MarkAsRead(r.Context(), userID, req.IDs); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func parseUserID(r *http.Request) (uuid.UUID, error) {
+	id, err := uuid.Parse(mux.Vars(r)["user_id"])
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid user id: %w", err)
+	}
+
+	return id, nil
+}
@@ -0,0 +1,160 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/goverland-labs/goverland-inbox-push/internal/sender"
+)
+
+type notificationsStub struct {
+	query  sender.NotificationsQuery
+	page   sender.NotificationsPage
+	err    error
+	userID uuid.UUID
+	read   []uuid.UUID
+	marked bool
+}
+
+func (n *notificationsStub) Notifications(_ context.Context, query sender.NotificationsQuery) (sender.NotificationsPage, error) {
+	n.query = query
+
+	return n.page, n.err
+}
+
+func (n *notificationsStub) UnreadNotificationsCount(_ context.Context, userID uuid.UUID) (int64, error) {
+	n.userID = userID
+
+	return 3, nil
+}
+
+func (n *notificationsStub) MarkAsRead(_ context.Context, userID uuid.UUID, ids []uuid.UUID) error {
+	n.userID = userID
+	n.read = ids
+	n.marked = true
+
+	return nil
+}
+
+func TestNotificationsList(t *testing.T) {
+	userID := uuid.New()
+	base := "/internal/users/" + userID.String() + "/notifications"
+
+	for name, tc := range map[string]struct {
+		target string
+		token  string
+		err    error
+		status int
+		limit  int
+	}{
+		"without token": {
+			target: base,
+			status: http.StatusUnauthorized,
+		},
+		"invalid user id": {
+			target: "/internal/users/wrong/notifications",
+			token:  testToken,
+			status: http.StatusBadRequest,
+		},
+		"invalid limit": {
+			target: base + "?limit=ten",
+			token:  testToken,
+			status: http.StatusBadRequest,
+		},
+		"invalid cursor": {
+			target: base + "?cursor=wrong",
+			token:  testToken,
+			err:    sender.ErrInvalidCursor,
+			status: http.StatusBadRequest,
+		},
+		"with limit": {
+			target: base + "?limit=10",
+			token:  testToken,
+			status: http.StatusOK,
+			limit:  10,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			stub := &notificationsStub{
+				err: tc.err,
+				page: sender.NotificationsPage{
+					Items:      []sender.Notification{{ID: uuid.New(), Title: "title"}},
+					NextCursor: "next",
+				},
+			}
+
+			rec := doRequest(t, NewHandler(nil, stub, nil, nil, nil, nil), http.MethodGet, tc.target, tc.token)
+			require.Equal(t, tc.status, rec.Code)
+			if tc.status != http.StatusOK {
+				return
+			}
+
+			require.Equal(t, userID, stub.query.UserID)
+			require.Equal(t, tc.limit, stub.query.Limit)
+
+			var resp sender.NotificationsPage
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			require.Equal(t, stub.page.NextCursor, resp.NextCursor)
+			require.Len(t, resp.Items, 1)
+		})
+	}
+}
+
+func TestUnreadNotificationsCount(t *testing.T) {
+	userID := uuid.New()
+	stub := &notificationsStub{}
+
+	rec := doRequest(t, NewHandler(nil, stub, nil, nil, nil, nil), http.MethodGet, "/internal/users/"+userID.String()+"/notifications/unread-count", testToken)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, userID, stub.userID)
+	require.JSONEq(t, `{"count":3}`, rec.Body.String())
+}
+
+func TestMarkNotificationsRead(t *testing.T) {
+	userID := uuid.New()
+	msgID := uuid.New()
+	target := "/internal/users/" + userID.String() + "/notifications/read"
+
+	for name, tc := range map[string]struct {
+		body   string
+		status int
+		ids    []uuid.UUID
+	}{
+		"invalid body": {
+			body:   "{",
+			status: http.StatusBadRequest,
+		},
+		"nothing to mark": {
+			body:   "{}",
+			status: http.StatusBadRequest,
+		},
+		"by ids": {
+			body:   `{"ids":["` + msgID.String() + `"]}`,
+			status: http.StatusNoContent,
+			ids:    []uuid.UUID{msgID},
+		},
+		"all": {
+			body:   `{"all":true}`,
+			status: http.StatusNoContent,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			stub := &notificationsStub{}
+
+			rec := doRequestWithBody(t, NewHandler(nil, stub, nil, nil, nil, nil), http.MethodPost, target, testToken, tc.body)
+			require.Equal(t, tc.status, rec.Code)
+			if tc.status != http.StatusNoContent {
+				require.False(t, stub.marked)
+				return
+			}
+
+			require.Equal(t, userID, stub.userID)
+			require.Equal(t, tc.ids, stub.read)
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goverland-labs/goverland-inbox-push/internal/sender"
+)
+
+// actorHeader names the person behind the admin token in the audit log
+const (
+	actorHeader  = "X-Actor"
+	defaultActor = "admin"
+)
+
+type queueChangeRequest struct {
+	IDs []uint `json:"ids"`
+}
+
+// queueItems returns the latest queue items.
+// Query params: id, user_id, dao_id, proposal_id, action (comma separated), status (pending, sent),
+// correlation_id, created_after and created_before in RFC3339, limit.
+func (h *Handler) queueItems(w http.ResponseWriter, r *http.Request) {
+	query, err := parseQueueQuery(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	list, err := h.queue.QueueItems(r.Context(), query)
+	if errors.Is(err, sender.ErrInvalidQueueQuery) {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"items": list,
+	})
+}
+
+func (h *Handler) cancelQueueItems(w http.ResponseWriter, r *http.Request) {
+	h.changeQueue(w, r, h.queue.CancelQueueItems)
+}
+
+func (h *Handler) requeueQueueItems(w http.ResponseWriter, r *http.Request) {
+	h.changeQueue(w, r, h.queue.RequeueQueueItems)
+}
+
+func (h *Handler) changeQueue(w http.ResponseWriter, r *http.Request, change func(ctx context.Context, actor string, ids []uint) ([]uint, error)) {
+	var req queueChangeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+		return
+	}
+
+	affected, err := change(r.Context(), actor(r), req.IDs)
+	if errors.Is(err, sender.ErrInvalidQueueQuery) {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"affected_ids": affected,
+	})
+}
+
+// forceSend sends pending items of the user right away
+func (h *Handler) forceSend(w http.ResponseWriter, r *http.Request) {
+	userID, err := parseUserID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ids, err := h.queue.ForceSend(r.Context(), actor(r), userID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"sent_ids": ids,
+	})
+}
+
+// auditLog returns the latest admin changes. Query params: action, limit.
+func (h *Handler) auditLog(w http.ResponseWriter, r *http.Request) {
+	limit, err := parseLimit(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	list, err := h.queue.AuditLog(r.Context(), r.URL.Query().Get("action"), limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"items": list,
+	})
+}
+
+func parseQueueQuery(r *http.Request) (sender.QueueQuery, error) {
+	values := r.URL.Query()
+	query := sender.QueueQuery{
+		ProposalID:    values.Get("proposal_id"),
+		Status:        sender.QueueStatus(values.Get("status")),
+		CorrelationID: values.Get("correlation_id"),
+	}
+
+	for _, value := range values["id"] {
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return query, fmt.Errorf("invalid id: %w", err)
+		}
+
+		query.IDs = append(query.IDs, uint(id))
+	}
+
+	var err error
+	if query.UserID, err = parseOptionalUUID(r, "user_id"); err != nil {
+		return query, err
+	}
+	if query.DaoID, err = parseOptionalUUID(r, "dao_id"); err != nil {
+		return query, err
+	}
+
+	if value := values.Get("action"); value != "" {
+		for _, action := range strings.Split(value, ",") {
+			query.Actions = append(query.Actions, sender.Action(action))
+		}
+	}
+
+	if query.CreatedAfter, err = parseTime(r, "created_after", time.Time{}); err != nil {
+		return query, err
+	}
+	if query.CreatedBefore, err = parseTime(r, "created_before", time.Time{}); err != nil {
+		return query, err
+	}
+
+	if query.Limit, err = parseLimit(r); err != nil {
+		return query, err
+	}
+
+	return query, nil
+}
+
+func parseLimit(r *http.Request) (int, error) {
+	value := r.URL.Query().Get("limit")
+	if value == "" {
+		return 0, nil
+	}
+
+	limit, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid limit: %w", err)
+	}
+
+	return limit, nil
+}
+
+func actor(r *http.Request) string {
+	if value := strings.TrimSpace(r.Header.Get(actorHeader)); value != "" {
+		return value
+	}
+
+	return defaultActor
+}
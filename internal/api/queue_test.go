@@ -0,0 +1,143 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/goverland-labs/goverland-inbox-push/internal/sender"
+)
+
+type queueStub struct {
+	query  sender.QueueQuery
+	actor  string
+	ids    []uint
+	userID uuid.UUID
+}
+
+func (q *queueStub) QueueItems(_ context.Context, query sender.QueueQuery) ([]sender.QueueItem, error) {
+	q.query = query
+
+	return nil, nil
+}
+
+func (q *queueStub) CancelQueueItems(_ context.Context, actor string, ids []uint) ([]uint, error) {
+	q.actor, q.ids = actor, ids
+
+	return ids, nil
+}
+
+func (q *queueStub) RequeueQueueItems(_ context.Context, actor string, ids []uint) ([]uint, error) {
+	q.actor, q.ids = actor, ids
+
+	return ids[:1], nil
+}
+
+func (q *queueStub) ForceSend(_ context.Context, actor string, userID uuid.UUID) ([]uint, error) {
+	q.actor, q.userID = actor, userID
+
+	return []uint{1}, nil
+}
+
+func (q *queueStub) AuditLog(_ context.Context, _ string, _ int) ([]sender.AuditRecord, error) {
+	return nil, nil
+}
+
+func TestQueueItems(t *testing.T) {
+	userID := uuid.New()
+
+	for name, tc := range map[string]struct {
+		target string
+		status int
+		check  func(t *testing.T, query sender.QueueQuery)
+	}{
+		"invalid user id": {
+			target: "/admin/queue?user_id=wrong",
+			status: http.StatusBadRequest,
+		},
+		"invalid id": {
+			target: "/admin/queue?id=first",
+			status: http.StatusBadRequest,
+		},
+		"invalid created after": {
+			target: "/admin/queue?created_after=yesterday",
+			status: http.StatusBadRequest,
+		},
+		"with filters": {
+			target: "/admin/queue?id=1&id=2&user_id=" + userID.String() +
+				"&action=proposal.created,proposal.voting.ended&status=pending&limit=10",
+			status: http.StatusOK,
+			check: func(t *testing.T, query sender.QueueQuery) {
+				require.Equal(t, []uint{1, 2}, query.IDs)
+				require.Equal(t, &userID, query.UserID)
+				require.Equal(t, []sender.Action{sender.ProposalCreated, sender.ProposalVotingEnded}, query.Actions)
+				require.Equal(t, sender.QueueStatusPending, query.Status)
+				require.Equal(t, 10, query.Limit)
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			stub := &queueStub{}
+
+			rec := doRequest(t, NewHandler(nil, nil, nil, nil, stub, nil), http.MethodGet, tc.target, testToken)
+			require.Equal(t, tc.status, rec.Code)
+			if tc.check != nil {
+				tc.check(t, stub.query)
+			}
+		})
+	}
+}
+
+func TestChangeQueue(t *testing.T) {
+	for name, tc := range map[string]struct {
+		target string
+		body   string
+		status int
+		ids    []uint
+	}{
+		"invalid body": {
+			target: "/admin/queue/cancel",
+			body:   "{",
+			status: http.StatusBadRequest,
+		},
+		"cancel": {
+			target: "/admin/queue/cancel",
+			body:   `{"ids":[3,4]}`,
+			status: http.StatusOK,
+			ids:    []uint{3, 4},
+		},
+		"requeue": {
+			target: "/admin/queue/requeue",
+			body:   `{"ids":[5]}`,
+			status: http.StatusOK,
+			ids:    []uint{5},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			stub := &queueStub{}
+
+			rec := doRequestWithBody(t, NewHandler(nil, nil, nil, nil, stub, nil), http.MethodPost, tc.target, testToken, tc.body)
+			require.Equal(t, tc.status, rec.Code)
+			require.Equal(t, tc.ids, stub.ids)
+			if tc.status == http.StatusOK {
+				require.Equal(t, defaultActor, stub.actor)
+			}
+		})
+	}
+}
+
+func TestForceSend(t *testing.T) {
+	userID := uuid.New()
+	stub := &queueStub{}
+
+	rec := doRequest(t, NewHandler(nil, nil, nil, nil, stub, nil), http.MethodPost, "/admin/users/"+userID.String()+"/queue/send", "")
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = doRequest(t, NewHandler(nil, nil, nil, nil, stub, nil), http.MethodPost, "/admin/users/"+userID.String()+"/queue/send", testToken)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, userID, stub.userID)
+	require.JSONEq(t, `{"sent_ids":[1]}`, rec.Body.String())
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+
+	"github.com/goverland-labs/goverland-inbox-push/internal/config"
+	"github.com/goverland-labs/goverland-inbox-push/internal/sender"
+	"github.com/goverland-labs/goverland-inbox-push/pkg/middleware"
+)
+
+const (
+	readHeaderTimeout = 30 * time.Second
+	requestTimeout    = time.Minute
+)
+
+type Analytics interface {
+	ClickStats(ctx context.Context, query sender.ClickStatsQuery) ([]sender.ClickStats, error)
+}
+
+type Notifications interface {
+	Notifications(ctx context.Context, query sender.NotificationsQuery) (sender.NotificationsPage, error)
+	UnreadNotificationsCount(ctx context.Context, userID uuid.UUID) (int64, error)
+	MarkAsRead(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) error
+}
+
+type HistoryExporter interface {
+	ExportHistory(ctx context.Context, w io.Writer, query sender.HistoryExportQuery) error
+}
+
+type Broadcasts interface {
+	CreateBroadcast(ctx context.Context, req sender.BroadcastRequest) (*sender.BroadcastPreview, error)
+	PreviewBroadcast(ctx context.Context, id uint) (*sender.BroadcastPreview, error)
+	ConfirmBroadcast(ctx context.Context, id uint) (*sender.BroadcastDetails, error)
+	CancelBroadcast(ctx context.Context, id uint) (*sender.BroadcastDetails, error)
+	GetBroadcast(ctx context.Context, id uint) (*sender.BroadcastDetails, error)
+	ListBroadcasts(ctx context.Context, limit int) ([]sender.BroadcastDetails, error)
+}
+
+type QueueManager interface {
+	QueueItems(ctx context.Context, query sender.QueueQuery) ([]sender.QueueItem, error)
+	CancelQueueItems(ctx context.Context, actor string, ids []uint) ([]uint, error)
+	RequeueQueueItems(ctx context.Context, actor string, ids []uint) ([]uint, error)
+	ForceSend(ctx context.Context, actor string, userID uuid.UUID) ([]uint, error)
+	AuditLog(ctx context.Context, action string, limit int) ([]sender.AuditRecord, error)
+}
+
+type TestPusher interface {
+	SendTestPush(ctx context.Context, actor string, req sender.TestPushRequest) ([]sender.TestPushResult, error)
+}
+
+type Handler struct {
+	analytics     Analytics
+	notifications Notifications
+	exporter      HistoryExporter
+	broadcasts    Broadcasts
+	queue         QueueManager
+	testPusher    TestPusher
+}
+
+func NewHandler(a Analytics, n Notifications, e HistoryExporter, b Broadcasts, q QueueManager, t TestPusher) *Handler {
+	return &Handler{
+		analytics:     a,
+		notifications: n,
+		exporter:      e,
+		broadcasts:    b,
+		queue:         q,
+		testPusher:    t,
+	}
+}
+
+// NewServer creates http api server. All routes under /admin require the admin token
+// and routes under /internal require the internal token.
+func NewServer(cfg config.HTTP, h *Handler) *http.Server {
+	router := mux.NewRouter()
+	router.Use(middleware.Panic, middleware.JSON)
+
+	// streaming routes go without the timeout middleware as it buffers the whole response
+	export := router.PathPrefix("/admin/export").Subrouter()
+	export.Use(middleware.BearerToken(cfg.AdminToken))
+	export.HandleFunc("/histories", h.exportHistory).Methods(http.MethodGet)
+
+	admin := router.PathPrefix("/admin").Subrouter()
+	admin.Use(middleware.Timeout(requestTimeout), middleware.BearerToken(cfg.AdminToken))
+	admin.HandleFunc("/analytics/clicks", h.clickStats).Methods(http.MethodGet)
+	admin.HandleFunc("/broadcasts", h.createBroadcast).Methods(http.MethodPost)
+	admin.HandleFunc("/broadcasts", h.broadcastsList).Methods(http.MethodGet)
+	admin.HandleFunc("/broadcasts/{id:[0-9]+}", h.getBroadcast).Methods(http.MethodGet)
+	admin.HandleFunc("/broadcasts/{id:[0-9]+}/preview", h.previewBroadcast).Methods(http.MethodGet)
+	admin.HandleFunc("/broadcasts/{id:[0-9]+}/confirm", h.confirmBroadcast).Methods(http.MethodPost)
+	admin.HandleFunc("/broadcasts/{id:[0-9]+}/cancel", h.cancelBroadcast).Methods(http.MethodPost)
+	admin.HandleFunc("/queue", h.queueItems).Methods(http.MethodGet)
+	admin.HandleFunc("/queue/cancel", h.cancelQueueItems).Methods(http.MethodPost)
+	admin.HandleFunc("/queue/requeue", h.requeueQueueItems).Methods(http.MethodPost)
+	admin.HandleFunc("/users/{user_id}/queue/send", h.forceSend).Methods(http.MethodPost)
+	admin.HandleFunc("/users/{user_id}/test-push", h.testPush).Methods(http.MethodPost)
+	admin.HandleFunc("/audit", h.auditLog).Methods(http.MethodGet)
+
+	internal := router.PathPrefix("/internal").Subrouter()
+	internal.Use(middleware.Timeout(requestTimeout), middleware.BearerToken(cfg.InternalToken))
+	internal.HandleFunc("/users/{user_id}/notifications", h.notificationsList).Methods(http.MethodGet)
+	internal.HandleFunc("/users/{user_id}/notifications/unread-count", h.unreadNotificationsCount).Methods(http.MethodGet)
+	internal.HandleFunc("/users/{user_id}/notifications/read", h.markNotificationsRead).Methods(http.MethodPost)
+
+	return &http.Server{
+		Addr:              cfg.Listen,
+		Handler:           router,
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		log.Error().Err(err).Msg("marshal response")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(status)
+	_, _ = w.Write(data)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	if status >= http.StatusInternalServerError {
+		log.Error().Err(err).Msg("handle api request")
+	}
+
+	writeJSON(w, status, map[string]string{
+		"message": err.Error(),
+	})
+}
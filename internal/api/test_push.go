@@ -0,0 +1,48 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/goverland-labs/goverland-inbox-push/internal/sender"
+)
+
+type testPushRequest struct {
+	DeviceUUID string `json:"device_uuid"`
+}
+
+// testPush sends the test push to all user devices or to the single one from the optional body
+// and returns the firebase result for each device
+func (h *Handler) testPush(w http.ResponseWriter, r *http.Request) {
+	userID, err := parseUserID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var req testPushRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+		return
+	}
+
+	results, err := h.testPusher.SendTestPush(r.Context(), actor(r), sender.TestPushRequest{
+		UserID:     userID,
+		DeviceUUID: req.DeviceUUID,
+	})
+	if errors.Is(err, sender.ErrNoDevices) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"results": results,
+	})
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/goverland-labs/goverland-inbox-push/internal/sender"
+)
+
+type testPusherStub struct {
+	req   sender.TestPushRequest
+	actor string
+	err   error
+}
+
+func (p *testPusherStub) SendTestPush(_ context.Context, actor string, req sender.TestPushRequest) ([]sender.TestPushResult, error) {
+	p.req, p.actor = req, actor
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	return []sender.TestPushResult{{DeviceUUID: "device", MessageID: "message"}}, nil
+}
+
+func TestTestPush(t *testing.T) {
+	userID := uuid.New()
+	target := "/admin/users/" + userID.String() + "/test-push"
+
+	for name, tc := range map[string]struct {
+		target string
+		body   string
+		err    error
+		status int
+		device string
+	}{
+		"invalid user id": {
+			target: "/admin/users/wrong/test-push",
+			status: http.StatusBadRequest,
+		},
+		"invalid body": {
+			target: target,
+			body:   "{",
+			status: http.StatusBadRequest,
+		},
+		"no devices": {
+			target: target,
+			err:    sender.ErrNoDevices,
+			status: http.StatusNotFound,
+		},
+		"all devices": {
+			target: target,
+			status: http.StatusOK,
+		},
+		"single device": {
+			target: target,
+			body:   `{"device_uuid":"device"}`,
+			status: http.StatusOK,
+			device: "device",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			stub := &testPusherStub{err: tc.err}
+
+			rec := doRequestWithBody(t, NewHandler(nil, nil, nil, nil, nil, stub), http.MethodPost, tc.target, testToken, tc.body)
+			require.Equal(t, tc.status, rec.Code)
+			if tc.status != http.StatusOK {
+				return
+			}
+
+			require.Equal(t, userID, stub.req.UserID)
+			require.Equal(t, tc.device, stub.req.DeviceUUID)
+			require.JSONEq(t, `{"results":[{"device_uuid":"device","message_id":"message"}]}`, rec.Body.String())
+		})
+	}
+}
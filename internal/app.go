@@ -1,42 +1,74 @@
 package internal
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	coresdk "github.com/goverland-labs/goverland-core-sdk-go"
 	"github.com/goverland-labs/goverland-inbox-api-protocol/protobuf/inboxapi"
+	"github.com/goverland-labs/goverland-platform-events/pkg/natsclient"
 	"github.com/nats-io/nats.go"
+	"github.com/rs/zerolog/log"
 	"github.com/s-larionov/process-manager"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 
+	"github.com/goverland-labs/goverland-inbox-push/internal/api"
 	"github.com/goverland-labs/goverland-inbox-push/internal/config"
 	"github.com/goverland-labs/goverland-inbox-push/internal/sender"
 	"github.com/goverland-labs/goverland-inbox-push/pkg/health"
+	"github.com/goverland-labs/goverland-inbox-push/pkg/migration"
 	"github.com/goverland-labs/goverland-inbox-push/pkg/prometheus"
+	"github.com/goverland-labs/goverland-inbox-push/pkg/tracing"
+	"github.com/goverland-labs/goverland-inbox-push/resources"
 )
 
+const tracingShutdownTimeout = 5 * time.Second
+
 type Application struct {
 	sigChan <-chan os.Signal
+	stop    chan struct{}
 	manager *process.Manager
 	cfg     config.App
+	live    *config.Live
 	db      *gorm.DB
+	tracing *sdktrace.TracerProvider
+
+	nats         *nats.Conn
+	inboxStorage *grpc.ClientConn
+	repo         sender.Storage
+	service      *sender.Service
+	deadLetters  *sender.DeadLetters
+
+	messageSender sender.MessageSender
+	clock         func() time.Time
 }
 
-func NewApplication(cfg config.App) (*Application, error) {
+func NewApplication(cfg config.App, opts ...Option) (*Application, error) {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
 	a := &Application{
 		sigChan: sigChan,
+		stop:    make(chan struct{}),
 		cfg:     cfg,
+		live:    config.NewLive(cfg),
 		manager: process.NewManager(),
 	}
+	for _, opt := range opts {
+		opt(a)
+	}
 
 	err := a.bootstrap()
 	if err != nil {
@@ -46,20 +78,58 @@ func NewApplication(cfg config.App) (*Application, error) {
 	return a, nil
 }
 
+// newStorageApplication wires the storage only for one-off commands which don't need nats,
+// inbox storage or firebase, so they keep working while those are unavailable. The service
+// has no clients of them and must be used only for storage operations.
+func newStorageApplication(cfg config.App, opts ...Option) (*Application, error) {
+	a := &Application{
+		cfg:  cfg,
+		live: config.NewLive(cfg),
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	if err := a.initDB(); err != nil {
+		return nil, err
+	}
+
+	if a.repo == nil {
+		a.repo = sender.NewRepo(a.db)
+	}
+
+	service, err := sender.NewService(a.repo, nil, sender.DedupPolicy{}, nil, nil, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	a.service = service
+	a.deadLetters = sender.NewDeadLetters(a.repo, nil, service, a.cfg.DeadLetter)
+
+	return a, nil
+}
+
 func (a *Application) Run() {
 	a.manager.StartAll()
 	a.registerShutdown()
 }
 
+// Stop stops workers the same way as the termination signal, Run returns once they are stopped
+func (a *Application) Stop() {
+	close(a.stop)
+}
+
 func (a *Application) bootstrap() error {
 	initializers := []func() error{
+		a.initTracing,
 		a.initDB,
+		a.initMigrations,
 
 		// Init Dependencies
 		a.initServices,
 
 		// Init Workers: Application
-		// TODO
+		a.initAPIWorker,
 
 		// Init Workers: System
 		a.initPrometheusWorker,
@@ -75,24 +145,104 @@ func (a *Application) bootstrap() error {
 	return nil
 }
 
+func (a *Application) initTracing() error {
+	if !a.cfg.Tracing.Enabled {
+		return nil
+	}
+
+	provider, err := tracing.NewProvider(context.Background(), a.cfg.Tracing.ServiceName, a.cfg.Tracing.SampleRatio)
+	if err != nil {
+		return fmt.Errorf("init tracing: %w", err)
+	}
+
+	a.tracing = provider
+
+	return nil
+}
+
 func (a *Application) initDB() error {
-	db, err := gorm.Open(postgres.Open(a.cfg.DB.DSN), &gorm.Config{})
+	if a.repo != nil {
+		return nil
+	}
+
+	db, err := openDB(a.cfg.DB)
 	if err != nil {
 		return err
 	}
 
-	ps, err := db.DB()
+	a.db = db
+
+	return nil
+}
+
+// initMigrations applies embedded migrations if enabled and makes sure the schema is not older than the binary
+func (a *Application) initMigrations() error {
+	if a.db == nil || !a.cfg.Migrations.Auto && !a.cfg.Migrations.Verify {
+		return nil
+	}
+
+	migrations, err := migration.Load(resources.Migrations)
 	if err != nil {
 		return err
 	}
-	ps.SetMaxOpenConns(a.cfg.DB.MaxOpenConnections)
 
-	a.db = db
-	if a.cfg.DB.Debug {
-		a.db = db.Debug()
+	db, err := a.db.DB()
+	if err != nil {
+		return err
 	}
 
-	return err
+	runner := migration.NewRunner(db)
+	ctx := context.Background()
+
+	if a.cfg.Migrations.Auto {
+		err := runner.Apply(ctx, migrations, func(item migration.Migration) {
+			log.Info().Int("version", item.Version).Str("name", item.Name).Msg("migration is applied")
+		})
+		if err != nil {
+			return fmt.Errorf("apply migrations: %w", err)
+		}
+	}
+
+	if a.cfg.Migrations.Verify {
+		err := runner.Verify(ctx, migrations)
+		switch {
+		case errors.Is(err, migration.ErrNoVersions):
+			// the schema migrated by hand is not refused, its version is unknown until the baseline
+			log.Warn().Err(err).
+				Int("expected_version", migrations[len(migrations)-1].Version).
+				Msg("schema version is not recorded, run migrate -baseline with the version applied by hand")
+		case err != nil:
+			return fmt.Errorf("verify schema: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func openDB(cfg config.DB) (*gorm.DB, error) {
+	db, err := gorm.Open(postgres.Open(cfg.DSN), &gorm.Config{
+		// unique violations are reported as gorm.ErrDuplicatedKey, the same as by the memory storage
+		TranslateError: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Use(tracing.GormPlugin{}); err != nil {
+		return nil, fmt.Errorf("use tracing plugin: %w", err)
+	}
+
+	ps, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	ps.SetMaxOpenConns(cfg.MaxOpenConnections)
+
+	if cfg.Debug {
+		return db.Debug(), nil
+	}
+
+	return db, nil
 }
 
 func (a *Application) initServices() error {
@@ -109,6 +259,7 @@ func (a *Application) initServices() error {
 	conn, err := grpc.NewClient(
 		a.cfg.InternalAPI.InboxStorageAddress,
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
 	)
 	if err != nil {
 		return fmt.Errorf("create connection with core storage server: %v", err)
@@ -117,25 +268,90 @@ func (a *Application) initServices() error {
 	subs := inboxapi.NewSubscriptionClient(conn)
 	usrs := inboxapi.NewUserClient(conn)
 	sp := inboxapi.NewSettingsClient(conn)
-	coreSDK := coresdk.NewClient(a.cfg.Core.CoreURL)
+	coreSDK := coresdk.NewClient(a.cfg.Core.CoreURL, &http.Client{
+		Transport: otelhttp.NewTransport(http.DefaultTransport),
+	})
 
-	repo := sender.NewRepo(a.db)
-	service, err := sender.NewService(repo, a.cfg.Push, subs, usrs, sp, coreSDK)
+	dedup, err := sender.NewDedupPolicy(a.cfg.Dedup)
 	if err != nil {
 		return err
 	}
 
-	dc, err := sender.NewConsumer(nc, service)
+	experiments, err := sender.NewExperiments(a.cfg.Experiments)
+	if err != nil {
+		return fmt.Errorf("load experiments: %w", err)
+	}
+
+	var firebase *sender.FirebaseProjects
+	if a.messageSender == nil {
+		firebase, err = sender.NewFirebaseProjects(a.cfg.Push)
+		if err != nil {
+			return fmt.Errorf("create firebase projects: %w", err)
+		}
+
+		a.messageSender = firebase
+	}
+
+	repo := a.repo
+	if repo == nil {
+		repo = sender.NewRepo(a.db)
+	}
+
+	service, err := sender.NewService(repo, a.messageSender, dedup, experiments, subs, usrs, sp, coreSDK)
+	if err != nil {
+		return err
+	}
+	if a.clock != nil {
+		service.SetClock(a.clock)
+	}
+
+	a.nats = nc
+	a.inboxStorage = conn
+	a.repo = repo
+	a.service = service
+
+	publisher, err := natsclient.NewPublisher(nc)
+	if err != nil {
+		return fmt.Errorf("create publisher: %w", err)
+	}
+
+	a.deadLetters = sender.NewDeadLetters(repo, publisher, service, a.cfg.DeadLetter)
+
+	dc, err := sender.NewConsumer(nc, service, a.deadLetters, a.cfg.DeadLetter)
 	if err != nil {
 		return fmt.Errorf("sender consumer: %w", err)
 	}
 
-	postman := sender.NewPostmanWorker(service)
+	postman := sender.NewPostmanWorker(service, a.live.Postman)
+	outbox := sender.NewOutboxWorker(repo, publisher)
+	analytics := sender.NewAnalyticsWorker(service)
+	dedupCleanup := sender.NewDedupWorker(repo, a.live.Dedup)
+	inboxCleanup := sender.NewInboxWorker(repo, a.cfg.Inbox)
+	queueMetrics := sender.NewQueueMetricsWorker(repo)
+	broadcasts := sender.NewBroadcastWorker(service, a.live.Broadcast)
 
 	a.manager.AddWorker(process.NewCallbackWorker("sender-consumer", dc.Start))
 	a.manager.AddWorker(process.NewCallbackWorker("postman-voting-ends-soon", postman.StartVotingEndsSoon))
 	a.manager.AddWorker(process.NewCallbackWorker("postman-delegate", postman.StartDelegates))
 	a.manager.AddWorker(process.NewCallbackWorker("postman-regular", postman.StartRegular))
+	a.manager.AddWorker(process.NewCallbackWorker("outbox", outbox.Start))
+	a.manager.AddWorker(process.NewCallbackWorker("analytics", analytics.Start))
+	a.manager.AddWorker(process.NewCallbackWorker("dedup-cleanup", dedupCleanup.Start))
+	a.manager.AddWorker(process.NewCallbackWorker("inbox-cleanup", inboxCleanup.Start))
+	a.manager.AddWorker(process.NewCallbackWorker("experiments", experiments.Start))
+	a.manager.AddWorker(process.NewCallbackWorker("queue-metrics", queueMetrics.Start))
+	a.manager.AddWorker(process.NewCallbackWorker("broadcast", broadcasts.Start))
+	if firebase != nil {
+		a.manager.AddWorker(process.NewCallbackWorker("firebase-credentials", firebase.Start))
+	}
+	a.manager.AddWorker(process.NewCallbackWorker("config-watcher", config.NewWatcher(a.live).Start))
+
+	return nil
+}
+
+func (a *Application) initAPIWorker() error {
+	srv := api.NewServer(a.cfg.HTTP, api.NewHandler(a.service, a.service, a.service, a.service, a.service, a.service))
+	a.manager.AddWorker(process.NewServerWorker("api", srv))
 
 	return nil
 }
@@ -148,18 +364,79 @@ func (a *Application) initPrometheusWorker() error {
 }
 
 func (a *Application) initHealthWorker() error {
-	srv := health.NewHealthCheckServer(a.cfg.Health.Listen, "/status", health.DefaultHandler(a.manager))
+	checks, err := a.readinessChecks()
+	if err != nil {
+		return err
+	}
+
+	srv := health.NewHealthCheckServer(a.cfg.Health.Listen, map[string]http.Handler{
+		"/status": health.DefaultHandler(a.manager),
+		"/livez":  health.LivenessHandler(a.manager),
+		"/readyz": health.ReadinessHandler(a.cfg.Health.CheckTimeout, checks),
+	})
 	a.manager.AddWorker(process.NewServerWorker("health", srv))
 
 	return nil
 }
 
+func (a *Application) readinessChecks() (map[string]health.Check, error) {
+	checks := map[string]health.Check{
+		"nats": func(_ context.Context) error {
+			if status := a.nats.Status(); status != nats.CONNECTED {
+				return fmt.Errorf("connection status: %s", status)
+			}
+
+			return nil
+		},
+		"inbox_storage": func(ctx context.Context) error {
+			return health.GRPCConnReady(ctx, a.inboxStorage)
+		},
+	}
+
+	if a.db != nil {
+		db, err := a.db.DB()
+		if err != nil {
+			return nil, err
+		}
+
+		checks["postgres"] = db.PingContext
+	}
+
+	// replaced message sender has no firebase credentials
+	if _, ok := a.messageSender.(*sender.FirebaseProjects); ok {
+		checks["firebase"] = a.service.CheckCredentials
+	}
+
+	maxAges, err := a.cfg.Health.WorkerMaxAges()
+	if err != nil {
+		return nil, err
+	}
+
+	for worker, maxAge := range maxAges {
+		checks["worker:"+worker] = sender.WorkerCheck(worker, maxAge)
+	}
+
+	return checks, nil
+}
+
 func (a *Application) registerShutdown() {
 	go func(manager *process.Manager) {
-		<-a.sigChan
+		select {
+		case <-a.sigChan:
+		case <-a.stop:
+		}
 
 		manager.StopAll()
 	}(a.manager)
 
 	a.manager.AwaitAll()
+
+	if a.tracing != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+		defer cancel()
+
+		if err := a.tracing.Shutdown(ctx); err != nil {
+			log.Error().Err(err).Msg("shutdown tracing")
+		}
+	}
 }
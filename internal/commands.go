@@ -0,0 +1,552 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	iofs "io/fs"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/goverland-labs/goverland-inbox-push/internal/config"
+	"github.com/goverland-labs/goverland-inbox-push/internal/sender"
+	"github.com/goverland-labs/goverland-inbox-push/pkg/migration"
+	"github.com/goverland-labs/goverland-inbox-push/resources"
+)
+
+const (
+	defaultListLimit   = 50
+	defaultReplayLimit = 1000
+
+	defaultActor = "cli"
+)
+
+const usage = `usage: application [command]
+
+commands:
+  serve                      run the service, default
+  migrate [flags]            apply pending schema migrations
+  config check               validate the configuration without connecting to anything
+  queue stats                show pending queue items by action
+  queue requeue [flags] ids  make sent queue items pending again
+  send-test [flags]          send the test push to user devices
+  replay [flags]             process feed events from the stream again
+  history export [flags]     export push history
+  dead-letter list|inspect <id>|replay <id>
+`
+
+// RunCommand runs the command from the command line arguments, the service is served without a command.
+// Commands share the configuration and the wiring of the service, so they can run as one-off jobs
+// with the same image. Queue, history and read-only dead letter commands open the database only.
+func RunCommand(ctx context.Context, cfg config.App, args []string, out io.Writer) error {
+	command := "serve"
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+
+	// commands which don't need the whole application
+	switch command {
+	case "help", "-h", "-help", "--help":
+		_, _ = fmt.Fprint(out, usage)
+
+		return nil
+	case "migrate":
+		return RunMigrateCommand(ctx, cfg, args, out)
+	case "config":
+		return RunConfigCommand(cfg, args, out)
+	}
+
+	var (
+		app *Application
+		err error
+	)
+	if storageCommand(command, args) {
+		app, err = newStorageApplication(cfg)
+	} else {
+		app, err = NewApplication(cfg)
+	}
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "serve":
+		app.Run()
+
+		return nil
+	case "queue":
+		return app.RunQueueCommand(ctx, args, out)
+	case "send-test":
+		return app.RunSendTestCommand(ctx, args, out)
+	case "dead-letter":
+		return app.RunDeadLetterCommand(ctx, args, out)
+	case "replay":
+		return app.RunReplayCommand(ctx, args, out)
+	case "history":
+		return app.RunHistoryCommand(ctx, args, out)
+	default:
+		return fmt.Errorf("unknown command: %s\n%s", command, usage)
+	}
+}
+
+// storageCommand reports whether the command works with the storage only
+func storageCommand(command string, args []string) bool {
+	switch command {
+	case "queue", "history":
+		return true
+	case "dead-letter":
+		return len(args) > 0 && (args[0] == "list" || args[0] == "inspect")
+	default:
+		return false
+	}
+}
+
+// RunMigrateCommand applies pending migrations in version order, the embedded ones by default
+func RunMigrateCommand(ctx context.Context, cfg config.App, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	dir := fs.String("dir", "", "directory with migration files instead of the embedded ones")
+	dryRun := fs.Bool("dry-run", false, "print pending migrations without applying them")
+	baseline := fs.Int("baseline", -1, "mark migrations up to the version as applied without running them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var source iofs.FS = resources.Migrations
+	if *dir != "" {
+		source = os.DirFS(*dir)
+	}
+
+	migrations, err := migration.Load(source)
+	if err != nil {
+		return err
+	}
+
+	db, err := openDB(cfg.DB)
+	if err != nil {
+		return fmt.Errorf("open db: %w", err)
+	}
+
+	conn, err := db.DB()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	runner := migration.NewRunner(conn)
+
+	if *baseline >= 0 {
+		marked, err := runner.Baseline(ctx, migrations, *baseline)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range marked {
+			_, _ = fmt.Fprintf(out, "baseline %d_%s\n", item.Version, item.Name)
+		}
+
+		return nil
+	}
+
+	if *dryRun {
+		pending, err := runner.Pending(ctx, migrations)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range pending {
+			_, _ = fmt.Fprintf(out, "pending %d_%s\n", item.Version, item.Name)
+		}
+
+		return nil
+	}
+
+	applied := 0
+	err = runner.Apply(ctx, migrations, func(item migration.Migration) {
+		applied++
+		_, _ = fmt.Fprintf(out, "applied %d_%s\n", item.Version, item.Name)
+	})
+	if err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(out, "migrations applied: %d\n", applied)
+
+	return nil
+}
+
+// RunConfigCommand handles the configuration: check
+func RunConfigCommand(cfg config.App, args []string, out io.Writer) error {
+	if len(args) == 0 || args[0] != "check" {
+		return fmt.Errorf("usage: config check")
+	}
+
+	if err := checkConfig(cfg); err != nil {
+		return fmt.Errorf("invalid config:\n%w", err)
+	}
+
+	_, _ = fmt.Fprintln(out, "config is valid")
+
+	return nil
+}
+
+// checkConfig validates the configuration the same way the service does on start
+func checkConfig(cfg config.App) error {
+	errs := []error{cfg.Validate()}
+	if cfg.Push.Enabled {
+		if err := sender.ValidateCredentials(cfg.Push); err != nil {
+			errs = append(errs, fmt.Errorf("PUSH_*: %w", err))
+		}
+	}
+	for _, project := range cfg.Push.Projects {
+		if !project.Enabled {
+			continue
+		}
+
+		if err := sender.ValidateCredentials(config.Push{CredentialsFile: project.CredentialsFile}); err != nil {
+			errs = append(errs, fmt.Errorf("push project %s: %w", project.Name, err))
+		}
+	}
+	if _, err := sender.NewDedupPolicy(cfg.Dedup); err != nil {
+		errs = append(errs, fmt.Errorf("dedup: %w", err))
+	}
+	if _, err := sender.NewExperiments(cfg.Experiments); err != nil {
+		errs = append(errs, fmt.Errorf("EXPERIMENTS_FILE: %w", err))
+	}
+
+	return errors.Join(errs...)
+}
+
+// RunQueueCommand handles the send queue: stats and requeue
+func (a *Application) RunQueueCommand(ctx context.Context, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: queue stats|requeue [-actor name] <id>...")
+	}
+
+	switch args[0] {
+	case "stats":
+		list, err := a.repo.PendingQueueStats(ctx)
+		if err != nil {
+			return fmt.Errorf("pending queue stats: %w", err)
+		}
+
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "ACTION\tPENDING\tOLDEST CREATED AT")
+		for _, item := range list {
+			_, _ = fmt.Fprintf(w, "%s\t%d\t%s\n", item.Action, item.Count, item.OldestCreatedAt.Format(time.RFC3339))
+		}
+
+		return w.Flush()
+	case "requeue":
+		fs := flag.NewFlagSet("queue requeue", flag.ContinueOnError)
+		actor := fs.String("actor", defaultActor, "name of the operator for the audit log")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		ids := make([]uint, 0, fs.NArg())
+		for _, value := range fs.Args() {
+			id, err := parseID([]string{value})
+			if err != nil {
+				return err
+			}
+
+			ids = append(ids, id)
+		}
+
+		affected, err := a.service.RequeueQueueItems(ctx, *actor, ids)
+		if err != nil {
+			return err
+		}
+
+		_, _ = fmt.Fprintf(out, "requeued: %v\n", affected)
+
+		return nil
+	default:
+		return fmt.Errorf("unknown queue command: %s", args[0])
+	}
+}
+
+// RunSendTestCommand sends the test push to the user devices and prints the firebase result per device
+func (a *Application) RunSendTestCommand(ctx context.Context, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("send-test", flag.ContinueOnError)
+	userID := fs.String("user", "", "user id, required")
+	device := fs.String("device", "", "send to the single device of the user")
+	actor := fs.String("actor", defaultActor, "name of the operator for the audit log")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	id, err := parseOptionalUUID("user", *userID)
+	if err != nil {
+		return err
+	}
+	if id == nil {
+		return fmt.Errorf("-user is required")
+	}
+
+	results, err := a.service.SendTestPush(ctx, *actor, sender.TestPushRequest{
+		UserID:     *id,
+		DeviceUUID: *device,
+	})
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "DEVICE\tMESSAGE ID\tERROR CLASS\tERROR")
+	for _, item := range results {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", item.DeviceUUID, item.MessageID, item.ErrorClass, item.Error)
+	}
+
+	return w.Flush()
+}
+
+// RunDeadLetterCommand handles dead letter management: list, inspect <id> and replay <id>
+func (a *Application) RunDeadLetterCommand(ctx context.Context, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: dead-letter list|inspect <id>|replay <id>")
+	}
+
+	switch args[0] {
+	case "list":
+		fs := flag.NewFlagSet("dead-letter list", flag.ContinueOnError)
+		subject := fs.String("subject", "", "filter by subject")
+		limit := fs.Int("limit", defaultListLimit, "max number of items")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		list, err := a.deadLetters.List(ctx, *subject, *limit)
+		if err != nil {
+			return fmt.Errorf("list dead letters: %w", err)
+		}
+
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "ID\tCREATED AT\tSUBJECT\tDELIVERIES\tREPLAYED\tERROR")
+		for _, item := range list {
+			_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%t\t%s\n",
+				item.ID,
+				item.CreatedAt.Format(time.RFC3339),
+				item.Subject,
+				item.Deliveries,
+				item.ReplayedAt != nil,
+				item.Error,
+			)
+		}
+
+		return w.Flush()
+	case "inspect":
+		id, err := parseID(args[1:])
+		if err != nil {
+			return err
+		}
+
+		item, err := a.deadLetters.Get(ctx, id)
+		if err != nil {
+			return fmt.Errorf("get dead letter: %w", err)
+		}
+
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(item)
+	case "replay":
+		id, err := parseID(args[1:])
+		if err != nil {
+			return err
+		}
+
+		if err := a.deadLetters.Replay(ctx, id); err != nil {
+			return err
+		}
+
+		_, _ = fmt.Fprintf(out, "dead letter %d replayed\n", id)
+
+		return nil
+	default:
+		return fmt.Errorf("unknown dead-letter command: %s", args[0])
+	}
+}
+
+func parseID(args []string) (uint, error) {
+	if len(args) == 0 {
+		return 0, fmt.Errorf("id is required")
+	}
+
+	id, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid id %s: %w", args[0], err)
+	}
+
+	return uint(id), nil
+}
+
+// RunReplayCommand replays feed events from the stream through the fan-out
+func (a *Application) RunReplayCommand(ctx context.Context, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	from := fs.String("from", "", "start time in RFC3339 format")
+	seq := fs.Uint64("seq", 0, "start stream sequence, has priority over -from")
+	daos := fs.String("dao", "", "comma separated dao ids")
+	proposals := fs.String("proposal", "", "comma separated proposal ids")
+	actions := fs.String("action", "", "comma separated actions")
+	dryRun := fs.Bool("dry-run", false, "print what would be queued without storing anything")
+	limit := fs.Int("limit", defaultReplayLimit, "max number of queued pushes, 0 means no limit")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	opts := sender.ReplayOptions{
+		StartSeq:  *seq,
+		DryRun:    *dryRun,
+		MaxPushes: *limit,
+		Filter: sender.ReplayFilter{
+			ProposalIDs: splitList(*proposals),
+		},
+	}
+
+	if *from != "" {
+		start, err := time.Parse(time.RFC3339, *from)
+		if err != nil {
+			return fmt.Errorf("invalid -from: %w", err)
+		}
+		opts.StartTime = start
+	}
+
+	for _, id := range splitList(*daos) {
+		daoID, err := uuid.Parse(id)
+		if err != nil {
+			return fmt.Errorf("invalid dao id %s: %w", id, err)
+		}
+		opts.Filter.DaoIDs = append(opts.Filter.DaoIDs, daoID)
+	}
+
+	for _, action := range splitList(*actions) {
+		opts.Filter.Actions = append(opts.Filter.Actions, sender.Action(action))
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "SEQ\tDAO\tPROPOSAL\tACTION\tUSER\tOUTCOME")
+
+	stats, err := sender.NewReplay(a.nats, a.service).Run(ctx, opts, func(seq uint64, item sender.Item, outcomes []sender.FanoutLog) {
+		for _, info := range outcomes {
+			_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", seq, item.DaoID, item.ProposalID, item.Action, info.UserID, info.Outcome)
+		}
+	})
+	if flushErr := w.Flush(); flushErr != nil {
+		return flushErr
+	}
+
+	_, _ = fmt.Fprintf(out, "events: %d, matched: %d, queued: %d, dry run: %t\n", stats.Events, stats.Matched, stats.Queued, *dryRun)
+
+	return err
+}
+
+// RunHistoryCommand handles push history: export
+func (a *Application) RunHistoryCommand(ctx context.Context, args []string, out io.Writer) error {
+	if len(args) == 0 || args[0] != "export" {
+		return fmt.Errorf("usage: history export [flags]")
+	}
+
+	fs := flag.NewFlagSet("history export", flag.ContinueOnError)
+	userID := fs.String("user", "", "filter by user id")
+	daoID := fs.String("dao", "", "filter by dao id")
+	templateID := fs.Int("template", 0, "filter by template id")
+	from := fs.String("from", "", "start time in RFC3339 format")
+	to := fs.String("to", "", "end time in RFC3339 format")
+	format := fs.String("format", string(sender.ExportCSV), "output format: csv or jsonl")
+	gzip := fs.Bool("gzip", false, "compress the output")
+	output := fs.String("out", "", "output file, stdout by default")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	query := sender.HistoryExportQuery{
+		Format: sender.ExportFormat(*format),
+		Gzip:   *gzip,
+	}
+
+	var err error
+	if query.UserID, err = parseOptionalUUID("user", *userID); err != nil {
+		return err
+	}
+	if query.DaoID, err = parseOptionalUUID("dao", *daoID); err != nil {
+		return err
+	}
+	if *templateID != 0 {
+		query.TemplateID = templateID
+	}
+	if query.From, err = parseOptionalTime("from", *from); err != nil {
+		return err
+	}
+	if query.To, err = parseOptionalTime("to", *to); err != nil {
+		return err
+	}
+
+	if !query.Format.Valid() {
+		return fmt.Errorf("invalid -format: %s", *format)
+	}
+
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("create output file: %w", err)
+		}
+
+		// the export is incomplete if the file is not flushed on close
+		err = a.service.ExportHistory(ctx, f, query)
+		if closeErr := f.Close(); closeErr != nil {
+			err = errors.Join(err, fmt.Errorf("close output file: %w", closeErr))
+		}
+
+		return err
+	}
+
+	return a.service.ExportHistory(ctx, out, query)
+}
+
+func parseOptionalUUID(name, value string) (*uuid.UUID, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -%s: %w", name, err)
+	}
+
+	return &id, nil
+}
+
+func parseOptionalTime(name, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid -%s: %w", name, err)
+	}
+
+	return parsed, nil
+}
+
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+
+	list := strings.Split(value, ",")
+	for idx := range list {
+		list[idx] = strings.TrimSpace(list[idx])
+	}
+
+	return list
+}
@@ -0,0 +1,92 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/goverland-labs/goverland-inbox-push/internal/config"
+	"github.com/goverland-labs/goverland-inbox-push/internal/sender"
+)
+
+func TestCheckConfigAggregatesErrors(t *testing.T) {
+	err := checkConfig(config.App{
+		LogLevel: "verbose",
+		Push: config.Push{
+			Enabled: true,
+		},
+		Health: config.Health{
+			WorkerMaxAge: config.StringMap{"outbox": "soon"},
+		},
+		Dedup: config.Dedup{
+			DefaultWindow: "24h",
+		},
+		Tracing: config.Tracing{
+			SampleRatio: 2,
+		},
+	})
+	require.Error(t, err)
+
+	for _, expected := range []string{
+		"LOG_LEVEL",
+		"POSTGRES_DSN is required",
+		"NATS_URL is required",
+		"PUSH_*",
+		"HEALTH_WORKER_MAX_AGE",
+		"TRACING_SAMPLE_RATIO",
+		"BROADCAST_RATE",
+	} {
+		require.ErrorContains(t, err, expected)
+	}
+}
+
+func TestRunCommandHelp(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, RunCommand(context.Background(), config.App{}, []string{"help"}, &out))
+	require.Contains(t, out.String(), "config check")
+}
+
+func TestStorageCommand(t *testing.T) {
+	for name, tc := range map[string]struct {
+		args     []string
+		expected bool
+	}{
+		"queue stats":         {args: []string{"queue", "stats"}, expected: true},
+		"queue requeue":       {args: []string{"queue", "requeue", "1"}, expected: true},
+		"history export":      {args: []string{"history", "export"}, expected: true},
+		"dead-letter list":    {args: []string{"dead-letter", "list"}, expected: true},
+		"dead-letter inspect": {args: []string{"dead-letter", "inspect", "1"}, expected: true},
+		"dead-letter replay":  {args: []string{"dead-letter", "replay", "1"}, expected: false},
+		"dead-letter":         {args: []string{"dead-letter"}, expected: false},
+		"replay":              {args: []string{"replay"}, expected: false},
+		"send-test":           {args: []string{"send-test"}, expected: false},
+		"serve":               {args: []string{"serve"}, expected: false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.expected, storageCommand(tc.args[0], tc.args[1:]))
+		})
+	}
+}
+
+func TestStorageCommandsWithoutNats(t *testing.T) {
+	// nothing listens there, the storage commands must not connect to it
+	app, err := newStorageApplication(config.App{
+		Nats: config.Nats{URL: "nats://127.0.0.1:1"},
+	}, WithStorage(sender.NewMemoryStorage()))
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, app.RunQueueCommand(context.Background(), []string{"stats"}, &out))
+	require.Contains(t, out.String(), "PENDING")
+
+	path := filepath.Join(t.TempDir(), "histories.csv")
+	require.NoError(t, app.RunHistoryCommand(context.Background(), []string{"export", "-out", path}, &out))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NotEmpty(t, data)
+}
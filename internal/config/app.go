@@ -1,12 +1,28 @@
 package config
 
+import "time"
+
 type App struct {
-	LogLevel    string `env:"LOG_LEVEL" envDefault:"info"`
-	Prometheus  Prometheus
-	Health      Health
-	Nats        Nats
-	Push        Push
-	DB          DB
-	InternalAPI API
-	Core        Core
+	// ConfigFiles are YAML or JSON files applied in order under env vars
+	ConfigFiles []string `env:"CONFIG_FILE" envSeparator:"," yaml:"-"`
+	// ConfigWatchInterval is how often config files are checked for changes
+	ConfigWatchInterval time.Duration `env:"CONFIG_WATCH_INTERVAL" envDefault:"10s" yaml:"-"`
+
+	LogLevel    string      `env:"LOG_LEVEL" envDefault:"info" yaml:"log_level"`
+	Prometheus  Prometheus  `yaml:"prometheus"`
+	Health      Health      `yaml:"health"`
+	HTTP        HTTP        `yaml:"http"`
+	Nats        Nats        `yaml:"nats"`
+	Push        Push        `yaml:"push"`
+	DB          DB          `yaml:"postgres"`
+	Migrations  Migrations  `yaml:"migrations"`
+	InternalAPI API         `yaml:"internal_api"`
+	Core        Core        `yaml:"core"`
+	DeadLetter  DeadLetter  `yaml:"dead_letter"`
+	Dedup       Dedup       `yaml:"dedup"`
+	Inbox       Inbox       `yaml:"inbox"`
+	Experiments Experiments `yaml:"experiments"`
+	Tracing     Tracing     `yaml:"tracing"`
+	Broadcast   Broadcast   `yaml:"broadcast"`
+	Postman     Postman     `yaml:"postman"`
 }
@@ -0,0 +1,14 @@
+package config
+
+import "time"
+
+type Broadcast struct {
+	// Enabled pauses sending of due broadcasts when false
+	Enabled bool `env:"BROADCAST_ENABLED" envDefault:"true" yaml:"enabled"`
+	// Rate is the max number of broadcast recipients handled per second
+	Rate          int           `env:"BROADCAST_RATE" envDefault:"20" yaml:"rate"`
+	CheckInterval time.Duration `env:"BROADCAST_CHECK_INTERVAL" envDefault:"30s" yaml:"check_interval"`
+	// Lease is how long the sending broadcast may go without saving progress before another run
+	// claims it again, e.g. after the crash of the instance which was sending it
+	Lease time.Duration `env:"BROADCAST_LEASE" envDefault:"10m" yaml:"lease"`
+}
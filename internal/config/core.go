@@ -1,5 +1,5 @@
 package config
 
 type Core struct {
-	CoreURL string `env:"CORE_URL" envDefault:"https://core.goverland.xyz/v1"`
+	CoreURL string `env:"CORE_URL" envDefault:"https://core.goverland.xyz/v1" yaml:"url"`
 }
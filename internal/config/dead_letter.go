@@ -0,0 +1,6 @@
+package config
+
+type DeadLetter struct {
+	MaxDeliveries int    `env:"DEAD_LETTER_MAX_DELIVERIES" envDefault:"10" yaml:"max_deliveries"`
+	Subject       string `env:"DEAD_LETTER_SUBJECT" envDefault:"inbox.push.dead_letter" yaml:"subject"`
+}
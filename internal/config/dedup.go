@@ -0,0 +1,11 @@
+package config
+
+import "time"
+
+type Dedup struct {
+	// DefaultWindow is used for actions without own window, "forever" means the push is never sent again
+	DefaultWindow string `env:"DEDUP_DEFAULT_WINDOW" envDefault:"24h" yaml:"default_window"`
+	// Windows by action, e.g. proposal.voting.ends_soon:6h,proposal.created:forever
+	Windows         StringMap     `env:"DEDUP_WINDOWS" envDefault:"proposal.voting.ends_soon:6h,proposal.created:forever" yaml:"windows"`
+	CleanupInterval time.Duration `env:"DEDUP_CLEANUP_INTERVAL" envDefault:"1h" yaml:"cleanup_interval"`
+}
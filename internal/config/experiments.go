@@ -0,0 +1,9 @@
+package config
+
+import "time"
+
+type Experiments struct {
+	// File with template experiments, experiments are disabled if it is empty
+	File           string        `env:"EXPERIMENTS_FILE" yaml:"file"`
+	ReloadInterval time.Duration `env:"EXPERIMENTS_RELOAD_INTERVAL" envDefault:"1m" yaml:"reload_interval"`
+}
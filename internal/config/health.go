@@ -1,5 +1,28 @@
 package config
 
+import (
+	"fmt"
+	"time"
+)
+
 type Health struct {
-	Listen string `env:"HEALTH_LISTEN" envDefault:":3000"`
+	Listen       string        `env:"HEALTH_LISTEN" envDefault:":3000" yaml:"listen"`
+	CheckTimeout time.Duration `env:"HEALTH_CHECK_TIMEOUT" envDefault:"3s" yaml:"check_timeout"`
+	// WorkerMaxAge is the max time since the last successful run of the worker for readiness
+	WorkerMaxAge StringMap `env:"HEALTH_WORKER_MAX_AGE" envDefault:"postman-regular:15m,postman-voting-ends-soon:15m,postman-delegate:15m,outbox:5m,broadcast:5m" yaml:"worker_max_age"`
+}
+
+// WorkerMaxAges parses max ages of workers
+func (h Health) WorkerMaxAges() (map[string]time.Duration, error) {
+	result := make(map[string]time.Duration, len(h.WorkerMaxAge))
+	for worker, value := range h.WorkerMaxAge {
+		maxAge, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("parse max age of %s worker: %w", worker, err)
+		}
+
+		result[worker] = maxAge
+	}
+
+	return result, nil
 }
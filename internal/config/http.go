@@ -0,0 +1,8 @@
+package config
+
+type HTTP struct {
+	Listen     string `env:"HTTP_LISTEN" envDefault:":3001" yaml:"listen"`
+	AdminToken string `env:"HTTP_ADMIN_TOKEN" yaml:"admin_token"`
+	// InternalToken protects the api used by other platform services, e.g. the notification center
+	InternalToken string `env:"HTTP_INTERNAL_TOKEN" yaml:"internal_token"`
+}
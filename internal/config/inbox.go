@@ -0,0 +1,9 @@
+package config
+
+import "time"
+
+type Inbox struct {
+	// Retention is how long processed feed events are kept to skip their redeliveries
+	Retention       time.Duration `env:"INBOX_RETENTION" envDefault:"168h" yaml:"retention"`
+	CleanupInterval time.Duration `env:"INBOX_CLEANUP_INTERVAL" envDefault:"1h" yaml:"cleanup_interval"`
+}
@@ -1,5 +1,5 @@
 package config
 
 type API struct {
-	InboxStorageAddress string `env:"INTERNAL_API_INBOX_STORAGE_ADDRESS" envDefault:"localhost:11100"`
+	InboxStorageAddress string `env:"INTERNAL_API_INBOX_STORAGE_ADDRESS" envDefault:"localhost:11100" yaml:"inbox_storage_address"`
 }
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"reflect"
+	"sync/atomic"
+)
+
+// Live keeps the config which is changed at runtime, only reloadable fields are ever updated:
+// the log level, postman and broadcast settings and the dedup cleanup interval
+type Live struct {
+	current atomic.Pointer[App]
+}
+
+func NewLive(cfg App) *Live {
+	l := &Live{}
+	l.current.Store(&cfg)
+
+	return l
+}
+
+func (l *Live) Get() App {
+	return *l.current.Load()
+}
+
+func (l *Live) Postman() Postman {
+	return l.current.Load().Postman
+}
+
+func (l *Live) Broadcast() Broadcast {
+	return l.current.Load().Broadcast
+}
+
+func (l *Live) Dedup() Dedup {
+	return l.current.Load().Dedup
+}
+
+// Apply updates reloadable fields from next. It returns names of changed reloadable fields
+// and names of sections which are changed too, but need the restart to be applied.
+func (l *Live) Apply(next App) (changed, restart []string) {
+	current := l.Get()
+
+	merged := current
+	merged.LogLevel = next.LogLevel
+	merged.Postman = next.Postman
+	merged.Broadcast = next.Broadcast
+	merged.Dedup.CleanupInterval = next.Dedup.CleanupInterval
+
+	for name, values := range map[string][2]any{
+		"log_level":              {current.LogLevel, merged.LogLevel},
+		"postman":                {current.Postman, merged.Postman},
+		"broadcast":              {current.Broadcast, merged.Broadcast},
+		"dedup.cleanup_interval": {current.Dedup.CleanupInterval, merged.Dedup.CleanupInterval},
+	} {
+		if values[0] != values[1] {
+			changed = append(changed, name)
+		}
+	}
+
+	mergedValue, nextValue := reflect.ValueOf(merged), reflect.ValueOf(next)
+	for idx := 0; idx < mergedValue.NumField(); idx++ {
+		if !reflect.DeepEqual(mergedValue.Field(idx).Interface(), nextValue.Field(idx).Interface()) {
+			restart = append(restart, sectionName(mergedValue.Type().Field(idx)))
+		}
+	}
+
+	l.current.Store(&merged)
+
+	return changed, restart
+}
+
+func sectionName(field reflect.StructField) string {
+	if name := field.Tag.Get("yaml"); name != "" && name != "-" {
+		return name
+	}
+
+	return field.Name
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLiveApply(t *testing.T) {
+	current := App{
+		LogLevel:  "info",
+		HTTP:      HTTP{Listen: ":3001"},
+		Broadcast: Broadcast{Enabled: true, Rate: 20, CheckInterval: time.Minute},
+		Postman:   Postman{Enabled: true, Interval: time.Minute},
+		Dedup:     Dedup{DefaultWindow: "24h", CleanupInterval: time.Hour},
+	}
+	live := NewLive(current)
+
+	next := current
+	next.Broadcast.Rate = 100
+	next.Postman.Enabled = false
+	next.HTTP.Listen = ":4000"
+	next.Dedup.DefaultWindow = "1h"
+
+	changed, restart := live.Apply(next)
+	require.ElementsMatch(t, []string{"broadcast", "postman"}, changed)
+	require.ElementsMatch(t, []string{"http", "dedup"}, restart)
+
+	require.Equal(t, 100, live.Broadcast().Rate)
+	require.False(t, live.Postman().Enabled)
+	// restart is required, so the old values are kept
+	require.Equal(t, ":3001", live.Get().HTTP.Listen)
+	require.Equal(t, "24h", live.Dedup().DefaultWindow)
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/caarlos0/env/v6"
+	"gopkg.in/yaml.v3"
+)
+
+const configFileEnv = "CONFIG_FILE"
+
+// Load builds the config from defaults, config files listed in CONFIG_FILE and env vars.
+// Env vars have priority over files, files have priority over defaults.
+func Load() (App, error) {
+	return load(environ())
+}
+
+func load(vars map[string]string) (App, error) {
+	// defaults only, env vars are applied after files
+	var cfg App
+	if err := env.Parse(&cfg, env.Options{Environment: map[string]string{}}); err != nil {
+		return App{}, fmt.Errorf("parse defaults: %w", err)
+	}
+
+	var fromEnv App
+	if err := env.Parse(&fromEnv, env.Options{Environment: vars}); err != nil {
+		return App{}, fmt.Errorf("parse env: %w", err)
+	}
+
+	for _, path := range fromEnv.ConfigFiles {
+		if err := decodeFile(path, &cfg); err != nil {
+			return App{}, err
+		}
+	}
+
+	overrideFromEnv(reflect.ValueOf(&cfg).Elem(), reflect.ValueOf(fromEnv), vars)
+
+	if err := cfg.Validate(); err != nil {
+		return App{}, fmt.Errorf("invalid config:\n%w", err)
+	}
+
+	return cfg, nil
+}
+
+// decodeFile applies YAML or JSON file to cfg, JSON is decoded as YAML since it is its subset
+func decodeFile(path string, cfg *App) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("decode config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// overrideFromEnv copies fields which have their env var set
+func overrideFromEnv(dst, src reflect.Value, vars map[string]string) {
+	for idx := 0; idx < dst.NumField(); idx++ {
+		field := dst.Type().Field(idx)
+
+		tag, ok := field.Tag.Lookup("env")
+		if !ok {
+			if field.Type.Kind() == reflect.Struct {
+				overrideFromEnv(dst.Field(idx), src.Field(idx), vars)
+			}
+
+			continue
+		}
+
+		name, _, _ := strings.Cut(tag, ",")
+		if _, set := vars[name]; set {
+			dst.Field(idx).Set(src.Field(idx))
+		}
+	}
+}
+
+func environ() map[string]string {
+	vars := make(map[string]string)
+	for _, item := range os.Environ() {
+		if key, value, ok := strings.Cut(item, "="); ok {
+			vars[key] = value
+		}
+	}
+
+	return vars
+}
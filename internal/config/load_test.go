@@ -0,0 +1,123 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	return path
+}
+
+func TestLoadLayers(t *testing.T) {
+	yamlFile := writeFile(t, "config.yaml", `
+log_level: debug
+broadcast:
+  rate: 50
+  check_interval: 1m
+dedup:
+  windows:
+    proposal.created: 12h
+push:
+  project_id: from-file
+  projects:
+    - name: beta
+      token_prefix: "beta:"
+      credentials_file: /secrets/beta.json
+    - name: partner
+      enabled: false
+      token_prefix: "partner:"
+      credentials_file: /secrets/partner.json
+`)
+	jsonFile := writeFile(t, "secrets.json", `{"http": {"admin_token": "secret"}, "broadcast": {"rate": 70}}`)
+
+	cfg, err := load(map[string]string{
+		"CONFIG_FILE": yamlFile + "," + jsonFile,
+		"LOG_LEVEL":   "warn",
+	})
+	require.NoError(t, err)
+
+	// env has priority over files
+	require.Equal(t, "warn", cfg.LogLevel)
+	// later files have priority over earlier ones
+	require.Equal(t, 70, cfg.Broadcast.Rate)
+	require.Equal(t, time.Minute, cfg.Broadcast.CheckInterval)
+	require.Equal(t, "secret", cfg.HTTP.AdminToken)
+	require.Equal(t, "from-file", cfg.Push.ProjectID)
+	require.Equal(t, []PushProject{
+		{Name: "beta", Enabled: true, TokenPrefix: "beta:", CredentialsFile: "/secrets/beta.json"},
+		{Name: "partner", Enabled: false, TokenPrefix: "partner:", CredentialsFile: "/secrets/partner.json"},
+	}, cfg.Push.Projects)
+	// maps from files replace defaults
+	require.Equal(t, StringMap{"proposal.created": "12h"}, cfg.Dedup.Windows)
+	// defaults are kept for the rest
+	require.Equal(t, ":3001", cfg.HTTP.Listen)
+	require.True(t, cfg.Postman.Enabled)
+}
+
+func TestLoadErrors(t *testing.T) {
+	for name, tc := range map[string]struct {
+		file     string
+		vars     map[string]string
+		expected []string
+	}{
+		"unknown field": {
+			file:     "broadcast:\n  rates: 10\n",
+			expected: []string{"field rates not found"},
+		},
+		"invalid push projects": {
+			file: `
+push:
+  projects:
+    - name: beta
+      token_prefix: "beta:"
+      credentials_file: /secrets/beta.json
+    - name: beta
+      token_prefix: "beta:"
+    - name: default
+      token_prefix: "default:"
+      credentials_file: /secrets/default.json
+`,
+			expected: []string{
+				"push project beta: name must be unique",
+				`push project beta: token_prefix "beta:" must be unique`,
+				"push project beta: credentials_file is required",
+				"push project default: name must be unique",
+			},
+		},
+		"aggregated validation": {
+			vars: map[string]string{
+				"LOG_LEVEL":            "verbose",
+				"BROADCAST_RATE":       "0",
+				"TRACING_SAMPLE_RATIO": "3",
+				"POSTMAN_INTERVAL":     "0s",
+			},
+			expected: []string{"LOG_LEVEL", "BROADCAST_RATE", "TRACING_SAMPLE_RATIO", "POSTMAN_INTERVAL"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			vars := map[string]string{}
+			for key, value := range tc.vars {
+				vars[key] = value
+			}
+			if tc.file != "" {
+				vars["CONFIG_FILE"] = writeFile(t, "config.yaml", tc.file)
+			}
+
+			_, err := load(vars)
+			require.Error(t, err)
+			for _, expected := range tc.expected {
+				require.ErrorContains(t, err, expected)
+			}
+		})
+	}
+}
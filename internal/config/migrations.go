@@ -0,0 +1,9 @@
+package config
+
+type Migrations struct {
+	// Auto applies pending migrations on start, concurrent instances wait for each other
+	Auto bool `env:"MIGRATIONS_AUTO" envDefault:"false" yaml:"auto"`
+	// Verify refuses to start if embedded migrations are not applied to the database. Databases migrated
+	// by hand have no schema_migrations table until migrate -baseline is run once, they are only warned about.
+	Verify bool `env:"MIGRATIONS_VERIFY" envDefault:"true" yaml:"verify"`
+}
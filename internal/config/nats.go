@@ -6,9 +6,9 @@ import (
 )
 
 type Nats struct {
-	URL              string        `env:"NATS_URL" envDefault:"nats://127.0.0.1:4222"`
-	MaxReconnects    int           `env:"NATS_MAX_RECONNECTS" envDefault:"10"`
-	ReconnectTimeout time.Duration `env:"NATS_RECONNECT_TIMEOUT" envDefault:"1s"`
+	URL              string        `env:"NATS_URL" envDefault:"nats://127.0.0.1:4222" yaml:"url"`
+	MaxReconnects    int           `env:"NATS_MAX_RECONNECTS" envDefault:"10" yaml:"max_reconnects"`
+	ReconnectTimeout time.Duration `env:"NATS_RECONNECT_TIMEOUT" envDefault:"1s" yaml:"reconnect_timeout"`
 }
 
 func GenerateGroupName(subgroup string) string {
@@ -0,0 +1,12 @@
+package config
+
+import "time"
+
+type Postman struct {
+	// Enabled pauses sending of queued pushes when false, items stay in the queue
+	Enabled bool `env:"POSTMAN_ENABLED" envDefault:"true" yaml:"enabled"`
+	// Interval between runs of the regular postman
+	Interval time.Duration `env:"POSTMAN_INTERVAL" envDefault:"5m" yaml:"interval"`
+	// ImmediateInterval between runs of voting ends soon and delegate postmen
+	ImmediateInterval time.Duration `env:"POSTMAN_IMMEDIATE_INTERVAL" envDefault:"5m" yaml:"immediate_interval"`
+}
@@ -1,5 +1,5 @@
 package config
 
 type Prometheus struct {
-	Listen string `env:"PROMETHEUS_LISTEN" envDefault:":2112"`
+	Listen string `env:"PROMETHEUS_LISTEN" envDefault:":2112" yaml:"listen"`
 }
@@ -1,15 +1,68 @@
 package config
 
+import (
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultPushProject is the name of the project configured by PUSH_* variables
+const DefaultPushProject = "default"
+
+// Push is the firebase service account of the default project. The account is read from CredentialsFile or
+// GOOGLE_APPLICATION_CREDENTIALS if any of them is set, otherwise from the fields below.
 type Push struct {
-	Type                string `env:"PUSH_TYPE" json:"type"`
-	ProjectID           string `env:"PUSH_PROJECT_ID" json:"project_id"`
-	PrivateKeyID        string `env:"PUSH_PRIVATE_KEY_ID" json:"private_key_id"`
-	PrivateKey          string `env:"PUSH_PRIVATE_KEY" json:"private_key"`
-	ClientEmail         string `env:"PUSH_CLIENT_EMAIL" json:"client_email"`
-	ClientID            string `env:"PUSH_CLIENT_ID" json:"client_id"`
-	AuthUri             string `env:"PUSH_AUTH_URI" json:"auth_uri"`
-	TokenUri            string `env:"PUSH_TOKEN_URI" json:"token_uri"`
-	AuthProviderCertURL string `env:"PUSH_AUTH_PROVIDER_CERT_URL" json:"auth_provider_x509_cert_url"`
-	ClientCertURL       string `env:"PUSH_CLIENT_CERT_URL" json:"client_x509_cert_url"`
-	UniverseDomain      string `env:"PUSH_UNIVERSE_DOMAIN" json:"universe_domain"`
+	// Enabled switches sending through the default project
+	Enabled bool `env:"PUSH_ENABLED" envDefault:"true" json:"-" yaml:"enabled"`
+	// Projects are additional firebase projects of other app builds, they are set by the config file only
+	Projects []PushProject `json:"-" yaml:"projects"`
+
+	CredentialsFile string `env:"PUSH_CREDENTIALS_FILE" json:"-" yaml:"credentials_file"`
+	// ApplicationCredentials is the standard google variable with the path to the service account
+	ApplicationCredentials string `env:"GOOGLE_APPLICATION_CREDENTIALS" json:"-" yaml:"-"`
+	// CredentialsWatchInterval is how often the credentials file is checked for changes
+	CredentialsWatchInterval time.Duration `env:"PUSH_CREDENTIALS_WATCH_INTERVAL" envDefault:"30s" json:"-" yaml:"credentials_watch_interval"`
+
+	Type                string `env:"PUSH_TYPE" json:"type" yaml:"type"`
+	ProjectID           string `env:"PUSH_PROJECT_ID" json:"project_id" yaml:"project_id"`
+	PrivateKeyID        string `env:"PUSH_PRIVATE_KEY_ID" json:"private_key_id" yaml:"private_key_id"`
+	PrivateKey          string `env:"PUSH_PRIVATE_KEY" json:"private_key" yaml:"private_key"`
+	ClientEmail         string `env:"PUSH_CLIENT_EMAIL" json:"client_email" yaml:"client_email"`
+	ClientID            string `env:"PUSH_CLIENT_ID" json:"client_id" yaml:"client_id"`
+	AuthUri             string `env:"PUSH_AUTH_URI" json:"auth_uri" yaml:"auth_uri"`
+	TokenUri            string `env:"PUSH_TOKEN_URI" json:"token_uri" yaml:"token_uri"`
+	AuthProviderCertURL string `env:"PUSH_AUTH_PROVIDER_CERT_URL" json:"auth_provider_x509_cert_url" yaml:"auth_provider_x509_cert_url"`
+	ClientCertURL       string `env:"PUSH_CLIENT_CERT_URL" json:"client_x509_cert_url" yaml:"client_x509_cert_url"`
+	UniverseDomain      string `env:"PUSH_UNIVERSE_DOMAIN" json:"universe_domain" yaml:"universe_domain"`
+}
+
+// CredentialsPath returns the file with the service account, it is empty if the account is set by fields
+func (p Push) CredentialsPath() string {
+	if p.CredentialsFile != "" {
+		return p.CredentialsFile
+	}
+
+	return p.ApplicationCredentials
+}
+
+// PushProject is the additional firebase project, e.g. of the beta or partner app
+type PushProject struct {
+	Name    string `yaml:"name"`
+	Enabled bool   `yaml:"enabled"`
+	// TokenPrefix routes tokens stored as <prefix><firebase token> to the project, the prefix is stripped before sending
+	TokenPrefix     string `yaml:"token_prefix"`
+	CredentialsFile string `yaml:"credentials_file"`
+}
+
+// UnmarshalYAML enables the project unless it is disabled explicitly
+func (p *PushProject) UnmarshalYAML(node *yaml.Node) error {
+	type plain PushProject
+	project := plain{Enabled: true}
+	if err := node.Decode(&project); err != nil {
+		return err
+	}
+
+	*p = PushProject(project)
+
+	return nil
 }
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// StringMap is parsed from comma separated key:value pairs, e.g. outbox:5m,broadcast:5m
+type StringMap map[string]string
+
+func (m *StringMap) UnmarshalText(text []byte) error {
+	result := make(StringMap)
+	for _, pair := range strings.Split(string(text), ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(pair, ":")
+		if !ok || strings.TrimSpace(key) == "" {
+			return fmt.Errorf("invalid key:value pair %q", pair)
+		}
+
+		result[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+
+	*m = result
+
+	return nil
+}
+
+// UnmarshalYAML accepts both the mapping and the env-like string, the value replaces defaults instead of merging
+func (m *StringMap) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return m.UnmarshalText([]byte(node.Value))
+	}
+
+	var result map[string]string
+	if err := node.Decode(&result); err != nil {
+		return err
+	}
+
+	*m = result
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStringMapUnmarshalText(t *testing.T) {
+	for name, tc := range map[string]struct {
+		text     string
+		expected StringMap
+		err      bool
+	}{
+		"pairs": {
+			text:     "proposal.voting.ends_soon:6h, proposal.created:forever",
+			expected: StringMap{"proposal.voting.ends_soon": "6h", "proposal.created": "forever"},
+		},
+		"empty": {
+			text:     "",
+			expected: StringMap{},
+		},
+		"without value": {
+			text: "outbox",
+			err:  true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var m StringMap
+			err := m.UnmarshalText([]byte(tc.text))
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, m)
+		})
+	}
+}
@@ -0,0 +1,8 @@
+package config
+
+type Tracing struct {
+	// Enabled turns on the OTLP export, the endpoint is configured by standard OTEL_EXPORTER_OTLP_* variables
+	Enabled     bool    `env:"TRACING_ENABLED" envDefault:"false" yaml:"enabled"`
+	ServiceName string  `env:"TRACING_SERVICE_NAME" envDefault:"inbox-push" yaml:"service_name"`
+	SampleRatio float64 `env:"TRACING_SAMPLE_RATIO" envDefault:"1" yaml:"sample_ratio"`
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Validate returns all problems of the config at once
+func (a App) Validate() error {
+	var errs []error
+	add := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if _, err := zerolog.ParseLevel(a.LogLevel); err != nil {
+		add("LOG_LEVEL: %w", err)
+	}
+	if a.DB.DSN == "" {
+		add("POSTGRES_DSN is required")
+	}
+	if a.Nats.URL == "" {
+		add("NATS_URL is required")
+	}
+	if a.InternalAPI.InboxStorageAddress == "" {
+		add("INTERNAL_API_INBOX_STORAGE_ADDRESS is required")
+	}
+	if _, err := a.Health.WorkerMaxAges(); err != nil {
+		add("HEALTH_WORKER_MAX_AGE: %w", err)
+	}
+	if a.Tracing.SampleRatio < 0 || a.Tracing.SampleRatio > 1 {
+		add("TRACING_SAMPLE_RATIO must be between 0 and 1, got %v", a.Tracing.SampleRatio)
+	}
+	if a.Broadcast.Rate <= 0 {
+		add("BROADCAST_RATE must be positive, got %d", a.Broadcast.Rate)
+	}
+
+	for _, item := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"CONFIG_WATCH_INTERVAL", a.ConfigWatchInterval},
+		{"BROADCAST_CHECK_INTERVAL", a.Broadcast.CheckInterval},
+		{"BROADCAST_LEASE", a.Broadcast.Lease},
+		{"DEDUP_CLEANUP_INTERVAL", a.Dedup.CleanupInterval},
+		{"EXPERIMENTS_RELOAD_INTERVAL", a.Experiments.ReloadInterval},
+		{"INBOX_RETENTION", a.Inbox.Retention},
+		{"INBOX_CLEANUP_INTERVAL", a.Inbox.CleanupInterval},
+		{"POSTMAN_INTERVAL", a.Postman.Interval},
+		{"POSTMAN_IMMEDIATE_INTERVAL", a.Postman.ImmediateInterval},
+		{"PUSH_CREDENTIALS_WATCH_INTERVAL", a.Push.CredentialsWatchInterval},
+	} {
+		if item.value <= 0 {
+			add("%s must be positive", item.name)
+		}
+	}
+
+	names := map[string]bool{DefaultPushProject: true}
+	prefixes := make(map[string]bool)
+	for idx, project := range a.Push.Projects {
+		switch {
+		case project.Name == "":
+			add("push project #%d: name is required", idx)
+		case names[project.Name]:
+			add("push project %s: name must be unique", project.Name)
+		}
+		names[project.Name] = true
+
+		switch {
+		case project.TokenPrefix == "":
+			add("push project %s: token_prefix is required", project.Name)
+		case prefixes[project.TokenPrefix]:
+			add("push project %s: token_prefix %q must be unique", project.Name, project.TokenPrefix)
+		}
+		prefixes[project.TokenPrefix] = true
+
+		if project.CredentialsFile == "" {
+			add("push project %s: credentials_file is required", project.Name)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// Watcher reloads the config on SIGHUP or when config files are changed and applies reloadable fields to Live
+type Watcher struct {
+	live     *Live
+	load     func() (App, error)
+	modTimes map[string]time.Time
+}
+
+func NewWatcher(live *Live) *Watcher {
+	return &Watcher{
+		live:     live,
+		load:     Load,
+		modTimes: make(map[string]time.Time),
+	}
+}
+
+func (w *Watcher) Start(ctx context.Context) error {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	w.filesChanged()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-hup:
+			w.reload("signal")
+		case <-time.After(w.live.Get().ConfigWatchInterval):
+			if w.filesChanged() {
+				w.reload("file")
+			}
+		}
+	}
+}
+
+// filesChanged remembers modification times of config files and reports if any of them is changed
+func (w *Watcher) filesChanged() bool {
+	changed := false
+	for _, path := range w.live.Get().ConfigFiles {
+		info, err := os.Stat(path)
+		if err != nil {
+			log.Error().Err(err).Str("path", path).Msg("stat config file")
+			continue
+		}
+
+		if prev, ok := w.modTimes[path]; ok && !prev.Equal(info.ModTime()) {
+			changed = true
+		}
+		w.modTimes[path] = info.ModTime()
+	}
+
+	return changed
+}
+
+func (w *Watcher) reload(reason string) {
+	cfg, err := w.load()
+	if err != nil {
+		log.Error().Err(err).Str("reason", reason).Msg("reload config, the current one is kept")
+		return
+	}
+
+	changed, restart := w.live.Apply(cfg)
+	sort.Strings(changed)
+	sort.Strings(restart)
+
+	if level, err := zerolog.ParseLevel(cfg.LogLevel); err == nil {
+		zerolog.SetGlobalLevel(level)
+	}
+
+	log.Info().Str("reason", reason).Strs("changed", changed).Msg("config is reloaded")
+	if len(restart) > 0 {
+		log.Warn().Strs("sections", restart).Msg("config changes require restart to be applied")
+	}
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatcherReload(t *testing.T) {
+	path := writeFile(t, "config.yaml", "postman:\n  enabled: true\n")
+	live := NewLive(App{LogLevel: "info", ConfigFiles: []string{path}, Postman: Postman{Enabled: true}})
+
+	next := live.Get()
+	next.Postman.Enabled = false
+
+	var loadErr error
+	w := NewWatcher(live)
+	w.load = func() (App, error) {
+		return next, loadErr
+	}
+
+	require.False(t, w.filesChanged())
+
+	modTime := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+	require.True(t, w.filesChanged())
+	require.False(t, w.filesChanged())
+
+	// the invalid config is not applied
+	loadErr = errors.New("invalid config")
+	w.reload("file")
+	require.True(t, live.Postman().Enabled)
+
+	loadErr = nil
+	w.reload("signal")
+	require.False(t, live.Postman().Enabled)
+}
@@ -0,0 +1,240 @@
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"firebase.google.com/go/v4/messaging"
+	"github.com/google/uuid"
+	"github.com/goverland-labs/goverland-core-sdk-go/dao"
+	"github.com/goverland-labs/goverland-core-sdk-go/proposal"
+	"github.com/goverland-labs/goverland-inbox-api-protocol/protobuf/inboxapi"
+)
+
+// fakeInbox keeps subscriptions, users and their settings of the inbox storage
+type fakeInbox struct {
+	mu          sync.Mutex
+	subscribers map[uuid.UUID][]uuid.UUID
+	tokens      map[uuid.UUID][]*inboxapi.PushTokenDetails
+	blocked     map[uuid.UUID]bool
+	allowChecks int
+}
+
+func newFakeInbox() *fakeInbox {
+	return &fakeInbox{
+		subscribers: make(map[uuid.UUID][]uuid.UUID),
+		tokens:      make(map[uuid.UUID][]*inboxapi.PushTokenDetails),
+		blocked:     make(map[uuid.UUID]bool),
+	}
+}
+
+// Subscribe subscribes the user to the dao
+func (f *fakeInbox) Subscribe(userID, daoID uuid.UUID) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.subscribers[daoID] = append(f.subscribers[daoID], userID)
+}
+
+// AddDevice registers the push token of the user device
+func (f *fakeInbox) AddDevice(userID uuid.UUID, deviceUUID, token string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.tokens[userID] = append(f.tokens[userID], &inboxapi.PushTokenDetails{
+		Token:      token,
+		DeviceUuid: deviceUUID,
+	})
+}
+
+// Block forbids sending pushes to the user
+func (f *fakeInbox) Block(userID uuid.UUID) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.blocked[userID] = true
+}
+
+// AllowChecks returns how many times the postman asked whether pushes are allowed
+func (f *fakeInbox) AllowChecks() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.allowChecks
+}
+
+type fakeSubscriptionServer struct {
+	inboxapi.UnimplementedSubscriptionServer
+
+	inbox *fakeInbox
+}
+
+func (s *fakeSubscriptionServer) FindSubscribers(_ context.Context, req *inboxapi.FindSubscribersRequest) (*inboxapi.UserList, error) {
+	s.inbox.mu.Lock()
+	defer s.inbox.mu.Unlock()
+
+	daoID, err := uuid.Parse(req.GetDaoId())
+	if err != nil {
+		return nil, err
+	}
+
+	list := &inboxapi.UserList{}
+	for _, userID := range s.inbox.subscribers[daoID] {
+		list.Users = append(list.Users, &inboxapi.UserID{UserId: userID.String()})
+	}
+
+	return list, nil
+}
+
+type fakeUserServer struct {
+	inboxapi.UnimplementedUserServer
+
+	inbox *fakeInbox
+}
+
+func (s *fakeUserServer) AllowSendingPush(_ context.Context, req *inboxapi.AllowSendingPushRequest) (*inboxapi.AllowSendingPushResponse, error) {
+	s.inbox.mu.Lock()
+	defer s.inbox.mu.Unlock()
+
+	userID, err := uuid.Parse(req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	s.inbox.allowChecks++
+
+	return &inboxapi.AllowSendingPushResponse{Allow: !s.inbox.blocked[userID]}, nil
+}
+
+type fakeSettingsServer struct {
+	inboxapi.UnimplementedSettingsServer
+
+	inbox *fakeInbox
+}
+
+func (s *fakeSettingsServer) GetPushTokenList(_ context.Context, req *inboxapi.GetPushTokenListRequest) (*inboxapi.PushTokenListResponse, error) {
+	s.inbox.mu.Lock()
+	defer s.inbox.mu.Unlock()
+
+	userID, err := uuid.Parse(req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	return &inboxapi.PushTokenListResponse{Tokens: s.inbox.tokens[userID]}, nil
+}
+
+// GetPushDetails enables all dao pushes
+func (s *fakeSettingsServer) GetPushDetails(_ context.Context, req *inboxapi.GetPushDetailsRequest) (*inboxapi.GetPushDetailsResponse, error) {
+	enabled := true
+
+	return &inboxapi.GetPushDetailsResponse{
+		UserId: req.GetUserId(),
+		Dao: &inboxapi.PushSettingsDao{
+			NewProposalCreated: &enabled,
+			QuorumReached:      &enabled,
+			VoteFinishesSoon:   &enabled,
+			VoteFinished:       &enabled,
+		},
+	}, nil
+}
+
+// fakeCore serves daos and proposals the same way as the core api
+type fakeCore struct {
+	mu        sync.Mutex
+	daos      map[string]dao.Dao
+	proposals map[string]proposal.Proposal
+}
+
+func newFakeCore() *fakeCore {
+	return &fakeCore{
+		daos:      make(map[string]dao.Dao),
+		proposals: make(map[string]proposal.Proposal),
+	}
+}
+
+func (f *fakeCore) AddDao(item dao.Dao) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.daos[item.ID.String()] = item
+}
+
+func (f *fakeCore) AddProposal(item proposal.Proposal) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.proposals[item.ID] = item
+}
+
+func (f *fakeCore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var (
+		item any
+		ok   bool
+	)
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/daos/"):
+		item, ok = f.daos[strings.TrimPrefix(r.URL.Path, "/daos/")]
+	case strings.HasPrefix(r.URL.Path, "/proposals/"):
+		item, ok = f.proposals[strings.TrimPrefix(r.URL.Path, "/proposals/")]
+	}
+	if !ok {
+		http.Error(w, fmt.Sprintf(`{"message":"%s not found"}`, r.URL.Path), http.StatusNotFound)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(item)
+}
+
+// recordingSender keeps messages instead of sending them to firebase
+type recordingSender struct {
+	mu       sync.Mutex
+	messages []*messaging.Message
+}
+
+func (s *recordingSender) Send(_ context.Context, message *messaging.Message) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.messages = append(s.messages, message)
+
+	return fmt.Sprintf("projects/e2e/messages/%d", len(s.messages)), nil
+}
+
+// Messages returns messages sent so far
+func (s *recordingSender) Messages() []*messaging.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]*messaging.Message(nil), s.messages...)
+}
+
+// fakeClock is the current time moved by tests only
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+}
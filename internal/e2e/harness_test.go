@@ -0,0 +1,180 @@
+package e2e
+
+import (
+	"context"
+	"net"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"firebase.google.com/go/v4/messaging"
+	"github.com/goverland-labs/goverland-inbox-api-protocol/protobuf/inboxapi"
+	"github.com/goverland-labs/goverland-platform-events/pkg/natsclient"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/goverland-labs/goverland-inbox-push/internal"
+	"github.com/goverland-labs/goverland-inbox-push/internal/config"
+	"github.com/goverland-labs/goverland-inbox-push/internal/sender"
+)
+
+const (
+	postmanInterval = 50 * time.Millisecond
+	waitTimeout     = 10 * time.Second
+	stopTimeout     = 10 * time.Second
+)
+
+// harness runs the application against embedded nats, fake inbox storage and core, and records
+// pushes instead of sending them to firebase
+type harness struct {
+	inbox     *fakeInbox
+	core      *fakeCore
+	sender    *recordingSender
+	clock     *fakeClock
+	storage   *sender.MemoryStorage
+	publisher *natsclient.Publisher
+}
+
+func newHarness(t *testing.T) *harness {
+	t.Helper()
+
+	h := &harness{
+		inbox:   newFakeInbox(),
+		core:    newFakeCore(),
+		sender:  &recordingSender{},
+		clock:   &fakeClock{now: time.Now().UTC().Truncate(time.Second)},
+		storage: sender.NewMemoryStorage(),
+	}
+	h.storage.SetClock(h.clock.Now)
+
+	natsURL := runNatsServer(t)
+	inboxAddress := runInboxServer(t, h.inbox)
+
+	coreServer := httptest.NewServer(h.core)
+	t.Cleanup(coreServer.Close)
+
+	nc, err := nats.Connect(natsURL)
+	require.NoError(t, err)
+	t.Cleanup(nc.Close)
+
+	h.publisher, err = natsclient.NewPublisher(nc)
+	require.NoError(t, err)
+
+	for key, value := range map[string]string{
+		"LOG_LEVEL":                          "error",
+		"NATS_URL":                           natsURL,
+		"INTERNAL_API_INBOX_STORAGE_ADDRESS": inboxAddress,
+		"CORE_URL":                           coreServer.URL,
+		"POSTMAN_INTERVAL":                   postmanInterval.String(),
+		"POSTMAN_IMMEDIATE_INTERVAL":         postmanInterval.String(),
+		"HTTP_LISTEN":                        "127.0.0.1:0",
+		"PROMETHEUS_LISTEN":                  "127.0.0.1:0",
+		"HEALTH_LISTEN":                      "127.0.0.1:0",
+	} {
+		t.Setenv(key, value)
+	}
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+
+	level, _ := zerolog.ParseLevel(cfg.LogLevel)
+	zerolog.SetGlobalLevel(level)
+
+	app, err := internal.NewApplication(cfg,
+		internal.WithStorage(h.storage),
+		internal.WithMessageSender(h.sender),
+		internal.WithClock(h.clock.Now),
+	)
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		app.Run()
+	}()
+
+	t.Cleanup(func() {
+		app.Stop()
+
+		select {
+		case <-done:
+		case <-time.After(stopTimeout):
+			t.Error("application is not stopped")
+		}
+	})
+
+	return h
+}
+
+func runNatsServer(t *testing.T) string {
+	t.Helper()
+
+	srv, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      server.RANDOM_PORT,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	require.NoError(t, err)
+
+	go srv.Start()
+	require.True(t, srv.ReadyForConnections(5*time.Second))
+
+	t.Cleanup(srv.Shutdown)
+
+	return srv.ClientURL()
+}
+
+func runInboxServer(t *testing.T, inbox *fakeInbox) string {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := grpc.NewServer()
+	inboxapi.RegisterSubscriptionServer(srv, &fakeSubscriptionServer{inbox: inbox})
+	inboxapi.RegisterUserServer(srv, &fakeUserServer{inbox: inbox})
+	inboxapi.RegisterSettingsServer(srv, &fakeSettingsServer{inbox: inbox})
+
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+
+	t.Cleanup(srv.Stop)
+
+	return lis.Addr().String()
+}
+
+// publish sends the event the same way platform services do
+func (h *harness) publish(t *testing.T, subject string, payload any) {
+	t.Helper()
+
+	require.NoError(t, h.publisher.PublishJSON(context.Background(), subject, payload))
+}
+
+// waitMessages waits until at least count pushes are sent and returns all of them
+func (h *harness) waitMessages(t *testing.T, count int) []*messaging.Message {
+	t.Helper()
+
+	require.Eventually(t, func() bool {
+		return len(h.sender.Messages()) >= count
+	}, waitTimeout, postmanInterval/2, "pushes are not sent")
+
+	return h.sender.Messages()
+}
+
+// waitPostmanRuns waits for a few postman runs, so the queue is processed at least once after the call
+func (h *harness) waitPostmanRuns(t *testing.T) {
+	t.Helper()
+
+	checks := h.inbox.AllowChecks()
+	require.Eventually(t, func() bool {
+		return h.inbox.AllowChecks() >= checks+2
+	}, waitTimeout, postmanInterval/2, "postman is not running")
+}
@@ -0,0 +1,145 @@
+package e2e
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/goverland-labs/goverland-core-sdk-go/dao"
+	"github.com/goverland-labs/goverland-core-sdk-go/proposal"
+	"github.com/goverland-labs/goverland-platform-events/events/inbox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/goverland-labs/goverland-inbox-push/internal/sender"
+)
+
+// fixture is the dao with the proposal and the subscriber with one device
+type fixture struct {
+	daoID      uuid.UUID
+	proposalID string
+	userID     uuid.UUID
+	token      string
+}
+
+func newFixture(h *harness) fixture {
+	f := fixture{
+		daoID:      uuid.New(),
+		proposalID: "0x" + uuid.NewString(),
+		userID:     uuid.New(),
+		token:      "token-" + uuid.NewString(),
+	}
+
+	h.core.AddDao(dao.Dao{ID: f.daoID, Alias: "aave.eth", Name: "Aave"})
+	h.core.AddProposal(proposal.Proposal{ID: f.proposalID, DaoID: f.daoID, Title: "Increase the reserve factor"})
+	h.inbox.Subscribe(f.userID, f.daoID)
+
+	return f
+}
+
+func (f fixture) event(action inbox.TimelineAction) inbox.FeedPayload {
+	return inbox.FeedPayload{
+		ID:         uuid.New(),
+		DaoID:      f.daoID,
+		ProposalID: f.proposalID,
+		Type:       inbox.TypeProposal,
+		Action:     action,
+	}
+}
+
+func TestFeedEventIsDelivered(t *testing.T) {
+	h := newHarness(t)
+	f := newFixture(h)
+	h.inbox.AddDevice(f.userID, "device", f.token)
+
+	h.publish(t, inbox.SubjectFeedUpdated, f.event(inbox.ProposalCreated))
+
+	messages := h.waitMessages(t, 1)
+	require.Len(t, messages, 1)
+	assert.Equal(t, f.token, messages[0].Token)
+	assert.Equal(t, "Aave: New proposal created", messages[0].Notification.Title)
+	assert.Equal(t, "Increase the reserve factor", messages[0].Notification.Body)
+
+	list, err := h.storage.Notifications(context.Background(), f.userID, nil, 10)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, messages[0].APNS.Payload.CustomData["id"], list[0].Message.ID)
+}
+
+func TestNotAllowedUserGetsNothing(t *testing.T) {
+	h := newHarness(t)
+	f := newFixture(h)
+	h.inbox.AddDevice(f.userID, "device", f.token)
+	h.inbox.Block(f.userID)
+
+	h.publish(t, inbox.SubjectFeedUpdated, f.event(inbox.ProposalCreated))
+
+	h.waitPostmanRuns(t)
+	assert.Empty(t, h.sender.Messages())
+}
+
+func TestUserWithoutTokensGetsNothing(t *testing.T) {
+	h := newHarness(t)
+	f := newFixture(h)
+
+	h.publish(t, inbox.SubjectFeedUpdated, f.event(inbox.ProposalCreated))
+
+	require.Eventually(t, func() bool {
+		list, err := h.storage.FanoutLogByFilters(context.Background(), []sender.Filter{
+			sender.UserIDIn(f.userID.String()),
+		})
+
+		return err == nil && len(list) == 1 && list[0].Outcome == sender.FanoutSkippedNoTokens
+	}, waitTimeout, postmanInterval/2)
+
+	queued, err := h.storage.QueueByFilters(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Empty(t, queued)
+	assert.Empty(t, h.sender.Messages())
+}
+
+func TestDuplicatePushIsSentAfterDedupWindow(t *testing.T) {
+	h := newHarness(t)
+	f := newFixture(h)
+	h.inbox.AddDevice(f.userID, "device", f.token)
+
+	h.publish(t, inbox.SubjectFeedUpdated, f.event(inbox.ProposalVotingQuorumReached))
+	h.waitMessages(t, 1)
+
+	// the same push within the default window of 24h is dropped
+	h.publish(t, inbox.SubjectFeedUpdated, f.event(inbox.ProposalVotingQuorumReached))
+	require.Eventually(t, func() bool {
+		sent, err := h.storage.QueueByFilters(context.Background(), []sender.Filter{sender.AlreadySent()})
+
+		return err == nil && len(sent) == 2
+	}, waitTimeout, postmanInterval/2)
+	assert.Len(t, h.sender.Messages(), 1)
+
+	h.clock.Advance(25 * time.Hour)
+
+	h.publish(t, inbox.SubjectFeedUpdated, f.event(inbox.ProposalVotingQuorumReached))
+	messages := h.waitMessages(t, 2)
+	require.Len(t, messages, 2)
+	assert.Equal(t, messages[0].Notification.Title, messages[1].Notification.Title)
+}
+
+func TestClickMarksHistory(t *testing.T) {
+	h := newHarness(t)
+	f := newFixture(h)
+	h.inbox.AddDevice(f.userID, "device", f.token)
+
+	h.publish(t, inbox.SubjectFeedUpdated, f.event(inbox.ProposalCreated))
+	messages := h.waitMessages(t, 1)
+
+	id, ok := messages[0].APNS.Payload.CustomData["id"].(uuid.UUID)
+	require.True(t, ok)
+
+	h.publish(t, inbox.SubjectPushClicked, inbox.PushClickPayload{ID: id})
+
+	require.Eventually(t, func() bool {
+		list, err := h.storage.Notifications(context.Background(), f.userID, nil, 10)
+
+		return err == nil && len(list) == 1 && list[0].ClickedAt != nil
+	}, waitTimeout, postmanInterval/2)
+}
@@ -0,0 +1,31 @@
+package internal
+
+import (
+	"time"
+
+	"github.com/goverland-labs/goverland-inbox-push/internal/sender"
+)
+
+// Option replaces a dependency of the application, e.g. to run it against fakes in tests
+type Option func(a *Application)
+
+// WithStorage replaces the postgres storage, the database is not opened and migrations are not applied
+func WithStorage(storage sender.Storage) Option {
+	return func(a *Application) {
+		a.repo = storage
+	}
+}
+
+// WithMessageSender replaces firebase projects, their credentials are neither loaded nor checked
+func WithMessageSender(ms sender.MessageSender) Option {
+	return func(a *Application) {
+		a.messageSender = ms
+	}
+}
+
+// WithClock replaces the source of the current time used by the service
+func WithClock(now func() time.Time) Option {
+	return func(a *Application) {
+		a.clock = now
+	}
+}
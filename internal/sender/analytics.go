@@ -0,0 +1,146 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	GroupByTemplate ClickStatsGroup = "template"
+	GroupByAction   ClickStatsGroup = "action"
+	GroupByDao      ClickStatsGroup = "dao"
+	GroupByDevice   ClickStatsGroup = "device"
+	GroupByDay      ClickStatsGroup = "day"
+	// GroupByVariant compares variants of the template experiment
+	GroupByVariant ClickStatsGroup = "variant"
+)
+
+const (
+	analyticsRefreshDelay = 5 * time.Minute
+	analyticsWindow       = 7 * 24 * time.Hour
+)
+
+type ClickStatsGroup string
+
+var ErrExperimentRequired = errors.New("experiment id is required")
+
+func (g ClickStatsGroup) Valid() bool {
+	switch g {
+	case GroupByTemplate, GroupByAction, GroupByDao, GroupByDevice, GroupByDay, GroupByVariant:
+		return true
+	}
+
+	return false
+}
+
+type ClickStatsQuery struct {
+	From    time.Time
+	To      time.Time
+	GroupBy ClickStatsGroup
+	// ExperimentID limits stats to pushes of the experiment, it is required for grouping by variant
+	ExperimentID string
+}
+
+// ClickStats describes engagement of pushes in the group. Pushes are counted once per message
+// except grouping by device, where each device is counted separately.
+type ClickStats struct {
+	Key       string  `json:"key"`
+	Sends     int64   `json:"sends"`
+	Clicks    int64   `json:"clicks"`
+	Delivered int64   `json:"delivered"`
+	Dismissed int64   `json:"dismissed"`
+	CTR       float64 `json:"ctr"`
+	// time to click percentiles in seconds
+	TimeToClickP50 *float64 `json:"time_to_click_p50,omitempty"`
+	TimeToClickP90 *float64 `json:"time_to_click_p90,omitempty"`
+	TimeToClickP99 *float64 `json:"time_to_click_p99,omitempty"`
+}
+
+func (s *Service) ClickStats(ctx context.Context, query ClickStatsQuery) ([]ClickStats, error) {
+	if !query.GroupBy.Valid() {
+		return nil, fmt.Errorf("unsupported group: %s", query.GroupBy)
+	}
+
+	if query.GroupBy == GroupByVariant && query.ExperimentID == "" {
+		return nil, ErrExperimentRequired
+	}
+
+	if query.To.IsZero() {
+		query.To = s.now()
+	}
+
+	list, err := s.repo.ClickStats(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("s.repo.ClickStats: %w", err)
+	}
+
+	for idx := range list {
+		if list[idx].Sends > 0 {
+			list[idx].CTR = float64(list[idx].Clicks) / float64(list[idx].Sends)
+		}
+	}
+
+	return list, nil
+}
+
+// AnalyticsWorker exports click stats for the recent period as prometheus gauges.
+// Only groups with bounded number of values are exported.
+type AnalyticsWorker struct {
+	service *Service
+}
+
+func NewAnalyticsWorker(s *Service) *AnalyticsWorker {
+	return &AnalyticsWorker{
+		service: s,
+	}
+}
+
+func (w *AnalyticsWorker) Start(ctx context.Context) error {
+	for {
+		start := time.Now()
+		runCtx := workerRunContext(ctx, "analytics")
+		err := w.refresh(runCtx)
+		if err != nil {
+			logger(runCtx).Error().Err(err).Msg("refresh click stats")
+		}
+
+		observeWorkerRun("analytics", start, err)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(analyticsRefreshDelay):
+		}
+	}
+}
+
+func (w *AnalyticsWorker) refresh(ctx context.Context) error {
+	for _, group := range []ClickStatsGroup{GroupByTemplate, GroupByAction} {
+		list, err := w.service.ClickStats(ctx, ClickStatsQuery{
+			From:    time.Now().Add(-analyticsWindow),
+			GroupBy: group,
+		})
+		if err != nil {
+			return err
+		}
+
+		metricClickStatsSends.DeletePartialMatch(map[string]string{"group": string(group)})
+		metricClickStatsCTR.DeletePartialMatch(map[string]string{"group": string(group)})
+		metricClickStatsTimeToClick.DeletePartialMatch(map[string]string{"group": string(group)})
+
+		for _, info := range list {
+			metricClickStatsSends.WithLabelValues(string(group), info.Key).Set(float64(info.Sends))
+			metricClickStatsCTR.WithLabelValues(string(group), info.Key).Set(info.CTR)
+			if info.TimeToClickP50 != nil {
+				metricClickStatsTimeToClick.WithLabelValues(string(group), info.Key, "0.5").Set(*info.TimeToClickP50)
+			}
+			if info.TimeToClickP90 != nil {
+				metricClickStatsTimeToClick.WithLabelValues(string(group), info.Key, "0.9").Set(*info.TimeToClickP90)
+			}
+		}
+	}
+
+	return nil
+}
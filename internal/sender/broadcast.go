@@ -0,0 +1,541 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/goverland-labs/goverland-inbox-api-protocol/protobuf/inboxapi"
+	"github.com/rs/zerolog"
+	"golang.org/x/time/rate"
+	"gorm.io/gorm"
+
+	"github.com/goverland-labs/goverland-inbox-push/internal/config"
+)
+
+const (
+	defaultBroadcastsLimit   = 20
+	maxBroadcastTitleLength  = 100
+	maxBroadcastBodyLength   = 500
+	broadcastPreviewSample   = 10
+	broadcastProgressBatch   = 100
+	broadcastFinishTimeout   = 10 * time.Second
+	broadcastRecipientsError = "resolve recipients"
+)
+
+var (
+	ErrBroadcastNotFound = errors.New("broadcast not found")
+	ErrInvalidBroadcast  = errors.New("invalid broadcast")
+	// ErrBroadcastState is returned when the broadcast can't be moved from the current status
+	ErrBroadcastState = errors.New("unexpected broadcast status")
+)
+
+// BroadcastRequest composes the broadcast, it is sent once confirmed
+type BroadcastRequest struct {
+	Title    string `json:"title"`
+	Body     string `json:"body"`
+	ImageURL string `json:"image_url"`
+	DeepLink string `json:"deep_link"`
+	// Segment "all" includes only users who have received pushes before
+	Segment BroadcastSegment `json:"segment"`
+	UserIDs []uuid.UUID      `json:"user_ids"`
+	DaoID   *uuid.UUID       `json:"dao_id"`
+	// ScheduledAt is the time to send the broadcast at, it is sent right after confirmation if empty
+	ScheduledAt *time.Time `json:"scheduled_at"`
+}
+
+func (r BroadcastRequest) validate() error {
+	switch {
+	case r.Title == "":
+		return fmt.Errorf("%w: title is required", ErrInvalidBroadcast)
+	case len(r.Title) > maxBroadcastTitleLength:
+		return fmt.Errorf("%w: title is longer than %d", ErrInvalidBroadcast, maxBroadcastTitleLength)
+	case r.Body == "":
+		return fmt.Errorf("%w: body is required", ErrInvalidBroadcast)
+	case len(r.Body) > maxBroadcastBodyLength:
+		return fmt.Errorf("%w: body is longer than %d", ErrInvalidBroadcast, maxBroadcastBodyLength)
+	}
+
+	for name, value := range map[string]string{"image_url": r.ImageURL, "deep_link": r.DeepLink} {
+		if value == "" {
+			continue
+		}
+
+		if u, err := url.Parse(value); err != nil || u.Scheme == "" {
+			return fmt.Errorf("%w: invalid %s", ErrInvalidBroadcast, name)
+		}
+	}
+
+	switch r.Segment {
+	case BroadcastSegmentUsers:
+		if len(r.UserIDs) == 0 {
+			return fmt.Errorf("%w: user_ids are required for the users segment", ErrInvalidBroadcast)
+		}
+	case BroadcastSegmentDao:
+		if r.DaoID == nil {
+			return fmt.Errorf("%w: dao_id is required for the dao segment", ErrInvalidBroadcast)
+		}
+	case BroadcastSegmentAll:
+	default:
+		return fmt.Errorf("%w: unsupported segment %q", ErrInvalidBroadcast, r.Segment)
+	}
+
+	return nil
+}
+
+type BroadcastDetails struct {
+	ID          uint             `json:"id"`
+	Title       string           `json:"title"`
+	Body        string           `json:"body"`
+	ImageURL    string           `json:"image_url,omitempty"`
+	DeepLink    string           `json:"deep_link,omitempty"`
+	Segment     BroadcastSegment `json:"segment"`
+	UserIDs     []uuid.UUID      `json:"user_ids,omitempty"`
+	DaoID       *uuid.UUID       `json:"dao_id,omitempty"`
+	Status      BroadcastStatus  `json:"status"`
+	CreatedAt   time.Time        `json:"created_at"`
+	ScheduledAt *time.Time       `json:"scheduled_at,omitempty"`
+	ConfirmedAt *time.Time       `json:"confirmed_at,omitempty"`
+	StartedAt   *time.Time       `json:"started_at,omitempty"`
+	FinishedAt  *time.Time       `json:"finished_at,omitempty"`
+	Recipients  int              `json:"recipients"`
+	Processed   int              `json:"processed"`
+	Skipped     int              `json:"skipped"`
+	Failed      int              `json:"failed"`
+	Error       string           `json:"error,omitempty"`
+}
+
+// BroadcastPreview shows whom the draft is going to be sent to before the confirmation
+type BroadcastPreview struct {
+	Broadcast  BroadcastDetails `json:"broadcast"`
+	Recipients int              `json:"recipients"`
+	// Invalid is the number of subscribers with malformed ids, they are skipped
+	Invalid int         `json:"invalid,omitempty"`
+	Sample  []uuid.UUID `json:"sample"`
+	// Note explains the limitation of the segment
+	Note string `json:"note,omitempty"`
+}
+
+// broadcastSegmentAllNote is shown in the preview of the "all" segment, users are known to the service only
+// once they were queued a push, so users who registered devices but never got a push are not included
+const broadcastSegmentAllNote = "the all segment includes only users who have received pushes before, " +
+	"users who registered devices but never got a push are not included"
+
+// CreateBroadcast stores the draft and returns its preview, nothing is sent until confirmation
+func (s *Service) CreateBroadcast(ctx context.Context, req BroadcastRequest) (*BroadcastPreview, error) {
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+
+	item := &Broadcast{
+		Title:       req.Title,
+		Body:        req.Body,
+		ImageURL:    req.ImageURL,
+		DeepLink:    req.DeepLink,
+		Segment:     req.Segment,
+		UserIDs:     req.UserIDs,
+		DaoID:       req.DaoID,
+		Status:      BroadcastDraft,
+		ScheduledAt: req.ScheduledAt,
+	}
+
+	if item.Segment != BroadcastSegmentUsers {
+		item.UserIDs = nil
+	}
+	if item.Segment != BroadcastSegmentDao {
+		item.DaoID = nil
+	}
+
+	if err := s.repo.CreateBroadcast(ctx, item); err != nil {
+		return nil, fmt.Errorf("s.repo.CreateBroadcast: %w", err)
+	}
+
+	return s.previewBroadcast(ctx, item)
+}
+
+func (s *Service) PreviewBroadcast(ctx context.Context, id uint) (*BroadcastPreview, error) {
+	item, err := s.getBroadcast(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.previewBroadcast(ctx, item)
+}
+
+func (s *Service) previewBroadcast(ctx context.Context, item *Broadcast) (*BroadcastPreview, error) {
+	recipients, invalid, err := s.broadcastRecipients(ctx, item)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", broadcastRecipientsError, err)
+	}
+
+	preview := &BroadcastPreview{
+		Broadcast:  convertBroadcastToDetails(*item),
+		Recipients: len(recipients),
+		Invalid:    invalid,
+		Sample:     recipients[:min(len(recipients), broadcastPreviewSample)],
+	}
+	if item.Segment == BroadcastSegmentAll {
+		preview.Note = broadcastSegmentAllNote
+	}
+
+	return preview, nil
+}
+
+// ConfirmBroadcast schedules the draft for sending
+func (s *Service) ConfirmBroadcast(ctx context.Context, id uint) (*BroadcastDetails, error) {
+	item, err := s.getBroadcast(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	scheduledAt := now
+	if item.ScheduledAt != nil && item.ScheduledAt.After(now) {
+		scheduledAt = *item.ScheduledAt
+	}
+
+	return s.moveBroadcast(ctx, id, []BroadcastStatus{BroadcastDraft}, map[string]any{
+		"status":       BroadcastScheduled,
+		"scheduled_at": scheduledAt,
+		"confirmed_at": now,
+	})
+}
+
+// CancelBroadcast cancels the broadcast which is not being sent yet
+func (s *Service) CancelBroadcast(ctx context.Context, id uint) (*BroadcastDetails, error) {
+	return s.moveBroadcast(ctx, id, []BroadcastStatus{BroadcastDraft, BroadcastScheduled}, map[string]any{
+		"status": BroadcastCancelled,
+	})
+}
+
+func (s *Service) GetBroadcast(ctx context.Context, id uint) (*BroadcastDetails, error) {
+	item, err := s.getBroadcast(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	details := convertBroadcastToDetails(*item)
+
+	return &details, nil
+}
+
+func (s *Service) ListBroadcasts(ctx context.Context, limit int) ([]BroadcastDetails, error) {
+	if limit <= 0 {
+		limit = defaultBroadcastsLimit
+	}
+
+	list, err := s.repo.ListBroadcasts(ctx, limit)
+	if err != nil {
+		return nil, fmt.Errorf("s.repo.ListBroadcasts: %w", err)
+	}
+
+	result := make([]BroadcastDetails, 0, len(list))
+	for _, item := range list {
+		result = append(result, convertBroadcastToDetails(item))
+	}
+
+	return result, nil
+}
+
+func (s *Service) getBroadcast(ctx context.Context, id uint) (*Broadcast, error) {
+	item, err := s.repo.GetBroadcast(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrBroadcastNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("s.repo.GetBroadcast: %w", err)
+	}
+
+	return item, nil
+}
+
+func (s *Service) moveBroadcast(ctx context.Context, id uint, from []BroadcastStatus, updates map[string]any) (*BroadcastDetails, error) {
+	updated, err := s.repo.UpdateBroadcast(ctx, id, from, updates)
+	if err != nil {
+		return nil, fmt.Errorf("s.repo.UpdateBroadcast: %w", err)
+	}
+
+	item, err := s.getBroadcast(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !updated {
+		return nil, fmt.Errorf("%w: %s", ErrBroadcastState, item.Status)
+	}
+
+	details := convertBroadcastToDetails(*item)
+
+	return &details, nil
+}
+
+// broadcastRecipients returns unique users of the broadcast segment. Everyone means all users
+// the service has ever queued a push for, as there is no other source of users with push tokens.
+// broadcastRecipients returns unique recipients of the broadcast and the number of subscribers
+// skipped due to malformed ids. The all segment includes only users who have received pushes before.
+func (s *Service) broadcastRecipients(ctx context.Context, item *Broadcast) ([]uuid.UUID, int, error) {
+	var (
+		list    []uuid.UUID
+		invalid int
+	)
+	switch item.Segment {
+	case BroadcastSegmentUsers:
+		list = item.UserIDs
+	case BroadcastSegmentDao:
+		if item.DaoID == nil {
+			return nil, 0, fmt.Errorf("%w: empty dao id", ErrInvalidBroadcast)
+		}
+
+		resp, err := s.subscriptions.FindSubscribers(ctx, &inboxapi.FindSubscribersRequest{
+			DaoId: item.DaoID.String(),
+		})
+		if err != nil {
+			return nil, 0, fmt.Errorf("find subscribers by dao id %s: %w", item.DaoID, err)
+		}
+
+		list = make([]uuid.UUID, 0, len(resp.Users))
+		for _, sub := range resp.Users {
+			// a malformed id affects only its subscriber, the rest still get the broadcast
+			id, err := uuid.Parse(sub.GetUserId())
+			if err != nil {
+				logger(ctx).Warn().Err(err).Str("subscriber_id", sub.GetUserId()).Msg("skip broadcast subscriber due to invalid id")
+
+				invalid++
+
+				continue
+			}
+
+			list = append(list, id)
+		}
+	case BroadcastSegmentAll:
+		var err error
+		if list, err = s.repo.KnownUserIDs(ctx); err != nil {
+			return nil, 0, fmt.Errorf("s.repo.KnownUserIDs: %w", err)
+		}
+	default:
+		return nil, 0, fmt.Errorf("%w: unsupported segment %q", ErrInvalidBroadcast, item.Segment)
+	}
+
+	unique := make([]uuid.UUID, 0, len(list))
+	seen := make(map[uuid.UUID]struct{}, len(list))
+	for _, id := range list {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+
+		seen[id] = struct{}{}
+		unique = append(unique, id)
+	}
+
+	return unique, invalid, nil
+}
+
+// sendBroadcast sends the push to each recipient the same way as other pushes: users who
+// don't allow pushes are skipped, dedup keeps the broadcast from being sent twice to a device.
+func (s *Service) sendBroadcast(ctx context.Context, item *Broadcast, limiter *rate.Limiter) (err error) {
+	ctx, span := tracer().Start(ctx, "send broadcast")
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	ctx = withLogFields(ctx, func(c zerolog.Context) zerolog.Context {
+		return c.Uint("broadcast_id", item.ID)
+	})
+
+	recipients, invalid, err := s.broadcastRecipients(ctx, item)
+	if err != nil {
+		s.finishBroadcast(ctx, item, BroadcastFailed, fmt.Errorf("%s: %w", broadcastRecipientsError, err))
+
+		return err
+	}
+
+	// the broadcast claimed again after the crash starts counting over, dedup skips already notified devices.
+	// Subscribers with malformed ids are counted as skipped.
+	item.Recipients = len(recipients) + invalid
+	item.Processed, item.Skipped, item.Failed = 0, invalid, 0
+	s.saveBroadcastProgress(ctx, item)
+
+	logger(ctx).Info().Int("recipients", item.Recipients).Msg("start sending broadcast")
+
+	for idx, userID := range recipients {
+		if err := limiter.Wait(ctx); err != nil {
+			// the broadcast is sent again after restart, dedup skips already notified devices
+			if _, resetErr := s.repo.UpdateBroadcast(context.WithoutCancel(ctx), item.ID, []BroadcastStatus{BroadcastSending}, map[string]any{
+				"status": BroadcastScheduled,
+			}); resetErr != nil {
+				logger(ctx).Error().Err(resetErr).Msg("reschedule interrupted broadcast")
+			}
+
+			return err
+		}
+
+		switch err := s.sendBroadcastTo(ctx, item, userID); {
+		case errors.Is(err, errPushNotAllowed):
+			item.Skipped++
+		case err != nil:
+			item.Failed++
+			logger(ctx).Warn().Err(err).Stringer("user_id", userID).Msg("send broadcast to user")
+		default:
+			item.Processed++
+		}
+
+		if (idx+1)%broadcastProgressBatch == 0 {
+			s.saveBroadcastProgress(ctx, item)
+		}
+	}
+
+	s.finishBroadcast(ctx, item, BroadcastSent, nil)
+
+	logger(ctx).Info().
+		Int("processed", item.Processed).
+		Int("skipped", item.Skipped).
+		Int("failed", item.Failed).
+		Msg("broadcast is sent")
+
+	return nil
+}
+
+var errPushNotAllowed = errors.New("push is not allowed")
+
+func (s *Service) sendBroadcastTo(ctx context.Context, item *Broadcast, userID uuid.UUID) error {
+	res, err := s.usrs.AllowSendingPush(ctx, &inboxapi.AllowSendingPushRequest{UserId: userID.String()})
+	if err != nil {
+		return fmt.Errorf("s.usrs.AllowSendingPush: %w", err)
+	}
+	if !res.Allow {
+		return errPushNotAllowed
+	}
+
+	return s.Send(ctx, request{
+		userID:      userID,
+		title:       item.Title,
+		body:        item.Body,
+		imageURL:    item.ImageURL,
+		deepLink:    item.DeepLink,
+		broadcastID: item.ID,
+		template:    templateIDBroadcast,
+		actions:     []Action{BroadcastAnnounced},
+	})
+}
+
+func (s *Service) saveBroadcastProgress(ctx context.Context, item *Broadcast) {
+	_, err := s.repo.UpdateBroadcast(context.WithoutCancel(ctx), item.ID, []BroadcastStatus{BroadcastSending}, map[string]any{
+		"recipients": item.Recipients,
+		"processed":  item.Processed,
+		"skipped":    item.Skipped,
+		"failed":     item.Failed,
+	})
+	if err != nil {
+		logger(ctx).Error().Err(err).Msg("save broadcast progress")
+	}
+}
+
+func (s *Service) finishBroadcast(ctx context.Context, item *Broadcast, status BroadcastStatus, sendErr error) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), broadcastFinishTimeout)
+	defer cancel()
+
+	updates := map[string]any{
+		"status":      status,
+		"finished_at": s.now(),
+		"recipients":  item.Recipients,
+		"processed":   item.Processed,
+		"skipped":     item.Skipped,
+		"failed":      item.Failed,
+	}
+	if sendErr != nil {
+		updates["error"] = sendErr.Error()
+	}
+
+	if _, err := s.repo.UpdateBroadcast(ctx, item.ID, []BroadcastStatus{BroadcastSending}, updates); err != nil {
+		logger(ctx).Error().Err(err).Msg("finish broadcast")
+	}
+}
+
+func convertBroadcastToDetails(item Broadcast) BroadcastDetails {
+	return BroadcastDetails{
+		ID:          item.ID,
+		Title:       item.Title,
+		Body:        item.Body,
+		ImageURL:    item.ImageURL,
+		DeepLink:    item.DeepLink,
+		Segment:     item.Segment,
+		UserIDs:     slices.Clone(item.UserIDs),
+		DaoID:       item.DaoID,
+		Status:      item.Status,
+		CreatedAt:   item.CreatedAt,
+		ScheduledAt: item.ScheduledAt,
+		ConfirmedAt: item.ConfirmedAt,
+		StartedAt:   item.StartedAt,
+		FinishedAt:  item.FinishedAt,
+		Recipients:  item.Recipients,
+		Processed:   item.Processed,
+		Skipped:     item.Skipped,
+		Failed:      item.Failed,
+		Error:       item.Error,
+	}
+}
+
+type BroadcastWorker struct {
+	service *Service
+	limiter *rate.Limiter
+	cfg     func() config.Broadcast
+}
+
+// NewBroadcastWorker creates the worker, cfg is called on each run to pick up reloaded settings
+func NewBroadcastWorker(s *Service, cfg func() config.Broadcast) *BroadcastWorker {
+	return &BroadcastWorker{
+		service: s,
+		limiter: rate.NewLimiter(rate.Limit(max(cfg().Rate, 1)), 1),
+		cfg:     cfg,
+	}
+}
+
+// Start sends due broadcasts one by one, a disabled worker keeps due broadcasts scheduled
+func (w *BroadcastWorker) Start(ctx context.Context) error {
+	for {
+		cfg := w.cfg()
+		w.limiter.SetLimit(rate.Limit(max(cfg.Rate, 1)))
+
+		start := time.Now()
+		runCtx := workerRunContext(ctx, "broadcast")
+
+		var err error
+		if cfg.Enabled {
+			err = w.sendDue(runCtx, cfg.Lease)
+		}
+		if err != nil && ctx.Err() == nil {
+			logger(runCtx).Error().Err(err).Msg("send broadcasts")
+		}
+
+		observeWorkerRun("broadcast", start, err)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(cfg.CheckInterval):
+		}
+	}
+}
+
+// sendDue sends due broadcasts and the ones which have not saved progress for the lease,
+// the progress is saved every broadcastProgressBatch recipients
+func (w *BroadcastWorker) sendDue(ctx context.Context, lease time.Duration) error {
+	for {
+		now := w.service.now()
+		item, err := w.service.repo.ClaimDueBroadcast(ctx, now, now.Add(-lease))
+		if err != nil {
+			return fmt.Errorf("claim due broadcast: %w", err)
+		}
+		if item == nil {
+			return nil
+		}
+
+		if err := w.service.sendBroadcast(ctx, item, w.limiter); err != nil {
+			return fmt.Errorf("send broadcast %d: %w", item.ID, err)
+		}
+	}
+}
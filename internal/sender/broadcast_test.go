@@ -0,0 +1,140 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/goverland-labs/goverland-inbox-api-protocol/protobuf/inboxapi"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBroadcastRequestValidate(t *testing.T) {
+	daoID := uuid.New()
+	valid := BroadcastRequest{
+		Title:    "Goverland",
+		Body:     "New version is available",
+		DeepLink: "goverland://settings",
+		Segment:  BroadcastSegmentAll,
+	}
+
+	for name, tc := range map[string]struct {
+		modify func(r BroadcastRequest) BroadcastRequest
+		valid  bool
+	}{
+		"everyone": {
+			modify: func(r BroadcastRequest) BroadcastRequest { return r },
+			valid:  true,
+		},
+		"dao subscribers": {
+			modify: func(r BroadcastRequest) BroadcastRequest {
+				r.Segment, r.DaoID = BroadcastSegmentDao, &daoID
+				return r
+			},
+			valid: true,
+		},
+		"users": {
+			modify: func(r BroadcastRequest) BroadcastRequest {
+				r.Segment, r.UserIDs = BroadcastSegmentUsers, []uuid.UUID{uuid.New()}
+				return r
+			},
+			valid: true,
+		},
+		"dao without id": {
+			modify: func(r BroadcastRequest) BroadcastRequest {
+				r.Segment = BroadcastSegmentDao
+				return r
+			},
+		},
+		"users without ids": {
+			modify: func(r BroadcastRequest) BroadcastRequest {
+				r.Segment = BroadcastSegmentUsers
+				return r
+			},
+		},
+		"unknown segment": {
+			modify: func(r BroadcastRequest) BroadcastRequest {
+				r.Segment = "admins"
+				return r
+			},
+		},
+		"empty title": {
+			modify: func(r BroadcastRequest) BroadcastRequest {
+				r.Title = ""
+				return r
+			},
+		},
+		"relative deep link": {
+			modify: func(r BroadcastRequest) BroadcastRequest {
+				r.DeepLink = "/settings"
+				return r
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := tc.modify(valid).validate()
+			if tc.valid {
+				require.NoError(t, err)
+				return
+			}
+
+			require.ErrorIs(t, err, ErrInvalidBroadcast)
+		})
+	}
+}
+
+func TestBroadcastRecipients(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	subs := NewMockSubscriptionsFinder(ctrl)
+	s := &Service{subscriptions: subs}
+
+	first, second := uuid.New(), uuid.New()
+
+	list, invalid, err := s.broadcastRecipients(context.Background(), &Broadcast{
+		Segment: BroadcastSegmentUsers,
+		UserIDs: []uuid.UUID{first, second, first},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []uuid.UUID{first, second}, list)
+	require.Zero(t, invalid)
+
+	daoID := uuid.New()
+	subs.EXPECT().
+		FindSubscribers(gomock.Any(), &inboxapi.FindSubscribersRequest{DaoId: daoID.String()}).
+		Return(&inboxapi.UserList{Users: []*inboxapi.UserID{
+			{UserId: "not-a-uuid"},
+			{UserId: second.String()},
+		}}, nil)
+
+	// the malformed subscriber id does not fail the whole segment
+	list, invalid, err = s.broadcastRecipients(context.Background(), &Broadcast{
+		Segment: BroadcastSegmentDao,
+		DaoID:   &daoID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []uuid.UUID{second}, list)
+	require.Equal(t, 1, invalid)
+}
+
+func TestSendBroadcastTo(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	usrs := NewMockUsersFinder(ctrl)
+	s := &Service{usrs: usrs}
+
+	usrs.EXPECT().
+		AllowSendingPush(gomock.Any(), gomock.Any()).
+		Return(&inboxapi.AllowSendingPushResponse{Allow: false}, nil)
+
+	err := s.sendBroadcastTo(context.Background(), &Broadcast{}, uuid.New())
+	require.ErrorIs(t, err, errPushNotAllowed)
+
+	usrs.EXPECT().
+		AllowSendingPush(gomock.Any(), gomock.Any()).
+		Return(nil, errors.New("unavailable"))
+
+	err = s.sendBroadcastTo(context.Background(), &Broadcast{}, uuid.New())
+	require.Error(t, err)
+	require.NotErrorIs(t, err, errPushNotAllowed)
+}
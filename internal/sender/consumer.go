@@ -2,14 +2,19 @@ package sender
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	pevents "github.com/goverland-labs/goverland-platform-events/events/inbox"
 	client "github.com/goverland-labs/goverland-platform-events/pkg/natsclient"
 	"github.com/nats-io/nats.go"
-	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/goverland-labs/goverland-inbox-push/internal/config"
 	"github.com/goverland-labs/goverland-inbox-push/internal/metrics"
@@ -19,35 +24,78 @@ const (
 	maxPendingElements = 100
 	rateLimit          = 500 * client.KiB
 	executionTtl       = time.Minute
+	nackDelay          = time.Second
+	// inProgressDelay postpones the redelivery of the event which is being processed by another attempt
+	inProgressDelay = 10 * time.Second
+)
+
+const (
+	consumerActionAck        = "ack"
+	consumerActionNack       = "nack"
+	consumerActionDeadLetter = "dead_letter"
+	consumerActionInProgress = "in_progress"
 )
 
 type PushManipulator interface {
-	MarkAsClicked(id uuid.UUID) error
+	MarkAsClicked(ctx context.Context, id uuid.UUID) error
+	MarkAsDelivered(ctx context.Context, id uuid.UUID) error
+	MarkAsDismissed(ctx context.Context, id uuid.UUID) error
 	ProcessFeedItem(ctx context.Context, item Item) error
+	ProcessFeedEvent(ctx context.Context, key string, item Item) error
+}
+
+type DeadLetterSink interface {
+	Put(ctx context.Context, item *DeadLetter) error
 }
 
 type closable interface {
 	Close() error
 }
 
+type subscription struct {
+	sub *nats.Subscription
+}
+
+func (s *subscription) Close() error {
+	return s.sub.Drain()
+}
+
 type Consumer struct {
-	conn      *nats.Conn
-	service   PushManipulator
-	consumers []closable
+	conn          *nats.Conn
+	service       PushManipulator
+	deadLetters   DeadLetterSink
+	maxDeliveries int
+	consumers     []closable
 }
 
-func NewConsumer(nc *nats.Conn, s *Service) (*Consumer, error) {
+func NewConsumer(nc *nats.Conn, s *Service, dl DeadLetterSink, cfg config.DeadLetter) (*Consumer, error) {
 	c := &Consumer{
-		conn:      nc,
-		service:   s,
-		consumers: make([]closable, 0),
+		conn:          nc,
+		service:       s,
+		deadLetters:   dl,
+		maxDeliveries: cfg.MaxDeliveries,
+		consumers:     make([]closable, 0),
 	}
 
 	return c, nil
 }
 
-func (c *Consumer) clickHandler() pevents.PushClickHandler {
-	return func(payload pevents.PushClickPayload) error {
+// handler processes the message within the context carrying the trace of the message
+type handler func(ctx context.Context, data []byte) error
+
+func jsonHandler[T any](h func(ctx context.Context, payload T) error) handler {
+	return func(ctx context.Context, data []byte) error {
+		var payload T
+		if err := json.Unmarshal(data, &payload); err != nil {
+			return err
+		}
+
+		return h(ctx, payload)
+	}
+}
+
+func (c *Consumer) clickHandler() func(ctx context.Context, payload pevents.PushClickPayload) error {
+	return func(ctx context.Context, payload pevents.PushClickPayload) error {
 		var err error
 		defer func(start time.Time) {
 			metricHandleHistogram.
@@ -55,7 +103,7 @@ func (c *Consumer) clickHandler() pevents.PushClickHandler {
 				Observe(time.Since(start).Seconds())
 		}(time.Now())
 
-		err = c.service.MarkAsClicked(payload.ID)
+		err = c.service.MarkAsClicked(ctx, payload.ID)
 
 		collectStats("mark", "clicked", err)
 
@@ -65,36 +113,179 @@ func (c *Consumer) clickHandler() pevents.PushClickHandler {
 
 func (c *Consumer) Start(ctx context.Context) error {
 	group := config.GenerateGroupName("send_push")
-	opts := []client.ConsumerOpt{
-		client.WithRateLimit(rateLimit),
-		client.WithMaxAckPending(maxPendingElements),
-		client.WithAckWait(executionTtl),
-	}
 
-	clicked, err := client.NewConsumer(ctx, c.conn, group, pevents.SubjectPushClicked, c.clickHandler(), opts...)
+	clicked, err := c.subscribe(ctx, group, pevents.SubjectPushClicked, jsonHandler(c.clickHandler()))
 	if err != nil {
 		return fmt.Errorf("consume for %s/%s: %w", group, pevents.SubjectPushClicked, err)
 	}
-	feed, err := client.NewConsumer(ctx, c.conn, group, pevents.SubjectFeedUpdated, c.handleFeed(), opts...)
+	delivered, err := c.subscribe(ctx, group, SubjectPushDelivered, jsonHandler(c.deliveredHandler()))
+	if err != nil {
+		return fmt.Errorf("consume for %s/%s: %w", group, SubjectPushDelivered, err)
+	}
+	dismissed, err := c.subscribe(ctx, group, SubjectPushDismissed, jsonHandler(c.dismissedHandler()))
+	if err != nil {
+		return fmt.Errorf("consume for %s/%s: %w", group, SubjectPushDismissed, err)
+	}
+	feed, err := c.subscribe(ctx, group, pevents.SubjectFeedUpdated, jsonHandler(c.handleFeed()))
 	if err != nil {
 		return fmt.Errorf("consume for %s/%s: %w", group, pevents.SubjectFeedUpdated, err)
 	}
 
-	c.consumers = append(c.consumers, clicked, feed)
+	c.consumers = append(c.consumers, clicked, delivered, dismissed, feed)
 
-	log.Info().Msg("sender consumers is started")
+	logger(ctx).Info().Str("group", group).Msg("sender consumers is started")
 
 	// todo: handle correct stopping the consumer by context
 	<-ctx.Done()
-	return c.stop()
+	return c.stop(ctx)
 }
 
-func (c *Consumer) stop() error {
+// subscribe binds to the same durable consumer as natsclient.NewConsumer does,
+// but has access to the message metadata to count deliveries and to the trace headers.
+// TestConsumerMatchesNatsclient keeps the stream and the consumer in line with natsclient.
+func (c *Consumer) subscribe(ctx context.Context, group, subject string, h handler) (closable, error) {
+	js, err := c.conn.JetStream()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ensureStream(js, subject); err != nil {
+		return nil, err
+	}
+
+	sub, err := js.QueueSubscribe(subject, group, func(msg *nats.Msg) {
+		var (
+			start      = time.Now()
+			deliveries = 1
+		)
+
+		if meta, err := msg.Metadata(); err == nil {
+			deliveries = int(meta.NumDelivered)
+		}
+
+		msgCtx, span := tracer().Start(messageContext(ctx, msg.Header), "consume "+subject,
+			trace.WithSpanKind(trace.SpanKindConsumer),
+			trace.WithAttributes(
+				semconv.MessagingSystemKey.String("nats"),
+				semconv.MessagingDestinationName(subject),
+				attribute.Int("messaging.nats.deliveries", deliveries),
+			),
+		)
+
+		action, err := c.process(msgCtx, subject, msg.Data, deliveries, h)
+		defer func() {
+			client.CollectConsumerMetric(subject, action, err, time.Since(start).Seconds())
+
+			span.SetAttributes(attribute.String("messaging.nats.action", action))
+			endSpan(span, err)
+		}()
+
+		switch action {
+		case consumerActionNack:
+			if err := msg.NakWithDelay(nackDelay); err != nil {
+				logger(msgCtx).Error().Err(err).Str("group", group).Str("subject", subject).Msg("nack message")
+			}
+
+			return
+		case consumerActionInProgress:
+			if err := msg.NakWithDelay(inProgressDelay); err != nil {
+				logger(msgCtx).Error().Err(err).Str("group", group).Str("subject", subject).Msg("nack message")
+			}
+
+			return
+		}
+
+		if err := msg.AckSync(); err != nil {
+			logger(msgCtx).Error().Err(err).Str("group", group).Str("subject", subject).Msg("ack message")
+		}
+	},
+		nats.Durable(consumerName(group, subject)),
+		nats.ManualAck(),
+		nats.DeliverAll(),
+		nats.Context(ctx),
+		nats.AckWait(executionTtl),
+		nats.MaxAckPending(maxPendingElements),
+		nats.RateLimit(rateLimit),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("queue subscribe: %w", err)
+	}
+
+	return &subscription{sub: sub}, nil
+}
+
+// process handles the message and decides what to do with it. The message is moved
+// to dead letters when it keeps failing after max deliveries. The event which is being processed
+// by another attempt is redelivered later and never moved to dead letters.
+func (c *Consumer) process(ctx context.Context, subject string, data []byte, deliveries int, h handler) (string, error) {
+	err := h(ctx, data)
+	if err == nil {
+		return consumerActionAck, nil
+	}
+
+	if errors.Is(err, ErrEventInProgress) {
+		return consumerActionInProgress, err
+	}
+
+	if c.maxDeliveries <= 0 || deliveries < c.maxDeliveries {
+		return consumerActionNack, err
+	}
+
+	logger(ctx).Error().Err(err).
+		Str("subject", subject).
+		Int("deliveries", deliveries).
+		Msg("move event to dead letters")
+
+	dlErr := c.deadLetters.Put(ctx, &DeadLetter{
+		Subject:    subject,
+		Payload:    string(data),
+		Error:      err.Error(),
+		Deliveries: deliveries,
+	})
+	if dlErr != nil {
+		logger(ctx).Error().Err(dlErr).Str("subject", subject).Msg("put event to dead letters")
+
+		return consumerActionNack, err
+	}
+
+	return consumerActionDeadLetter, err
+}
+
+func (c *Consumer) stop(ctx context.Context) error {
 	for _, cs := range c.consumers {
 		if err := cs.Close(); err != nil {
-			log.Error().Err(err).Msg("unable to close sender consumer")
+			logger(ctx).Error().Err(err).Msg("unable to close sender consumer")
 		}
 	}
 
 	return nil
 }
+
+// ensureStream creates the stream the same way natsclient does, so the consumer
+// can be started before anything was published to a new subject.
+func ensureStream(js nats.JetStreamContext, subject string) error {
+	name := strings.ReplaceAll(fmt.Sprintf("str_%s", subject), ".", "_")
+	if _, err := js.StreamInfo(name); err == nil {
+		return nil
+	} else if !errors.Is(err, nats.ErrStreamNotFound) {
+		return fmt.Errorf("get stream info [%s]: %w", name, err)
+	}
+
+	_, err := js.AddStream(&nats.StreamConfig{
+		Name:      name,
+		Subjects:  []string{subject},
+		Retention: nats.LimitsPolicy,
+		Discard:   nats.DiscardOld,
+		Storage:   nats.FileStorage,
+		MaxAge:    client.StreamDefaultMaxAge,
+	})
+	if err != nil {
+		return fmt.Errorf("add stream [%s]: %w", name, err)
+	}
+
+	return nil
+}
+
+func consumerName(group, subject string) string {
+	return strings.ReplaceAll(fmt.Sprintf("consumer_%s_%s", group, subject), ".", "_")
+}
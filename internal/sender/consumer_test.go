@@ -0,0 +1,212 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/goverland-labs/goverland-platform-events/pkg/natsclient"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsumerProcess(t *testing.T) {
+	handleErr := errors.New("handle error")
+
+	for name, tc := range map[string]struct {
+		handlerErr    error
+		maxDeliveries int
+		deliveries    int
+		sink          func(ctrl *gomock.Controller) DeadLetterSink
+		action        string
+	}{
+		"processed": {
+			maxDeliveries: 3,
+			deliveries:    1,
+			sink: func(ctrl *gomock.Controller) DeadLetterSink {
+				return NewMockDeadLetterSink(ctrl)
+			},
+			action: consumerActionAck,
+		},
+		"failed before max deliveries": {
+			handlerErr:    handleErr,
+			maxDeliveries: 3,
+			deliveries:    2,
+			sink: func(ctrl *gomock.Controller) DeadLetterSink {
+				return NewMockDeadLetterSink(ctrl)
+			},
+			action: consumerActionNack,
+		},
+		"failed on max deliveries": {
+			handlerErr:    handleErr,
+			maxDeliveries: 3,
+			deliveries:    3,
+			sink: func(ctrl *gomock.Controller) DeadLetterSink {
+				m := NewMockDeadLetterSink(ctrl)
+				m.EXPECT().
+					Put(gomock.Any(), &DeadLetter{
+						Subject:    "subject",
+						Payload:    "payload",
+						Error:      handleErr.Error(),
+						Deliveries: 3,
+					}).
+					Times(1).
+					Return(nil)
+
+				return m
+			},
+			action: consumerActionDeadLetter,
+		},
+		"unable to put dead letter": {
+			handlerErr:    handleErr,
+			maxDeliveries: 3,
+			deliveries:    4,
+			sink: func(ctrl *gomock.Controller) DeadLetterSink {
+				m := NewMockDeadLetterSink(ctrl)
+				m.EXPECT().
+					Put(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(errors.New("unavailable"))
+
+				return m
+			},
+			action: consumerActionNack,
+		},
+		"in progress on max deliveries": {
+			handlerErr:    ErrEventInProgress,
+			maxDeliveries: 3,
+			deliveries:    3,
+			sink: func(ctrl *gomock.Controller) DeadLetterSink {
+				return NewMockDeadLetterSink(ctrl)
+			},
+			action: consumerActionInProgress,
+		},
+		"dead letters disabled": {
+			handlerErr:    handleErr,
+			maxDeliveries: 0,
+			deliveries:    100,
+			sink: func(ctrl *gomock.Controller) DeadLetterSink {
+				return NewMockDeadLetterSink(ctrl)
+			},
+			action: consumerActionNack,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			c := &Consumer{
+				deadLetters:   tc.sink(ctrl),
+				maxDeliveries: tc.maxDeliveries,
+			}
+
+			action, err := c.process(context.Background(), "subject", []byte("payload"), tc.deliveries, func(context.Context, []byte) error {
+				return tc.handlerErr
+			})
+			require.Equal(t, tc.action, action)
+			require.ErrorIs(t, err, tc.handlerErr)
+		})
+	}
+}
+
+// TestConsumerMatchesNatsclient pins the stream and the durable consumer to the ones natsclient
+// creates, so the service shares them with natsclient publishers and consumers of the same group
+func TestConsumerMatchesNatsclient(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("stream created by natsclient is reused", func(t *testing.T) {
+		nc, js := connectNats(t)
+
+		publisher, err := natsclient.NewPublisher(nc)
+		require.NoError(t, err)
+		require.NoError(t, publisher.PublishJSON(ctx, "inbox.test.first", struct{}{}))
+
+		require.NoError(t, ensureStream(js, "inbox.test.first"))
+		require.Equal(t, []string{"str_inbox_test_first"}, streamNames(js))
+	})
+
+	t.Run("stream created here is reused by natsclient", func(t *testing.T) {
+		nc, js := connectNats(t)
+
+		require.NoError(t, ensureStream(js, "inbox.test.first"))
+
+		publisher, err := natsclient.NewPublisher(nc)
+		require.NoError(t, err)
+		require.NoError(t, publisher.PublishJSON(ctx, "inbox.test.first", struct{}{}))
+		require.NoError(t, publisher.PublishJSON(ctx, "inbox.test.second", struct{}{}))
+		require.ElementsMatch(t, []string{"str_inbox_test_first", "str_inbox_test_second"}, streamNames(js))
+
+		own, err := js.StreamInfo("str_inbox_test_first")
+		require.NoError(t, err)
+		created, err := js.StreamInfo("str_inbox_test_second")
+		require.NoError(t, err)
+
+		own.Config.Name, own.Config.Subjects = created.Config.Name, created.Config.Subjects
+		require.Equal(t, created.Config, own.Config)
+	})
+
+	t.Run("durable consumer created by natsclient is reused", func(t *testing.T) {
+		nc, js := connectNats(t)
+
+		consumer, err := natsclient.NewConsumer(ctx, nc, "push", "inbox.test.first", func(struct{}) error { return nil },
+			natsclient.WithAckWait(executionTtl),
+			natsclient.WithMaxAckPending(maxPendingElements),
+			natsclient.WithRateLimit(rateLimit),
+		)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = consumer.Close() })
+
+		sub, err := (&Consumer{conn: nc}).subscribe(ctx, "push", "inbox.test.first", func(context.Context, []byte) error { return nil })
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = sub.Close() })
+
+		require.Equal(t, []string{consumerName("push", "inbox.test.first")}, consumerNames(js, "str_inbox_test_first"))
+		require.Equal(t, "consumer_push_inbox_test_first", consumerName("push", "inbox.test.first"))
+	})
+}
+
+func connectNats(t *testing.T) (*nats.Conn, nats.JetStreamContext) {
+	t.Helper()
+
+	srv, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      server.RANDOM_PORT,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	require.NoError(t, err)
+
+	go srv.Start()
+	require.True(t, srv.ReadyForConnections(5*time.Second))
+	t.Cleanup(srv.Shutdown)
+
+	nc, err := nats.Connect(srv.ClientURL())
+	require.NoError(t, err)
+	t.Cleanup(nc.Close)
+
+	js, err := nc.JetStream()
+	require.NoError(t, err)
+
+	return nc, js
+}
+
+func streamNames(js nats.JetStreamContext) []string {
+	var names []string
+	for name := range js.StreamNames() {
+		names = append(names, name)
+	}
+
+	return names
+}
+
+func consumerNames(js nats.JetStreamContext, stream string) []string {
+	var names []string
+	for name := range js.ConsumerNames(stream) {
+		names = append(names, name)
+	}
+
+	return names
+}
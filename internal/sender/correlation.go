@@ -0,0 +1,73 @@
+package sender
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+const correlationIDField = "correlation_id"
+
+type correlationIDKey struct{}
+
+// withCorrelationID stores the correlation id in the context and adds it to the context logger
+func withCorrelationID(ctx context.Context, id string) context.Context {
+	ctx = context.WithValue(ctx, correlationIDKey{}, id)
+
+	return withLogFields(ctx, func(c zerolog.Context) zerolog.Context {
+		return c.Str(correlationIDField, id)
+	})
+}
+
+// ensureCorrelationID keeps the correlation id of the context or starts a new one
+func ensureCorrelationID(ctx context.Context) context.Context {
+	if CorrelationID(ctx) != "" {
+		return ctx
+	}
+
+	return withCorrelationID(ctx, uuid.NewString())
+}
+
+// CorrelationID returns the correlation id of the feed event or worker run, empty if there is none
+func CorrelationID(ctx context.Context) string {
+	id, _ := ctx.Value(correlationIDKey{}).(string)
+
+	return id
+}
+
+// withLogFields returns the context with the logger extended by the fields
+func withLogFields(ctx context.Context, fields func(c zerolog.Context) zerolog.Context) context.Context {
+	l := fields(logger(ctx).With()).Logger()
+
+	return l.WithContext(ctx)
+}
+
+// logger returns the logger of the context falling back to the global one
+func logger(ctx context.Context) *zerolog.Logger {
+	if l := zerolog.Ctx(ctx); l.GetLevel() != zerolog.Disabled {
+		return l
+	}
+
+	return &log.Logger
+}
+
+// workerRunContext starts a new correlation id for a single run of the worker
+func workerRunContext(ctx context.Context, worker string) context.Context {
+	ctx = withCorrelationID(ctx, uuid.NewString())
+
+	return withLogFields(ctx, func(c zerolog.Context) zerolog.Context {
+		return c.Str("worker", worker)
+	})
+}
+
+// withItemLogFields adds the feed item to the context logger
+func withItemLogFields(ctx context.Context, item Item) context.Context {
+	return withLogFields(ctx, func(c zerolog.Context) zerolog.Context {
+		return c.
+			Stringer("dao_id", item.DaoID).
+			Str("proposal_id", item.ProposalID).
+			Str("action", string(item.Action))
+	})
+}
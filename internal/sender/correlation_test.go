@@ -0,0 +1,52 @@
+package sender
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCorrelationID(t *testing.T) {
+	ctx := context.Background()
+	require.Empty(t, CorrelationID(ctx))
+
+	ctx = ensureCorrelationID(ctx)
+	id := CorrelationID(ctx)
+	require.NotEmpty(t, id)
+
+	// existing id is kept
+	require.Equal(t, id, CorrelationID(ensureCorrelationID(ctx)))
+	require.Equal(t, "other", CorrelationID(withCorrelationID(ctx, "other")))
+	require.NotEqual(t, id, CorrelationID(workerRunContext(ctx, "test-worker")))
+}
+
+func TestCorrelationLogFields(t *testing.T) {
+	var buf bytes.Buffer
+	ctx := zerolog.New(&buf).WithContext(context.Background())
+
+	item := Item{DaoID: uuid.New(), ProposalID: "proposal", Action: ProposalCreated}
+	ctx = withItemLogFields(withCorrelationID(ctx, "event-key"), item)
+
+	logger(ctx).Info().Msg("processed")
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	require.Equal(t, "event-key", entry["correlation_id"])
+	require.Equal(t, item.DaoID.String(), entry["dao_id"])
+	require.Equal(t, "proposal", entry["proposal_id"])
+	require.Equal(t, string(ProposalCreated), entry["action"])
+}
+
+func TestCorrelationIDInEvents(t *testing.T) {
+	ctx := withCorrelationID(context.Background(), "run")
+
+	var payload PushEventPayload
+	msg := queueItemEvent(SubjectPushQueued, SendQueue{CorrelationID: CorrelationID(ctx)})
+	require.NoError(t, json.Unmarshal([]byte(msg.Payload), &payload))
+	require.Equal(t, "run", payload.CorrelationID)
+}
@@ -0,0 +1,200 @@
+package sender
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"firebase.google.com/go/v4/messaging"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+
+	"github.com/goverland-labs/goverland-inbox-push/internal/config"
+)
+
+// forcedReloadInterval limits reloads on auth errors when credentials are not changed,
+// so revoked credentials don't rebuild the client on each send
+const forcedReloadInterval = time.Minute
+
+// firebaseClient is the messaging client built from the single version of credentials
+type firebaseClient struct {
+	sender MessageSender
+	tokens oauth2.TokenSource
+	data   []byte
+}
+
+// FirebaseSender sends messages through the client built from the current credentials.
+// The client is swapped atomically when credentials are changed, in-flight sends finish on the old one.
+type FirebaseSender struct {
+	cfg    config.Push
+	client atomic.Pointer[firebaseClient]
+	build  func(ctx context.Context, data []byte, projectID string) (MessageSender, error)
+
+	mu         sync.Mutex
+	modTime    time.Time
+	reloadedAt time.Time
+}
+
+func NewFirebaseSender(cfg config.Push) (*FirebaseSender, error) {
+	f := &FirebaseSender{
+		cfg:   cfg,
+		build: makeSender,
+	}
+
+	if _, err := f.Reload(context.Background(), true); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (f *FirebaseSender) Send(ctx context.Context, message *messaging.Message) (string, error) {
+	return f.client.Load().sender.Send(ctx, message)
+}
+
+// TokenSource returns the cached access token source of the current credentials
+func (f *FirebaseSender) TokenSource() oauth2.TokenSource {
+	return f.client.Load().tokens
+}
+
+// CheckCredentials checks that credentials can be exchanged for the access token.
+// The token is cached until it expires, so the check doesn't call google on each probe.
+func (f *FirebaseSender) CheckCredentials(_ context.Context) error {
+	if _, err := f.TokenSource().Token(); err != nil {
+		return fmt.Errorf("get access token: %w", err)
+	}
+
+	return nil
+}
+
+// Reload reads credentials and swaps the client if they are changed. With force the client is
+// rebuilt from the same credentials too, at most once per forcedReloadInterval.
+// It returns true if the client is swapped.
+func (f *FirebaseSender) Reload(ctx context.Context, force bool) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	data, modTime, err := readCredentials(f.cfg)
+	if err != nil {
+		collectStats("credentials", "reload", err)
+		return false, err
+	}
+
+	current := f.client.Load()
+	if current != nil && bytes.Equal(current.data, data) {
+		if !force || time.Since(f.reloadedAt) < forcedReloadInterval {
+			f.modTime = modTime
+			return false, nil
+		}
+	}
+
+	client, err := newFirebaseClient(ctx, data, f.build)
+	collectStats("credentials", "reload", err)
+	if err != nil {
+		return false, err
+	}
+
+	f.client.Store(client)
+	f.modTime = modTime
+	f.reloadedAt = time.Now()
+
+	return true, nil
+}
+
+// Start reloads credentials when the credentials file is changed
+func (f *FirebaseSender) Start(ctx context.Context) error {
+	path := f.cfg.CredentialsPath()
+	if path == "" {
+		<-ctx.Done()
+		return nil
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(f.cfg.CredentialsWatchInterval):
+		}
+
+		runCtx := workerRunContext(ctx, "firebase-credentials")
+		if !f.fileChanged(runCtx, path) {
+			continue
+		}
+
+		swapped, err := f.Reload(runCtx, false)
+		if err != nil {
+			logger(runCtx).Error().Err(err).Str("path", path).Msg("reload firebase credentials, the current ones are kept")
+			continue
+		}
+
+		if swapped {
+			logger(runCtx).Info().Str("path", path).Msg("firebase credentials are reloaded")
+		}
+	}
+}
+
+func (f *FirebaseSender) fileChanged(ctx context.Context, path string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		logger(ctx).Error().Err(err).Str("path", path).Msg("stat firebase credentials file")
+		return false
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return !info.ModTime().Equal(f.modTime)
+}
+
+func newFirebaseClient(
+	ctx context.Context,
+	data []byte,
+	build func(ctx context.Context, data []byte, projectID string) (MessageSender, error),
+) (*firebaseClient, error) {
+	creds, err := google.CredentialsFromJSON(ctx, data, firebaseMessagingScope)
+	if err != nil {
+		return nil, fmt.Errorf("parse credentials: %w", err)
+	}
+
+	if creds.ProjectID == "" {
+		return nil, errors.New("project id is required")
+	}
+
+	sender, err := build(ctx, data, creds.ProjectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make sender: %w", err)
+	}
+
+	return &firebaseClient{
+		sender: sender,
+		tokens: oauth2.ReuseTokenSource(nil, creds.TokenSource),
+		data:   data,
+	}, nil
+}
+
+// readCredentials returns the service account from the file or from the config fields
+func readCredentials(cfg config.Push) ([]byte, time.Time, error) {
+	path := cfg.CredentialsPath()
+	if path == "" {
+		data, err := json.Marshal(cfg)
+		return data, time.Time{}, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("stat credentials file: %w", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("read credentials file: %w", err)
+	}
+
+	return data, info.ModTime(), nil
+}
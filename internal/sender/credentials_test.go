@@ -0,0 +1,112 @@
+package sender
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"firebase.google.com/go/v4/messaging"
+	"github.com/stretchr/testify/require"
+
+	"github.com/goverland-labs/goverland-inbox-push/internal/config"
+)
+
+// projectSender answers with the project of credentials it is built from
+type projectSender string
+
+func (p projectSender) Send(_ context.Context, _ *messaging.Message) (string, error) {
+	return string(p), nil
+}
+
+func writeServiceAccount(t *testing.T, path, projectID string, modTime time.Time) {
+	t.Helper()
+
+	data := fmt.Sprintf(`{"type":"service_account","project_id":%q,"private_key":"key","client_email":"push@example.com"}`, projectID)
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func newTestFirebaseSender(t *testing.T, path string) *FirebaseSender {
+	t.Helper()
+
+	f := &FirebaseSender{
+		cfg: config.Push{CredentialsFile: path},
+		build: func(_ context.Context, _ []byte, projectID string) (MessageSender, error) {
+			return projectSender(projectID), nil
+		},
+	}
+	_, err := f.Reload(context.Background(), true)
+	require.NoError(t, err)
+
+	return f
+}
+
+func TestFirebaseSenderReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "service-account.json")
+	now := time.Now()
+	writeServiceAccount(t, path, "project-1", now)
+
+	f := newTestFirebaseSender(t, path)
+	old := f.client.Load()
+
+	// unchanged credentials keep the client
+	swapped, err := f.Reload(context.Background(), false)
+	require.NoError(t, err)
+	require.False(t, swapped)
+	require.False(t, f.fileChanged(context.Background(), path))
+
+	writeServiceAccount(t, path, "project-2", now.Add(time.Minute))
+	require.True(t, f.fileChanged(context.Background(), path))
+
+	swapped, err = f.Reload(context.Background(), false)
+	require.NoError(t, err)
+	require.True(t, swapped)
+	require.False(t, f.fileChanged(context.Background(), path))
+
+	response, err := f.Send(context.Background(), &messaging.Message{})
+	require.NoError(t, err)
+	require.Equal(t, "project-2", response)
+
+	// in-flight sends keep the old client
+	response, err = old.sender.Send(context.Background(), &messaging.Message{})
+	require.NoError(t, err)
+	require.Equal(t, "project-1", response)
+
+	// broken credentials don't replace the working client
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0o600))
+	_, err = f.Reload(context.Background(), false)
+	require.Error(t, err)
+
+	response, err = f.Send(context.Background(), &messaging.Message{})
+	require.NoError(t, err)
+	require.Equal(t, "project-2", response)
+}
+
+func TestFirebaseSenderForcedReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "service-account.json")
+	writeServiceAccount(t, path, "project-1", time.Now())
+
+	f := newTestFirebaseSender(t, path)
+
+	// the client was built right now, the same credentials are not rebuilt on each auth error
+	swapped, err := f.Reload(context.Background(), true)
+	require.NoError(t, err)
+	require.False(t, swapped)
+
+	f.reloadedAt = time.Now().Add(-forcedReloadInterval)
+	swapped, err = f.Reload(context.Background(), true)
+	require.NoError(t, err)
+	require.True(t, swapped)
+}
+
+func TestValidateCredentials(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "service-account.json")
+	writeServiceAccount(t, path, "project-1", time.Now())
+
+	require.NoError(t, ValidateCredentials(config.Push{ApplicationCredentials: path}))
+	require.Error(t, ValidateCredentials(config.Push{CredentialsFile: filepath.Join(t.TempDir(), "missing.json")}))
+	require.Error(t, ValidateCredentials(config.Push{}))
+}
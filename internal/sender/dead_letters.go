@@ -0,0 +1,120 @@
+package sender
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	pevents "github.com/goverland-labs/goverland-platform-events/events/inbox"
+
+	"github.com/goverland-labs/goverland-inbox-push/internal/config"
+)
+
+// DeadLetterPayload is published to the dead letter subject
+type DeadLetterPayload struct {
+	ID         uint      `json:"id,omitempty"`
+	Subject    string    `json:"subject"`
+	Payload    string    `json:"payload"`
+	Error      string    `json:"error"`
+	Deliveries int       `json:"deliveries"`
+	FailedAt   time.Time `json:"failed_at"`
+}
+
+type DeadLetters struct {
+	repo      Storage
+	publisher EventPublisher
+	service   PushManipulator
+	subject   string
+}
+
+func NewDeadLetters(r Storage, p EventPublisher, s PushManipulator, cfg config.DeadLetter) *DeadLetters {
+	return &DeadLetters{
+		repo:      r,
+		publisher: p,
+		service:   s,
+		subject:   cfg.Subject,
+	}
+}
+
+// Put stores the event locally and publishes it to the dead letter subject.
+// It fails only if the event was neither stored nor published, so it is not lost.
+func (d *DeadLetters) Put(ctx context.Context, item *DeadLetter) error {
+	storeErr := d.repo.CreateDeadLetter(ctx, item)
+	if storeErr != nil {
+		logger(ctx).Error().Err(storeErr).Str("subject", item.Subject).Msg("store dead letter")
+	}
+
+	pubErr := d.publisher.PublishJSON(ctx, d.subject, DeadLetterPayload{
+		ID:         item.ID,
+		Subject:    item.Subject,
+		Payload:    item.Payload,
+		Error:      item.Error,
+		Deliveries: item.Deliveries,
+		FailedAt:   time.Now(),
+	})
+	if pubErr != nil {
+		logger(ctx).Error().Err(pubErr).Str("subject", item.Subject).Msg("publish dead letter")
+	}
+
+	collectStats("dead_letter", item.Subject, errors.Join(storeErr, pubErr))
+
+	if storeErr != nil && pubErr != nil {
+		return fmt.Errorf("put dead letter: %w", errors.Join(storeErr, pubErr))
+	}
+
+	return nil
+}
+
+func (d *DeadLetters) List(ctx context.Context, subject string, limit int) ([]DeadLetter, error) {
+	return d.repo.ListDeadLetters(ctx, subject, limit)
+}
+
+func (d *DeadLetters) Get(ctx context.Context, id uint) (*DeadLetter, error) {
+	return d.repo.GetDeadLetter(ctx, id)
+}
+
+// Replay processes the dead lettered event again bypassing the inbox
+func (d *DeadLetters) Replay(ctx context.Context, id uint) error {
+	item, err := d.repo.GetDeadLetter(ctx, id)
+	if err != nil {
+		return fmt.Errorf("d.repo.GetDeadLetter: %d: %w", id, err)
+	}
+
+	switch item.Subject {
+	case pevents.SubjectFeedUpdated:
+		var payload pevents.FeedPayload
+		if err := json.Unmarshal([]byte(item.Payload), &payload); err != nil {
+			return fmt.Errorf("unmarshal feed payload: %w", err)
+		}
+
+		err = d.service.ProcessFeedItem(ctx, convertPayloadToInternal(ctx, payload))
+	case pevents.SubjectPushClicked:
+		var payload pevents.PushClickPayload
+		if err := json.Unmarshal([]byte(item.Payload), &payload); err != nil {
+			return fmt.Errorf("unmarshal click payload: %w", err)
+		}
+
+		err = d.service.MarkAsClicked(ctx, payload.ID)
+	case SubjectPushDelivered, SubjectPushDismissed:
+		var payload PushReceiptPayload
+		if err := json.Unmarshal([]byte(item.Payload), &payload); err != nil {
+			return fmt.Errorf("unmarshal receipt payload: %w", err)
+		}
+
+		if item.Subject == SubjectPushDelivered {
+			err = d.service.MarkAsDelivered(ctx, payload.ID)
+		} else {
+			err = d.service.MarkAsDismissed(ctx, payload.ID)
+		}
+	default:
+		return fmt.Errorf("unsupported dead letter subject: %s", item.Subject)
+	}
+
+	if err != nil {
+		return fmt.Errorf("replay dead letter %d: %w", id, err)
+	}
+
+	return d.repo.MarkDeadLetterReplayed(ctx, id)
+}
@@ -0,0 +1,179 @@
+package sender
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/goverland-labs/goverland-inbox-push/internal/config"
+)
+
+const dedupForever = "forever"
+
+// DedupPolicy defines how long the same push is not sent again to the same device.
+// Zero window means forever.
+type DedupPolicy struct {
+	defaultWindow time.Duration
+	windows       map[Action]time.Duration
+}
+
+func NewDedupPolicy(cfg config.Dedup) (DedupPolicy, error) {
+	defaultWindow, err := parseDedupWindow(cfg.DefaultWindow)
+	if err != nil {
+		return DedupPolicy{}, fmt.Errorf("default dedup window: %w", err)
+	}
+
+	policy := DedupPolicy{
+		defaultWindow: defaultWindow,
+		windows:       make(map[Action]time.Duration, len(cfg.Windows)),
+	}
+	for action, value := range cfg.Windows {
+		window, err := parseDedupWindow(value)
+		if err != nil {
+			return DedupPolicy{}, fmt.Errorf("dedup window for %s: %w", action, err)
+		}
+
+		policy.windows[Action(action)] = window
+	}
+
+	return policy, nil
+}
+
+func parseDedupWindow(value string) (time.Duration, error) {
+	if value == dedupForever {
+		return 0, nil
+	}
+
+	window, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+
+	if window <= 0 {
+		return 0, fmt.Errorf("window must be positive or %s: %s", dedupForever, value)
+	}
+
+	return window, nil
+}
+
+// window returns the longest window of the actions, the push about a few actions is
+// deduplicated as long as any of them requires
+func (p DedupPolicy) window(actions []Action) time.Duration {
+	if len(actions) == 0 {
+		return p.defaultWindow
+	}
+
+	var longest time.Duration
+	for _, action := range actions {
+		window, ok := p.windows[action]
+		if !ok {
+			window = p.defaultWindow
+		}
+
+		if window == 0 {
+			return 0
+		}
+
+		longest = max(longest, window)
+	}
+
+	return longest
+}
+
+// expiresAt returns nil if the key has to be kept forever
+func (p DedupPolicy) expiresAt(actions []Action, now time.Time) *time.Time {
+	window := p.window(actions)
+	if window == 0 {
+		return nil
+	}
+
+	expiresAt := now.Add(window)
+
+	return &expiresAt
+}
+
+// dedupKey identifies the push by the recipient device and the content meaning rather than
+// the rendered text, so the same proposals are recognized regardless of their titles
+func dedupKey(req request) string {
+	proposals := slices.Clone(req.proposals)
+	slices.Sort(proposals)
+
+	actions := make([]string, 0, len(req.actions))
+	for _, action := range req.actions {
+		actions = append(actions, string(action))
+	}
+	slices.Sort(actions)
+
+	summary := fmt.Sprintf(
+		"%s|%s|%d|%s|%s",
+		req.userID.String(),
+		req.deviceUUID,
+		req.template,
+		strings.Join(actions, ","),
+		strings.Join(proposals, ","),
+	)
+	// each broadcast is a separate push even if it has the same text
+	if req.broadcastID != 0 {
+		summary = fmt.Sprintf("%s|broadcast:%d", summary, req.broadcastID)
+	}
+
+	hash := sha256.Sum256([]byte(summary))
+
+	return hex.EncodeToString(hash[:])
+}
+
+// reserveDedupKey reports whether the push is not a duplicate within the dedup window
+func (s *Service) reserveDedupKey(ctx context.Context, req request, key string) (bool, error) {
+	reserved, err := s.repo.ReserveDedupKey(ctx, key, s.dedup.expiresAt(req.actions, s.now()))
+
+	collectStats("dedup", "reserve", err)
+
+	return reserved, err
+}
+
+// releaseDedupKey allows sending the push again, e.g. when it was not delivered to firebase
+func (s *Service) releaseDedupKey(ctx context.Context, key string) {
+	if err := s.repo.ReleaseDedupKey(context.WithoutCancel(ctx), key); err != nil {
+		logger(ctx).Error().Err(err).Str("dedup_key", key).Msg("release dedup key")
+	}
+}
+
+type DedupWorker struct {
+	repo Storage
+	cfg  func() config.Dedup
+}
+
+// NewDedupWorker creates the worker, cfg is called on each run to pick up reloaded settings
+func NewDedupWorker(r Storage, cfg func() config.Dedup) *DedupWorker {
+	return &DedupWorker{
+		repo: r,
+		cfg:  cfg,
+	}
+}
+
+// Start removes expired dedup keys periodically
+func (w *DedupWorker) Start(ctx context.Context) error {
+	for {
+		start := time.Now()
+		runCtx := workerRunContext(ctx, "dedup-cleanup")
+		deleted, err := w.repo.DeleteExpiredDedupKeys(runCtx, start)
+		if err != nil {
+			logger(runCtx).Error().Err(err).Msg("delete expired dedup keys")
+		} else if deleted > 0 {
+			logger(runCtx).Info().Int64("deleted", deleted).Msg("deleted expired dedup keys")
+		}
+
+		collectStats("dedup", "cleanup", err)
+		observeWorkerRun("dedup-cleanup", start, err)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(w.cfg().CleanupInterval):
+		}
+	}
+}
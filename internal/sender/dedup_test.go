@@ -0,0 +1,145 @@
+package sender
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/goverland-labs/goverland-inbox-push/internal/config"
+)
+
+func TestDedupKey(t *testing.T) {
+	req := request{
+		body:       "body",
+		title:      "title",
+		imageURL:   "image",
+		userID:     uuid.MustParse("3fca2745-4adc-4fdb-b56c-ef07e38aea6a"),
+		deviceUUID: "uuid_1",
+		proposals:  []string{"str1", "str2", "str3"},
+		actions:    []Action{ProposalCreated, ProposalVotingEnded},
+		template:   1,
+	}
+	key := dedupKey(req)
+
+	// changing the key format resets the dedup for all pushes
+	t.Run("stable", func(t *testing.T) {
+		require.Equal(t, "853d26fc0c401ebb4a0bfe133c4f2470f85b78a8ea203001f696790440ec27b1", key)
+		require.Equal(t, key, dedupKey(req))
+	})
+
+	for name, tc := range map[string]struct {
+		modify func(r request) request
+		same   bool
+	}{
+		"rendered text is ignored": {
+			modify: func(r request) request {
+				r.title, r.body, r.imageURL = "new title", "new body", "new image"
+				return r
+			},
+			same: true,
+		},
+		"order of proposals and actions is ignored": {
+			modify: func(r request) request {
+				r.proposals = []string{"str3", "str1", "str2"}
+				r.actions = []Action{ProposalVotingEnded, ProposalCreated}
+				return r
+			},
+			same: true,
+		},
+		"another device": {
+			modify: func(r request) request {
+				r.deviceUUID = "uuid_2"
+				return r
+			},
+		},
+		"another user": {
+			modify: func(r request) request {
+				r.userID = uuid.New()
+				return r
+			},
+		},
+		"another proposal set": {
+			modify: func(r request) request {
+				r.proposals = []string{"str1", "str2"}
+				return r
+			},
+		},
+		"another template": {
+			modify: func(r request) request {
+				r.template = 2
+				return r
+			},
+		},
+		"another action": {
+			modify: func(r request) request {
+				r.actions = []Action{ProposalCreated}
+				return r
+			},
+		},
+		"another broadcast": {
+			modify: func(r request) request {
+				r.broadcastID = 1
+				return r
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			modified := tc.modify(req)
+			if tc.same {
+				require.Equal(t, key, dedupKey(modified))
+				return
+			}
+
+			require.NotEqual(t, key, dedupKey(modified))
+		})
+	}
+}
+
+func TestDedupPolicy(t *testing.T) {
+	policy, err := NewDedupPolicy(config.Dedup{
+		DefaultWindow: "24h",
+		Windows: map[string]string{
+			string(ProposalVotingEndsSoon): "6h",
+			string(ProposalCreated):        "forever",
+		},
+	})
+	require.NoError(t, err)
+
+	now := time.Now()
+	for name, tc := range map[string]struct {
+		actions []Action
+		window  time.Duration
+	}{
+		"ends soon":             {actions: []Action{ProposalVotingEndsSoon}, window: 6 * time.Hour},
+		"created forever":       {actions: []Action{ProposalCreated}, window: 0},
+		"default":               {actions: []Action{ProposalVotingEnded}, window: 24 * time.Hour},
+		"without actions":       {window: 24 * time.Hour},
+		"longest of a few":      {actions: []Action{ProposalVotingEndsSoon, ProposalVotingEnded}, window: 24 * time.Hour},
+		"forever wins in a few": {actions: []Action{ProposalVotingEnded, ProposalCreated}, window: 0},
+	} {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.window, policy.window(tc.actions))
+
+			expiresAt := policy.expiresAt(tc.actions, now)
+			if tc.window == 0 {
+				require.Nil(t, expiresAt)
+				return
+			}
+
+			require.Equal(t, now.Add(tc.window), *expiresAt)
+		})
+	}
+
+	for name, cfg := range map[string]config.Dedup{
+		"invalid default": {DefaultWindow: "day"},
+		"negative window": {DefaultWindow: "1h", Windows: map[string]string{"proposal.created": "-1h"}},
+		"zero window":     {DefaultWindow: "0s"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewDedupPolicy(cfg)
+			require.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package sender
+
+import (
+	"context"
+	"errors"
+
+	firebaseerrs "firebase.google.com/go/v4/errorutils"
+	"firebase.google.com/go/v4/messaging"
+)
+
+const (
+	errorClassUnregistered    = "unregistered"
+	errorClassInvalidArgument = "invalid_argument"
+	errorClassAuth            = "auth"
+	errorClassQuotaExceeded   = "quota_exceeded"
+	errorClassUnavailable     = "unavailable"
+	errorClassInternal        = "internal"
+	errorClassTimeout         = "timeout"
+	errorClassProjectDisabled = "project_disabled"
+	errorClassUnknown         = "unknown"
+)
+
+// classifyError converts the send error to the small fixed set of classes
+// which is safe to use in events and metric labels
+func classifyError(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrProjectDisabled):
+		return errorClassProjectDisabled
+	case messaging.IsUnregistered(err), firebaseerrs.IsNotFound(err):
+		return errorClassUnregistered
+	case messaging.IsInvalidArgument(err):
+		return errorClassInvalidArgument
+	case messaging.IsSenderIDMismatch(err),
+		messaging.IsThirdPartyAuthError(err),
+		firebaseerrs.IsUnauthenticated(err),
+		firebaseerrs.IsPermissionDenied(err):
+		return errorClassAuth
+	case messaging.IsQuotaExceeded(err):
+		return errorClassQuotaExceeded
+	case messaging.IsUnavailable(err):
+		return errorClassUnavailable
+	case messaging.IsInternal(err):
+		return errorClassInternal
+	case errors.Is(err, context.DeadlineExceeded), firebaseerrs.IsDeadlineExceeded(err):
+		return errorClassTimeout
+	default:
+		return errorClassUnknown
+	}
+}
@@ -0,0 +1,69 @@
+package sender
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	SubjectPushQueued  = "push.queued"
+	SubjectPushSent    = "push.sent"
+	SubjectPushFailed  = "push.failed"
+	SubjectPushSkipped = "push.skipped"
+	SubjectPushExpired = "push.expired"
+)
+
+// PushEventPayload describes the push lifecycle event for analytics consumers
+type PushEventPayload struct {
+	UserID      uuid.UUID   `json:"user_id"`
+	MessageID   *uuid.UUID  `json:"message_id,omitempty"`
+	TemplateID  templateID  `json:"template_id,omitempty"`
+	Actions     []Action    `json:"actions,omitempty"`
+	DaoIDs      []uuid.UUID `json:"dao_ids,omitempty"`
+	ProposalIDs []string    `json:"proposal_ids,omitempty"`
+	DeviceUUID  string      `json:"device_uuid,omitempty"`
+	ErrorClass  string      `json:"error_class,omitempty"`
+	// CorrelationID is the id of the feed event or worker run produced the event
+	CorrelationID string    `json:"correlation_id,omitempty"`
+	OccurredAt    time.Time `json:"occurred_at"`
+}
+
+func newOutboxMessage(subject string, payload PushEventPayload) OutboxMessage {
+	if payload.OccurredAt.IsZero() {
+		payload.OccurredAt = time.Now()
+	}
+
+	// the payload consists of plain types only, so marshaling can't fail
+	data, _ := json.Marshal(payload)
+
+	return OutboxMessage{
+		Subject: subject,
+		Payload: string(data),
+	}
+}
+
+func queueItemEvent(subject string, item SendQueue) OutboxMessage {
+	return newOutboxMessage(subject, PushEventPayload{
+		UserID:        item.UserID,
+		Actions:       []Action{item.Action},
+		DaoIDs:        []uuid.UUID{item.DaoID},
+		ProposalIDs:   []string{item.ProposalID},
+		CorrelationID: item.CorrelationID,
+	})
+}
+
+func requestEvent(subject string, req request, msgID uuid.UUID, deviceUUID string, err error) OutboxMessage {
+	return newOutboxMessage(subject, PushEventPayload{
+		UserID:        req.userID,
+		MessageID:     &msgID,
+		TemplateID:    req.template,
+		Actions:       req.actions,
+		DaoIDs:        req.daos,
+		ProposalIDs:   req.proposals,
+		DeviceUUID:    deviceUUID,
+		ErrorClass:    classifyError(err),
+		CorrelationID: req.correlationID,
+	})
+}
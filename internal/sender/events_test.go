@@ -0,0 +1,82 @@
+package sender
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueueItemEvent(t *testing.T) {
+	item := SendQueue{
+		UserID:     uuid.New(),
+		DaoID:      uuid.New(),
+		ProposalID: "proposal",
+		Action:     ProposalCreated,
+	}
+
+	actual := queueItemEvent(SubjectPushQueued, item)
+	require.Equal(t, SubjectPushQueued, actual.Subject)
+
+	var payload PushEventPayload
+	require.NoError(t, json.Unmarshal([]byte(actual.Payload), &payload))
+	require.Equal(t, item.UserID, payload.UserID)
+	require.Equal(t, []uuid.UUID{item.DaoID}, payload.DaoIDs)
+	require.Equal(t, []string{item.ProposalID}, payload.ProposalIDs)
+	require.Equal(t, []Action{ProposalCreated}, payload.Actions)
+	require.Nil(t, payload.MessageID)
+	require.False(t, payload.OccurredAt.IsZero())
+}
+
+func TestRequestEvent(t *testing.T) {
+	req := request{
+		userID:    uuid.New(),
+		proposals: []string{"proposal_1", "proposal_2"},
+		daos:      []uuid.UUID{uuid.New()},
+		actions:   []Action{ProposalCreated, ProposalVotingEnded},
+		template:  templateIDOneDaoFewProposal,
+	}
+	msgID := uuid.New()
+
+	actual := requestEvent(SubjectPushFailed, req, msgID, "device", context.DeadlineExceeded)
+	require.Equal(t, SubjectPushFailed, actual.Subject)
+
+	var payload PushEventPayload
+	require.NoError(t, json.Unmarshal([]byte(actual.Payload), &payload))
+	require.Equal(t, req.userID, payload.UserID)
+	require.Equal(t, &msgID, payload.MessageID)
+	require.Equal(t, req.template, payload.TemplateID)
+	require.Equal(t, req.actions, payload.Actions)
+	require.Equal(t, req.daos, payload.DaoIDs)
+	require.Equal(t, req.proposals, payload.ProposalIDs)
+	require.Equal(t, "device", payload.DeviceUUID)
+	require.Equal(t, errorClassTimeout, payload.ErrorClass)
+}
+
+func TestClassifyError(t *testing.T) {
+	for name, tc := range map[string]struct {
+		err      error
+		expected string
+	}{
+		"no error": {
+			err:      nil,
+			expected: "",
+		},
+		"timeout": {
+			err:      fmt.Errorf("send: %w", context.DeadlineExceeded),
+			expected: errorClassTimeout,
+		},
+		"unknown": {
+			err:      errors.New("something went wrong"),
+			expected: errorClassUnknown,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.expected, classifyError(tc.err))
+		})
+	}
+}
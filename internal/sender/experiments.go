@@ -0,0 +1,311 @@
+package sender
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"sync/atomic"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/goverland-labs/goverland-inbox-push/internal/config"
+)
+
+// ExperimentsFile is the versioned file with template experiments. The file is applied
+// only if its version is greater than the version of the applied one.
+type ExperimentsFile struct {
+	Version     int          `json:"version"`
+	Experiments []Experiment `json:"experiments"`
+}
+
+// Experiment splits users of the template between variants by weights
+type Experiment struct {
+	ID         string    `json:"id"`
+	TemplateID int       `json:"template_id"`
+	Enabled    bool      `json:"enabled"`
+	Variants   []Variant `json:"variants"`
+}
+
+// Variant overrides the copy of the template. Title and body are text templates
+// rendered with copy vars and the default copy, empty ones keep the default copy.
+type Variant struct {
+	ID     string `json:"id"`
+	Weight int    `json:"weight"`
+	Title  string `json:"title,omitempty"`
+	Body   string `json:"body,omitempty"`
+
+	title *template.Template
+	body  *template.Template
+}
+
+type variantData struct {
+	copyVars
+
+	Title string
+	Body  string
+}
+
+func ParseExperimentsFile(data []byte) (*ExperimentsFile, error) {
+	var file ExperimentsFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("unmarshal experiments: %w", err)
+	}
+
+	if err := file.prepare(); err != nil {
+		return nil, err
+	}
+
+	return &file, nil
+}
+
+// prepare validates experiments and parses variant templates
+func (f *ExperimentsFile) prepare() error {
+	var errs []error
+	ids := make(map[string]struct{})
+	templates := make(map[int]string)
+	for idx := range f.Experiments {
+		exp := &f.Experiments[idx]
+		if exp.ID == "" {
+			errs = append(errs, fmt.Errorf("experiment #%d: empty id", idx))
+			continue
+		}
+
+		if _, ok := ids[exp.ID]; ok {
+			errs = append(errs, fmt.Errorf("experiment %s: duplicate id", exp.ID))
+		}
+		ids[exp.ID] = struct{}{}
+
+		if exp.TemplateID < int(templateIDVoteFinishesSoon) || exp.TemplateID > int(templateIDDelegateVotingSkipVote) {
+			errs = append(errs, fmt.Errorf("experiment %s: unknown template %d", exp.ID, exp.TemplateID))
+		}
+
+		if other, ok := templates[exp.TemplateID]; ok && exp.Enabled {
+			errs = append(errs, fmt.Errorf("experiment %s: template %d is already used by %s", exp.ID, exp.TemplateID, other))
+		}
+		if exp.Enabled {
+			templates[exp.TemplateID] = exp.ID
+		}
+
+		if len(exp.Variants) == 0 {
+			errs = append(errs, fmt.Errorf("experiment %s: no variants", exp.ID))
+		}
+
+		variants := make(map[string]struct{})
+		for vIdx := range exp.Variants {
+			v := &exp.Variants[vIdx]
+			if _, ok := variants[v.ID]; ok || v.ID == "" {
+				errs = append(errs, fmt.Errorf("experiment %s: empty or duplicate variant id %q", exp.ID, v.ID))
+			}
+			variants[v.ID] = struct{}{}
+
+			if v.Weight <= 0 {
+				errs = append(errs, fmt.Errorf("experiment %s: variant %s: weight must be positive", exp.ID, v.ID))
+			}
+
+			var err error
+			if v.title, err = parseVariantTemplate(v.Title); err != nil {
+				errs = append(errs, fmt.Errorf("experiment %s: variant %s: title: %w", exp.ID, v.ID, err))
+			}
+			if v.body, err = parseVariantTemplate(v.Body); err != nil {
+				errs = append(errs, fmt.Errorf("experiment %s: variant %s: body: %w", exp.ID, v.ID, err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func parseVariantTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		return nil, nil
+	}
+
+	return template.New("").Option("missingkey=error").Parse(text)
+}
+
+// experiment returns the enabled experiment for the template
+func (f *ExperimentsFile) experiment(id templateID) *Experiment {
+	for idx := range f.Experiments {
+		if f.Experiments[idx].Enabled && f.Experiments[idx].TemplateID == int(id) {
+			return &f.Experiments[idx]
+		}
+	}
+
+	return nil
+}
+
+// bucket chooses the variant for the user. The same user always gets the same variant
+// while variants of the experiment are the same.
+func (e *Experiment) bucket(userID uuid.UUID) *Variant {
+	total := 0
+	for _, v := range e.Variants {
+		total += v.Weight
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(e.ID + ":" + userID.String()))
+	point := int(h.Sum32() % uint32(total))
+
+	for idx := range e.Variants {
+		point -= e.Variants[idx].Weight
+		if point < 0 {
+			return &e.Variants[idx]
+		}
+	}
+
+	return &e.Variants[len(e.Variants)-1]
+}
+
+// Experiments holds the applied experiments file, nil holder means no experiments
+type Experiments struct {
+	path     string
+	interval time.Duration
+	current  atomic.Pointer[ExperimentsFile]
+	modTime  time.Time
+}
+
+// NewExperiments loads experiments from the configured file. Experiments are disabled
+// if the file is not configured.
+func NewExperiments(cfg config.Experiments) (*Experiments, error) {
+	e := &Experiments{
+		path:     cfg.File,
+		interval: cfg.ReloadInterval,
+	}
+
+	if cfg.File == "" {
+		return e, nil
+	}
+
+	if _, err := e.reload(context.Background()); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// Start reloads the experiments file when it is changed
+func (e *Experiments) Start(ctx context.Context) error {
+	if e.path == "" {
+		<-ctx.Done()
+		return nil
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(e.interval):
+		}
+
+		runCtx := workerRunContext(ctx, "experiments")
+		reloaded, err := e.reload(runCtx)
+		if err != nil {
+			logger(runCtx).Error().Err(err).Str("path", e.path).Msg("reload experiments")
+		}
+
+		if reloaded {
+			logger(runCtx).Info().Int("version", e.current.Load().Version).Msg("experiments version applied")
+		}
+
+		collectStats("experiments", "reload", err)
+	}
+}
+
+func (e *Experiments) reload(ctx context.Context) (bool, error) {
+	info, err := os.Stat(e.path)
+	if err != nil {
+		return false, err
+	}
+
+	if info.ModTime().Equal(e.modTime) {
+		return false, nil
+	}
+	e.modTime = info.ModTime()
+
+	data, err := os.ReadFile(e.path)
+	if err != nil {
+		return false, err
+	}
+
+	file, err := ParseExperimentsFile(data)
+	if err != nil {
+		return false, err
+	}
+
+	if current := e.current.Load(); current != nil && file.Version <= current.Version {
+		logger(ctx).Warn().
+			Int("version", file.Version).
+			Int("current_version", current.Version).
+			Msg("experiments file is changed but its version is not greater than the current one")
+
+		return false, nil
+	}
+
+	e.current.Store(file)
+
+	return true, nil
+}
+
+// apply replaces the copy of the request by the variant of the template experiment
+func (e *Experiments) apply(ctx context.Context, req request) request {
+	if e == nil {
+		return req
+	}
+
+	file := e.current.Load()
+	if file == nil {
+		return req
+	}
+
+	exp := file.experiment(req.template)
+	if exp == nil {
+		return req
+	}
+
+	variant := exp.bucket(req.userID)
+	data := variantData{
+		copyVars: req.vars,
+		Title:    req.title,
+		Body:     req.body,
+	}
+
+	title, err := renderVariant(variant.title, data, req.title)
+	if err != nil {
+		logger(ctx).Error().Err(err).Str("experiment_id", exp.ID).Str("variant_id", variant.ID).Msg("render variant title")
+		collectStats("experiments", "fallback", err)
+
+		return req
+	}
+
+	body, err := renderVariant(variant.body, data, req.body)
+	if err != nil {
+		logger(ctx).Error().Err(err).Str("experiment_id", exp.ID).Str("variant_id", variant.ID).Msg("render variant body")
+		collectStats("experiments", "fallback", err)
+
+		return req
+	}
+
+	req.title, req.body = title, body
+	req.experimentID, req.variantID = exp.ID, variant.ID
+
+	return req
+}
+
+func renderVariant(tpl *template.Template, data variantData, def string) (string, error) {
+	if tpl == nil {
+		return def, nil
+	}
+
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
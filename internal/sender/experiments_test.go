@@ -0,0 +1,175 @@
+package sender
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/goverland-labs/goverland-inbox-push/internal/config"
+)
+
+const testExperiments = `{
+	"version": 1,
+	"experiments": [{
+		"id": "few-proposals",
+		"template_id": 3,
+		"enabled": true,
+		"variants": [
+			{"id": "control", "weight": 1},
+			{"id": "short", "weight": 1, "body": "{{.Proposals}} proposals in {{.DaoName}}"}
+		]
+	}]
+}`
+
+func TestParseExperimentsFile(t *testing.T) {
+	file, err := ParseExperimentsFile([]byte(testExperiments))
+	require.NoError(t, err)
+	require.Equal(t, 1, file.Version)
+	require.NotNil(t, file.experiment(templateIDOneDaoFewProposal))
+	require.Nil(t, file.experiment(templateIDOneDaoOneProposal))
+
+	for name, data := range map[string]string{
+		"invalid json":     `{`,
+		"empty id":         `{"experiments": [{"template_id": 3, "variants": [{"id": "a", "weight": 1}]}]}`,
+		"unknown template": `{"experiments": [{"id": "a", "template_id": 99, "variants": [{"id": "a", "weight": 1}]}]}`,
+		"no variants":      `{"experiments": [{"id": "a", "template_id": 3}]}`,
+		"zero weight":      `{"experiments": [{"id": "a", "template_id": 3, "variants": [{"id": "a"}]}]}`,
+		"duplicate variant": `{"experiments": [{"id": "a", "template_id": 3, "variants": [
+			{"id": "a", "weight": 1}, {"id": "a", "weight": 1}]}]}`,
+		"invalid template": `{"experiments": [{"id": "a", "template_id": 3, "variants": [
+			{"id": "a", "weight": 1, "body": "{{.Proposals"}]}]}`,
+		"two enabled for template": `{"experiments": [
+			{"id": "a", "template_id": 3, "enabled": true, "variants": [{"id": "a", "weight": 1}]},
+			{"id": "b", "template_id": 3, "enabled": true, "variants": [{"id": "a", "weight": 1}]}]}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseExperimentsFile([]byte(data))
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestExperimentBucket(t *testing.T) {
+	exp := Experiment{
+		ID: "exp",
+		Variants: []Variant{
+			{ID: "a", Weight: 1},
+			{ID: "b", Weight: 3},
+		},
+	}
+
+	counts := map[string]int{}
+	for i := 0; i < 4000; i++ {
+		userID := uuid.New()
+		variant := exp.bucket(userID)
+		require.Equal(t, variant.ID, exp.bucket(userID).ID, "bucketing must be deterministic")
+
+		counts[variant.ID]++
+	}
+
+	require.InDelta(t, 1000, counts["a"], 150)
+	require.InDelta(t, 3000, counts["b"], 150)
+}
+
+func TestExperimentsApply(t *testing.T) {
+	file, err := ParseExperimentsFile([]byte(testExperiments))
+	require.NoError(t, err)
+
+	e := &Experiments{}
+	e.current.Store(file)
+
+	exp := file.experiment(templateIDOneDaoFewProposal)
+	req := request{
+		title:    "Aave",
+		body:     "Updates on 3 proposals",
+		template: templateIDOneDaoFewProposal,
+		vars: copyVars{
+			DaoName:   "Aave",
+			Proposals: 3,
+		},
+	}
+
+	seen := map[string]bool{}
+	for i := 0; i < 100 && len(seen) < 2; i++ {
+		req.userID = uuid.New()
+		applied := e.apply(context.Background(), req)
+		require.Equal(t, exp.ID, applied.experimentID)
+		require.Equal(t, "Aave", applied.title)
+
+		switch applied.variantID {
+		case "control":
+			require.Equal(t, "Updates on 3 proposals", applied.body)
+		case "short":
+			require.Equal(t, "3 proposals in Aave", applied.body)
+		default:
+			t.Fatalf("unexpected variant %s", applied.variantID)
+		}
+		seen[applied.variantID] = true
+	}
+	require.Len(t, seen, 2)
+
+	t.Run("without experiment for template", func(t *testing.T) {
+		other := req
+		other.template = templateIDOneDaoOneProposal
+		require.Equal(t, other, e.apply(context.Background(), other))
+	})
+
+	t.Run("render failure keeps the default copy", func(t *testing.T) {
+		broken, err := ParseExperimentsFile([]byte(`{"experiments": [{"id": "broken", "template_id": 3, "enabled": true,
+			"variants": [{"id": "a", "weight": 1, "body": "{{.Unknown}}"}]}]}`))
+		require.NoError(t, err)
+
+		withBroken := &Experiments{}
+		withBroken.current.Store(broken)
+		fallbacks := testutil.ToFloat64(metricPushCounter.WithLabelValues("experiments", "fallback", "true"))
+
+		require.Equal(t, req, withBroken.apply(context.Background(), req))
+		require.Equal(t, fallbacks+1, testutil.ToFloat64(metricPushCounter.WithLabelValues("experiments", "fallback", "true")))
+	})
+
+	t.Run("without experiments", func(t *testing.T) {
+		var empty *Experiments
+		require.Equal(t, req, empty.apply(context.Background(), req))
+		require.Equal(t, req, (&Experiments{}).apply(context.Background(), req))
+	})
+}
+
+func TestExperimentsReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "experiments.json")
+	require.NoError(t, os.WriteFile(path, []byte(testExperiments), 0o600))
+
+	e, err := NewExperiments(config.Experiments{File: path})
+	require.NoError(t, err)
+	require.Equal(t, 1, e.current.Load().Version)
+
+	write := func(data string, modTime time.Time) {
+		require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+
+	// the same version is not applied
+	write(`{"version": 1}`, time.Now().Add(time.Minute))
+	reloaded, err := e.reload(context.Background())
+	require.NoError(t, err)
+	require.False(t, reloaded)
+	require.Len(t, e.current.Load().Experiments, 1)
+
+	// invalid file keeps the current one
+	write(`{"version": 3, "experiments": [{"id": ""}]}`, time.Now().Add(2*time.Minute))
+	_, err = e.reload(context.Background())
+	require.Error(t, err)
+	require.Equal(t, 1, e.current.Load().Version)
+
+	write(`{"version": 2}`, time.Now().Add(3*time.Minute))
+	reloaded, err = e.reload(context.Background())
+	require.NoError(t, err)
+	require.True(t, reloaded)
+	require.Equal(t, 2, e.current.Load().Version)
+	require.Empty(t, e.current.Load().Experiments)
+}
@@ -0,0 +1,237 @@
+package sender
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const exportBatchSize = 500
+
+type ExportFormat string
+
+const (
+	ExportCSV   ExportFormat = "csv"
+	ExportJSONL ExportFormat = "jsonl"
+)
+
+func (f ExportFormat) Valid() bool {
+	return f == ExportCSV || f == ExportJSONL
+}
+
+// HistoryExportQuery selects history rows to export, empty fields are not filtered
+type HistoryExportQuery struct {
+	UserID     *uuid.UUID
+	DaoID      *uuid.UUID
+	TemplateID *int
+	From       time.Time
+	To         time.Time
+	Format     ExportFormat
+	Gzip       bool
+}
+
+func (q HistoryExportQuery) filters() []Filter {
+	var filters []Filter
+	if q.UserID != nil {
+		filters = append(filters, UserIDIn(q.UserID.String()))
+	}
+	if q.DaoID != nil {
+		filters = append(filters, DaoIDIn(q.DaoID.String()))
+	}
+	if q.TemplateID != nil {
+		filters = append(filters, TemplateIDIn(*q.TemplateID))
+	}
+	if !q.From.IsZero() {
+		filters = append(filters, CreatedAfter(q.From))
+	}
+	if !q.To.IsZero() {
+		filters = append(filters, CreatedBefore(q.To))
+	}
+
+	return filters
+}
+
+// HistoryExportRow is a history row with decoded message
+type HistoryExportRow struct {
+	ID            uint       `json:"id"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UserID        uuid.UUID  `json:"user_id"`
+	MessageID     uuid.UUID  `json:"message_id"`
+	Title         string     `json:"title"`
+	Body          string     `json:"body"`
+	ImageURL      string     `json:"image_url"`
+	Proposals     []string   `json:"proposals"`
+	TemplateID    int        `json:"template_id"`
+	DeviceUUID    string     `json:"device_uuid"`
+	Action        Action     `json:"action,omitempty"`
+	DaoID         *uuid.UUID `json:"dao_id,omitempty"`
+	PushResponse  string     `json:"push_response"`
+	ClickedAt     *time.Time `json:"clicked_at,omitempty"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+	DismissedAt   *time.Time `json:"dismissed_at,omitempty"`
+	CorrelationID string     `json:"correlation_id,omitempty"`
+}
+
+var historyExportHeader = []string{
+	"id", "created_at", "user_id", "message_id", "title", "body", "image_url", "proposals", "template_id",
+	"device_uuid", "action", "dao_id", "push_response", "clicked_at", "delivered_at", "dismissed_at",
+	"correlation_id",
+}
+
+func (r HistoryExportRow) record() []string {
+	daoID := ""
+	if r.DaoID != nil {
+		daoID = r.DaoID.String()
+	}
+
+	return []string{
+		strconv.FormatUint(uint64(r.ID), 10),
+		r.CreatedAt.Format(time.RFC3339),
+		r.UserID.String(),
+		r.MessageID.String(),
+		r.Title,
+		r.Body,
+		r.ImageURL,
+		strings.Join(r.Proposals, ";"),
+		strconv.Itoa(r.TemplateID),
+		r.DeviceUUID,
+		string(r.Action),
+		daoID,
+		r.PushResponse,
+		formatOptionalTime(r.ClickedAt),
+		formatOptionalTime(r.DeliveredAt),
+		formatOptionalTime(r.DismissedAt),
+		r.CorrelationID,
+	}
+}
+
+func newHistoryExportRow(h History) HistoryExportRow {
+	var proposals []string
+	if len(h.Message.Payload) > 0 {
+		_ = json.Unmarshal(h.Message.Payload, &proposals)
+	}
+
+	return HistoryExportRow{
+		ID:            h.ID,
+		CreatedAt:     h.CreatedAt,
+		UserID:        h.UserID,
+		MessageID:     h.Message.ID,
+		Title:         h.Message.Title,
+		Body:          h.Message.Body,
+		ImageURL:      h.Message.ImageURL,
+		Proposals:     proposals,
+		TemplateID:    int(h.Message.TemplateID),
+		DeviceUUID:    h.Message.DeviceUUID,
+		Action:        h.Action,
+		DaoID:         h.DaoID,
+		PushResponse:  h.PushResponse,
+		ClickedAt:     h.ClickedAt,
+		DeliveredAt:   h.DeliveredAt,
+		DismissedAt:   h.DismissedAt,
+		CorrelationID: h.CorrelationID,
+	}
+}
+
+// ExportHistory streams matched history rows to w batch by batch, so memory usage
+// does not depend on the number of exported rows.
+func (s *Service) ExportHistory(ctx context.Context, w io.Writer, query HistoryExportQuery) error {
+	if !query.Format.Valid() {
+		return fmt.Errorf("unsupported export format: %s", query.Format)
+	}
+
+	if !query.Gzip {
+		return s.exportHistory(ctx, w, query)
+	}
+
+	zw := gzip.NewWriter(w)
+	err := s.exportHistory(ctx, zw, query)
+
+	return errors.Join(err, zw.Close())
+}
+
+func (s *Service) exportHistory(ctx context.Context, w io.Writer, query HistoryExportQuery) error {
+	exporter := newHistoryExporter(w, query.Format)
+	err := s.repo.HistoriesInBatches(ctx, query.filters(), exportBatchSize, func(list []History) error {
+		for _, item := range list {
+			if err := exporter.write(newHistoryExportRow(item)); err != nil {
+				return err
+			}
+		}
+
+		return exporter.flush()
+	})
+	if err != nil {
+		return fmt.Errorf("s.repo.HistoriesInBatches: %w", err)
+	}
+
+	return exporter.flush()
+}
+
+type historyExporter struct {
+	format ExportFormat
+	csv    *csv.Writer
+	json   *json.Encoder
+	header bool
+}
+
+func newHistoryExporter(w io.Writer, format ExportFormat) *historyExporter {
+	return &historyExporter{
+		format: format,
+		csv:    csv.NewWriter(w),
+		json:   json.NewEncoder(w),
+	}
+}
+
+func (e *historyExporter) write(row HistoryExportRow) error {
+	if e.format == ExportJSONL {
+		return e.json.Encode(row)
+	}
+
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+
+	return e.csv.Write(row.record())
+}
+
+// flush writes buffered csv rows, the header is written even if there are no rows
+func (e *historyExporter) flush() error {
+	if e.format == ExportJSONL {
+		return nil
+	}
+
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+
+	e.csv.Flush()
+
+	return e.csv.Error()
+}
+
+func (e *historyExporter) writeHeader() error {
+	if e.header {
+		return nil
+	}
+
+	e.header = true
+
+	return e.csv.Write(historyExportHeader)
+}
+
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,88 @@
+package sender
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestHistoryExporter(t *testing.T) {
+	createdAt := time.Date(2024, 12, 1, 10, 0, 0, 0, time.UTC)
+	payload, _ := json.Marshal([]string{"p1", "p2"})
+	h := History{
+		Model:  gorm.Model{ID: 7, CreatedAt: createdAt},
+		UserID: uuid.New(),
+		Message: Message{
+			ID:         uuid.New(),
+			Title:      "title, with comma",
+			Body:       "body",
+			Payload:    payload,
+			TemplateID: templateIDOneDaoOneProposal,
+			DeviceUUID: "device",
+		},
+		PushResponse: "response",
+		ClickedAt:    &createdAt,
+	}
+	row := newHistoryExportRow(h)
+
+	t.Run("csv", func(t *testing.T) {
+		var buf bytes.Buffer
+		e := newHistoryExporter(&buf, ExportCSV)
+		require.NoError(t, e.write(row))
+		require.NoError(t, e.flush())
+
+		records, err := csv.NewReader(&buf).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, records, 2)
+		require.Equal(t, historyExportHeader, records[0])
+		require.Equal(t, row.record(), records[1])
+		require.Equal(t, "p1;p2", records[1][7])
+		require.Equal(t, "title, with comma", records[1][4])
+	})
+
+	t.Run("csv without rows", func(t *testing.T) {
+		var buf bytes.Buffer
+		e := newHistoryExporter(&buf, ExportCSV)
+		require.NoError(t, e.flush())
+		require.NoError(t, e.flush())
+
+		records, err := csv.NewReader(&buf).ReadAll()
+		require.NoError(t, err)
+		require.Equal(t, [][]string{historyExportHeader}, records)
+	})
+
+	t.Run("jsonl", func(t *testing.T) {
+		var buf bytes.Buffer
+		e := newHistoryExporter(&buf, ExportJSONL)
+		require.NoError(t, e.write(row))
+		require.NoError(t, e.write(row))
+		require.NoError(t, e.flush())
+
+		lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+		require.Len(t, lines, 2)
+
+		var decoded HistoryExportRow
+		require.NoError(t, json.Unmarshal(lines[0], &decoded))
+		require.Equal(t, row.MessageID, decoded.MessageID)
+		require.Equal(t, []string{"p1", "p2"}, decoded.Proposals)
+		require.Equal(t, "device", decoded.DeviceUUID)
+	})
+}
+
+func TestHistoryExportQueryFilters(t *testing.T) {
+	userID := uuid.New()
+	templateID := 2
+
+	require.Empty(t, HistoryExportQuery{}.filters())
+	require.Len(t, HistoryExportQuery{
+		UserID:     &userID,
+		TemplateID: &templateID,
+		From:       time.Now(),
+	}.filters(), 3)
+}
@@ -1,12 +1,40 @@
 package sender
 
 import (
+	"slices"
 	"time"
 
 	"gorm.io/gorm"
 )
 
-type Filter func(query *gorm.DB) *gorm.DB
+// Filter narrows down the query. The same condition is checked by MemoryStorage against stored rows.
+type Filter struct {
+	apply func(query *gorm.DB) *gorm.DB
+	match func(row filterRow) bool
+}
+
+// filterRow holds columns which filters are checked against in memory
+type filterRow struct {
+	ID            uint
+	UserID        string
+	DaoID         string
+	ProposalID    string
+	Action        string
+	CreatedAt     time.Time
+	SentAt        *time.Time
+	TemplateID    int
+	CorrelationID string
+}
+
+func matchFilters(filters []Filter, row filterRow) bool {
+	for _, f := range filters {
+		if !f.match(row) {
+			return false
+		}
+	}
+
+	return true
+}
 
 func AvailableForSending() Filter {
 	var (
@@ -14,8 +42,13 @@ func AvailableForSending() Filter {
 		_     = dummy.SentAt
 	)
 
-	return func(query *gorm.DB) *gorm.DB {
-		return query.Where("sent_at is null")
+	return Filter{
+		apply: func(query *gorm.DB) *gorm.DB {
+			return query.Where("sent_at is null")
+		},
+		match: func(row filterRow) bool {
+			return row.SentAt == nil
+		},
 	}
 }
 
@@ -25,8 +58,13 @@ func ActionIn(in ...string) Filter {
 		_     = dummy.Action
 	)
 
-	return func(query *gorm.DB) *gorm.DB {
-		return query.Where("action in ?", in)
+	return Filter{
+		apply: func(query *gorm.DB) *gorm.DB {
+			return query.Where("action in ?", in)
+		},
+		match: func(row filterRow) bool {
+			return slices.Contains(in, row.Action)
+		},
 	}
 }
 
@@ -36,8 +74,13 @@ func ActionNotIn(in ...string) Filter {
 		_     = dummy.Action
 	)
 
-	return func(query *gorm.DB) *gorm.DB {
-		return query.Where("action not in ?", in)
+	return Filter{
+		apply: func(query *gorm.DB) *gorm.DB {
+			return query.Where("action not in ?", in)
+		},
+		match: func(row filterRow) bool {
+			return !slices.Contains(in, row.Action)
+		},
 	}
 }
 
@@ -47,8 +90,13 @@ func UserIDIn(in ...string) Filter {
 		_     = dummy.UserID
 	)
 
-	return func(query *gorm.DB) *gorm.DB {
-		return query.Where("user_id in ?", in)
+	return Filter{
+		apply: func(query *gorm.DB) *gorm.DB {
+			return query.Where("user_id in ?", in)
+		},
+		match: func(row filterRow) bool {
+			return slices.Contains(in, row.UserID)
+		},
 	}
 }
 
@@ -58,7 +106,124 @@ func CreatedAfter(after time.Time) Filter {
 		_     = dummy.UserID
 	)
 
-	return func(query *gorm.DB) *gorm.DB {
-		return query.Where("created_at >= ?", after)
+	return Filter{
+		apply: func(query *gorm.DB) *gorm.DB {
+			return query.Where("created_at >= ?", after)
+		},
+		match: func(row filterRow) bool {
+			return !row.CreatedAt.Before(after)
+		},
+	}
+}
+
+func DaoIDIn(in ...string) Filter {
+	var (
+		dummy SendQueue
+		_     = dummy.DaoID
+	)
+
+	return Filter{
+		apply: func(query *gorm.DB) *gorm.DB {
+			return query.Where("dao_id in ?", in)
+		},
+		match: func(row filterRow) bool {
+			return slices.Contains(in, row.DaoID)
+		},
+	}
+}
+
+func ProposalIDIn(in ...string) Filter {
+	var (
+		dummy SendQueue
+		_     = dummy.ProposalID
+	)
+
+	return Filter{
+		apply: func(query *gorm.DB) *gorm.DB {
+			return query.Where("proposal_id in ?", in)
+		},
+		match: func(row filterRow) bool {
+			return slices.Contains(in, row.ProposalID)
+		},
+	}
+}
+
+func CreatedBefore(before time.Time) Filter {
+	var (
+		dummy SendQueue
+		_     = dummy.CreatedAt
+	)
+
+	return Filter{
+		apply: func(query *gorm.DB) *gorm.DB {
+			return query.Where("created_at < ?", before)
+		},
+		match: func(row filterRow) bool {
+			return row.CreatedAt.Before(before)
+		},
+	}
+}
+
+func TemplateIDIn(in ...int) Filter {
+	var (
+		dummy History
+		_     = dummy.Message.TemplateID
+	)
+
+	return Filter{
+		apply: func(query *gorm.DB) *gorm.DB {
+			return query.Where("(message->>'template_id')::int in ?", in)
+		},
+		match: func(row filterRow) bool {
+			return slices.Contains(in, row.TemplateID)
+		},
+	}
+}
+
+func CorrelationIDIn(in ...string) Filter {
+	var (
+		dummy SendQueue
+		_     = dummy.CorrelationID
+	)
+
+	return Filter{
+		apply: func(query *gorm.DB) *gorm.DB {
+			return query.Where("correlation_id in ?", in)
+		},
+		match: func(row filterRow) bool {
+			return slices.Contains(in, row.CorrelationID)
+		},
+	}
+}
+
+func IDIn(in ...uint) Filter {
+	var (
+		dummy SendQueue
+		_     = dummy.ID
+	)
+
+	return Filter{
+		apply: func(query *gorm.DB) *gorm.DB {
+			return query.Where("id in ?", in)
+		},
+		match: func(row filterRow) bool {
+			return slices.Contains(in, row.ID)
+		},
+	}
+}
+
+func AlreadySent() Filter {
+	var (
+		dummy SendQueue
+		_     = dummy.SentAt
+	)
+
+	return Filter{
+		apply: func(query *gorm.DB) *gorm.DB {
+			return query.Where("sent_at is not null")
+		},
+		match: func(row filterRow) bool {
+			return row.SentAt != nil
+		},
 	}
 }
@@ -2,94 +2,339 @@ package sender
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 
 	"github.com/google/uuid"
 	"github.com/goverland-labs/goverland-inbox-api-protocol/protobuf/inboxapi"
 	"github.com/goverland-labs/goverland-platform-events/events/inbox"
-	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
-func (c *Consumer) handleFeed() inbox.FeedHandler {
-	return func(payload inbox.FeedPayload) error {
-		converted := convertPayloadToInternal(payload)
+func (c *Consumer) handleFeed() func(ctx context.Context, payload inbox.FeedPayload) error {
+	return func(ctx context.Context, payload inbox.FeedPayload) error {
+		key, err := feedEventKey(payload)
+		if err != nil {
+			return fmt.Errorf("feedEventKey: %w", err)
+		}
+
+		ctx = withCorrelationID(ctx, key)
+		converted := convertPayloadToInternal(ctx, payload)
+		ctx = withItemLogFields(ctx, converted)
+
+		logger(ctx).Info().Msg("start processing feed event")
 
-		log.Info().Msgf("start processing proposal %s with action %s", converted.ProposalID, converted.Action)
+		if err := c.service.ProcessFeedEvent(ctx, key, converted); err != nil {
+			if errors.Is(err, ErrEventInProgress) {
+				logger(ctx).Info().Msg("feed event is being processed by another attempt")
 
-		if err := c.service.ProcessFeedItem(context.TODO(), converted); err != nil {
-			log.Error().
-				Err(err).
-				Msgf("process proposal %s with action %s", converted.ProposalID, converted.Action)
+				return err
+			}
+
+			logger(ctx).Error().Err(err).Msg("process feed event")
 
 			return err
 		}
 
-		log.Info().Msgf("processed proposal %s with action %s", converted.ProposalID, converted.Action)
+		logger(ctx).Info().Msg("processed feed event")
 
 		return nil
 	}
 }
 
+// ErrFanoutLimitReached is returned when the fan-out stopped due to the limit of queued items
+var ErrFanoutLimitReached = errors.New("fan-out limit reached")
+
+// ErrEventInProgress is returned for the redelivered event while its previous attempt holds the lease
+var ErrEventInProgress = errors.New("event is being processed")
+
+// inboxLease is how long the attempt owns the event. It is longer than the ack wait, so the redelivery
+// after the ack wait does not start the fan-out again while the first attempt is still running.
+const inboxLease = 5 * executionTtl
+
+type fanoutMode struct {
+	dryRun bool
+	// limit is the max number of queued items, zero means no limit
+	limit int
+}
+
+// ProcessFeedEvent processes the feed item only once for the same event key.
+// Redelivered events which were already processed are skipped without any side effects,
+// ErrEventInProgress is returned while another attempt holds the lease of the event.
+// The event key is used as the correlation id, so redeliveries share it.
+func (s *Service) ProcessFeedEvent(ctx context.Context, key string, item Item) (err error) {
+	if CorrelationID(ctx) != key {
+		ctx = withItemLogFields(withCorrelationID(ctx, key), item)
+	}
+
+	ctx, span := tracer().Start(ctx, "process feed event", trace.WithAttributes(itemAttributes(item)...))
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	event, claimed, err := s.repo.ClaimInboxEvent(ctx, inbox.SubjectFeedUpdated, key, s.now().Add(inboxLease))
+	if err != nil {
+		return fmt.Errorf("s.repo.ClaimInboxEvent: %w", err)
+	}
+
+	if event.Status == InboxProcessed {
+		logger(ctx).Info().Msg("skip already processed feed event")
+
+		collectStats("inbox", "duplicate", nil)
+
+		return nil
+	}
+
+	if !claimed {
+		collectStats("inbox", "in_progress", nil)
+
+		return ErrEventInProgress
+	}
+
+	err = s.ProcessFeedItem(ctx, item)
+	if markErr := s.repo.MarkInboxEvent(context.WithoutCancel(ctx), event.ID, err); markErr != nil {
+		logger(ctx).Error().Err(markErr).Msg("mark inbox event")
+	}
+
+	return err
+}
+
 func (s *Service) ProcessFeedItem(ctx context.Context, item Item) error {
+	_, err := s.processFeedItem(ctx, item, fanoutMode{})
+
+	return err
+}
+
+// ReplayFeedItem processes the feed item again, queueing not more than limit items.
+// In dry run mode nothing is stored and returned outcomes show what would be queued.
+func (s *Service) ReplayFeedItem(ctx context.Context, item Item, dryRun bool, limit int) ([]FanoutLog, error) {
+	return s.processFeedItem(ctx, item, fanoutMode{
+		dryRun: dryRun,
+		limit:  limit,
+	})
+}
+
+func (s *Service) processFeedItem(ctx context.Context, item Item, mode fanoutMode) (_ []FanoutLog, err error) {
+	if CorrelationID(ctx) == "" {
+		ctx = withItemLogFields(ensureCorrelationID(ctx), item)
+	}
+
+	ctx, span := tracer().Start(ctx, "fanout", trace.WithAttributes(itemAttributes(item)...))
+	span.SetAttributes(attribute.Bool("fanout.dry_run", mode.dryRun))
+	defer func() {
+		endSpan(span, err)
+	}()
+
 	if !item.AllowSending() {
-		log.Info().Msgf("skip processing due to invalid type/action: %s with action %s", item.ProposalID, item.Action)
+		logger(ctx).Info().Msg("skip processing due to invalid type/action")
 
-		return nil
+		outcomes := []FanoutLog{newFanoutLog(item, uuid.Nil, FanoutSkippedAction)}
+		if mode.dryRun {
+			return outcomes, nil
+		}
+
+		if err := s.repo.CreateFanoutLog(ctx, outcomes); err != nil {
+			logger(ctx).Error().Err(err).Msg("create fanout log")
+		}
+
+		return outcomes, nil
 	}
 
 	resp, err := s.subscriptions.FindSubscribers(ctx, &inboxapi.FindSubscribersRequest{
 		DaoId: item.DaoID.String(),
 	})
 	if err != nil {
-		return fmt.Errorf("find subscribers by dao id %s: %w", item.DaoID.String(), err)
+		return nil, fmt.Errorf("find subscribers by dao id %s: %w", item.DaoID.String(), err)
+	}
+
+	logger(ctx).Info().Int("subscribers", len(resp.Users)).Msg("found dao subscribers")
+
+	span.SetAttributes(attribute.Int("fanout.subscribers", len(resp.Users)))
+
+	if !mode.dryRun {
+		metricFanoutSize.WithLabelValues(actionLabel(item.Action)).Observe(float64(len(resp.Users)))
 	}
 
-	log.Info().Msgf("for dao %s founded %d subscribers", item.DaoID.String(), len(resp.Users))
+	// store decisions made so far even if processing is interrupted by an error
+	outcomes := make([]FanoutLog, 0, len(resp.Users))
+	defer func() {
+		if mode.dryRun {
+			return
+		}
+
+		if err := s.storeFanoutLog(context.WithoutCancel(ctx), outcomes); err != nil {
+			logger(ctx).Error().Err(err).Msg("create fanout log")
+		}
+	}()
 
+	var (
+		queued    int
+		failed    int
+		lookupErr error
+	)
 	for _, sub := range resp.Users {
+		if mode.limit > 0 && queued >= mode.limit {
+			return outcomes, ErrFanoutLimitReached
+		}
+
+		// a malformed id affects only its subscriber, the rest are decided independently
 		subscriberID, err := uuid.Parse(sub.GetUserId())
 		if err != nil {
-			return fmt.Errorf("unable to parse subscriber id '%s': %w", sub.GetUserId(), err)
-		}
+			logger(ctx).Warn().Err(err).Str("subscriber_id", sub.GetUserId()).Msg("skip subscriber due to invalid id")
 
-		// check that the user has allowed to receive push notifications
-		if list, err := s.GetTokens(ctx, subscriberID); err != nil || len(list) == 0 {
-			log.Info().Msgf("skip processing %s to user %s due to missing tokens", item.ProposalID, subscriberID.String())
+			outcomes = append(outcomes, newFanoutLog(item, uuid.Nil, FanoutSkippedInvalidUser))
 
-			return nil
+			continue
 		}
 
-		err = s.repo.CreateSendQueueRequest(ctx, &SendQueue{
-			UserID:     subscriberID,
-			DaoID:      item.DaoID,
-			ProposalID: item.ProposalID,
-			Action:     item.Action,
-		})
+		outcome, err := s.fanout(ctx, item, subscriberID, mode.dryRun)
+		if outcome == FanoutFailedTokens {
+			// the failed lookup affects only its subscriber, the event is retried after the rest are decided
+			logger(ctx).Error().Err(err).Stringer("user_id", subscriberID).Msg("get subscriber tokens")
+
+			outcomes = append(outcomes, newFanoutLog(item, subscriberID, outcome))
+			if lookupErr == nil {
+				lookupErr = err
+			}
+			failed++
+
+			continue
+		}
 		if err != nil {
-			return fmt.Errorf("s.repo.CreateSendQueueRequest: %w", err)
+			return outcomes, err
+		}
+
+		if outcome == FanoutQueued {
+			queued++
+		}
+
+		outcomes = append(outcomes, newFanoutLog(item, subscriberID, outcome))
+	}
+
+	if lookupErr != nil {
+		return outcomes, fmt.Errorf("get tokens of %d subscribers: %w", failed, lookupErr)
+	}
+
+	return outcomes, nil
+}
+
+// fanout decides whether the feed item has to be queued for the subscriber
+func (s *Service) fanout(ctx context.Context, item Item, subscriberID uuid.UUID, dryRun bool) (FanoutOutcome, error) {
+	// check that the user has allowed to receive push notifications, the failed lookup is retried
+	// with the whole event instead of being taken as missing tokens
+	list, err := s.GetTokens(ctx, subscriberID)
+	if err != nil {
+		return FanoutFailedTokens, fmt.Errorf("s.GetTokens: %w", err)
+	}
+	if len(list) == 0 {
+		logger(ctx).Info().Stringer("user_id", subscriberID).Msg("skip subscriber due to missing tokens")
+
+		return FanoutSkippedNoTokens, nil
+	}
+
+	if dryRun {
+		return FanoutQueued, nil
+	}
+
+	queueItem := SendQueue{
+		UserID:        subscriberID,
+		DaoID:         item.DaoID,
+		ProposalID:    item.ProposalID,
+		Action:        item.Action,
+		TraceParent:   traceParent(ctx),
+		CorrelationID: CorrelationID(ctx),
+	}
+
+	var created bool
+	err = s.repo.Transaction(ctx, func(tx Storage) error {
+		var err error
+		created, err = tx.CreateSendQueueRequest(ctx, &queueItem)
+		if err != nil || !created {
+			return err
 		}
 
-		log.Info().Msgf("proposal %s processed for %s subscriber", item.ProposalID, subscriberID.String())
+		return tx.CreateOutboxMessages(ctx, []OutboxMessage{queueItemEvent(SubjectPushQueued, queueItem)})
+	})
+
+	collectStats("queue", "add", err)
+
+	if err != nil {
+		return "", fmt.Errorf("s.repo.CreateSendQueueRequest: %w", err)
+	}
+
+	if !created {
+		logger(ctx).Info().Stringer("user_id", subscriberID).Msg("proposal already queued for subscriber")
+
+		return FanoutDuplicate, nil
+	}
+
+	logger(ctx).Info().Stringer("user_id", subscriberID).Msg("proposal queued for subscriber")
+
+	return FanoutQueued, nil
+}
+
+// storeFanoutLog stores fan-out decisions along with events about skipped subscribers
+func (s *Service) storeFanoutLog(ctx context.Context, outcomes []FanoutLog) error {
+	events := make([]OutboxMessage, 0, len(outcomes))
+	for _, info := range outcomes {
+		if info.Outcome != FanoutSkippedNoTokens {
+			continue
+		}
 
-		collectStats("queue", "add", err)
+		events = append(events, queueItemEvent(SubjectPushSkipped, SendQueue{
+			UserID:        info.UserID,
+			DaoID:         info.DaoID,
+			ProposalID:    info.ProposalID,
+			Action:        info.Action,
+			CorrelationID: CorrelationID(ctx),
+		}))
 	}
 
-	return nil
+	return s.repo.Transaction(ctx, func(tx Storage) error {
+		if err := tx.CreateFanoutLog(ctx, outcomes); err != nil {
+			return err
+		}
+
+		return tx.CreateOutboxMessages(ctx, events)
+	})
+}
+
+// FanoutLogByFilters returns fan-out decisions, e.g. to find out why a user did not receive a push
+func (s *Service) FanoutLogByFilters(ctx context.Context, filters []Filter) ([]FanoutLog, error) {
+	return s.repo.FanoutLogByFilters(ctx, filters)
+}
+
+// feedEventKey identifies the feed event by its content. The payload timeline holds
+// the timestamp of each action, so the same action published again later gets a new key.
+func feedEventKey(payload inbox.FeedPayload) (string, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(data)
+
+	return hex.EncodeToString(hash[:]), nil
 }
 
-func convertPayloadToInternal(payload inbox.FeedPayload) Item {
+func convertPayloadToInternal(ctx context.Context, payload inbox.FeedPayload) Item {
 	return Item{
+		FeedID:     payload.ID,
 		DaoID:      payload.DaoID,
 		ProposalID: payload.ProposalID,
-		Action:     convertPayloadActionToInternal(payload.Action),
+		Action:     convertPayloadActionToInternal(ctx, payload.Action),
 	}
 }
 
-func convertPayloadActionToInternal(action inbox.TimelineAction) Action {
+func convertPayloadActionToInternal(ctx context.Context, action inbox.TimelineAction) Action {
 	converted, ok := payloadActionMap[action]
 
 	if !ok {
-		log.Warn().Any("action", action).Msg("unknown payload timeline action")
+		logger(ctx).Warn().Str("payload_action", string(action)).Msg("unknown payload timeline action")
 	}
 
 	return converted
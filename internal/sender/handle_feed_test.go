@@ -1,11 +1,18 @@
 package sender
 
 import (
+	"context"
+	"errors"
+	"slices"
 	"testing"
+	"time"
 
+	"github.com/golang/mock/gomock"
 	"github.com/google/uuid"
+	"github.com/goverland-labs/goverland-inbox-api-protocol/protobuf/inboxapi"
 	"github.com/goverland-labs/goverland-platform-events/events/inbox"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestConvertPayloadActionToInternal(t *testing.T) {
@@ -51,7 +58,7 @@ func TestConvertPayloadActionToInternal(t *testing.T) {
 		},
 	} {
 		t.Run(name, func(t *testing.T) {
-			actual := convertPayloadActionToInternal(tc.in)
+			actual := convertPayloadActionToInternal(context.Background(), tc.in)
 			assert.Equal(t, tc.expected, actual)
 		})
 	}
@@ -59,13 +66,219 @@ func TestConvertPayloadActionToInternal(t *testing.T) {
 
 func TestConvertPayloadToInternal(t *testing.T) {
 	in := inbox.FeedPayload{
+		ID:         uuid.New(),
 		DaoID:      uuid.New(),
 		ProposalID: uuid.New().String(),
 		Action:     inbox.ProposalCreated,
 	}
 
-	actual := convertPayloadToInternal(in)
-	assert.Equal(t, convertPayloadActionToInternal(in.Action), actual.Action)
+	actual := convertPayloadToInternal(context.Background(), in)
+	assert.Equal(t, convertPayloadActionToInternal(context.Background(), in.Action), actual.Action)
 	assert.Equal(t, in.ProposalID, actual.ProposalID)
 	assert.Equal(t, in.DaoID, actual.DaoID)
+	assert.Equal(t, in.ID, actual.FeedID)
+}
+
+func TestFeedEventKey(t *testing.T) {
+	in := inbox.FeedPayload{
+		ID:         uuid.New(),
+		DaoID:      uuid.New(),
+		ProposalID: uuid.New().String(),
+		Type:       inbox.TypeProposal,
+		Action:     inbox.ProposalCreated,
+		Timeline: []inbox.TimelineItem{
+			{
+				CreatedAt: time.Date(2024, 12, 1, 10, 0, 0, 0, time.UTC),
+				Action:    inbox.ProposalCreated,
+			},
+		},
+	}
+
+	expected, err := feedEventKey(in)
+	require.NoError(t, err)
+
+	t.Run("same event", func(t *testing.T) {
+		actual, err := feedEventKey(in)
+		require.NoError(t, err)
+		assert.Equal(t, expected, actual)
+	})
+
+	t.Run("another action", func(t *testing.T) {
+		next := in
+		next.Action = inbox.ProposalVotingEnded
+		next.Timeline = append(slices.Clone(in.Timeline), inbox.TimelineItem{
+			CreatedAt: time.Date(2024, 12, 3, 10, 0, 0, 0, time.UTC),
+			Action:    inbox.ProposalVotingEnded,
+		})
+
+		actual, err := feedEventKey(next)
+		require.NoError(t, err)
+		assert.NotEqual(t, expected, actual)
+	})
+}
+
+func TestHandleFeed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	in := inbox.FeedPayload{
+		ID:         uuid.New(),
+		DaoID:      uuid.New(),
+		ProposalID: uuid.New().String(),
+		Action:     inbox.ProposalCreated,
+	}
+	key, err := feedEventKey(in)
+	require.NoError(t, err)
+
+	pm := NewMockPushManipulator(ctrl)
+	pm.EXPECT().
+		ProcessFeedEvent(gomock.Any(), key, convertPayloadToInternal(context.Background(), in)).
+		Times(1).
+		Return(nil)
+
+	c := &Consumer{service: pm}
+	require.NoError(t, c.handleFeed()(context.Background(), in))
+}
+
+func TestProcessFeedItem(t *testing.T) {
+	var (
+		withoutTokens = uuid.New()
+		withTokens    = uuid.New()
+	)
+	item := Item{
+		FeedID:     uuid.New(),
+		DaoID:      uuid.New(),
+		ProposalID: uuid.NewString(),
+		Action:     ProposalCreated,
+	}
+	tokens := func(userID uuid.UUID) (*inboxapi.PushTokenListResponse, error) {
+		if userID != withTokens {
+			return &inboxapi.PushTokenListResponse{}, nil
+		}
+
+		return &inboxapi.PushTokenListResponse{
+			Tokens: []*inboxapi.PushTokenDetails{{Token: "token", DeviceUuid: "device"}},
+		}, nil
+	}
+
+	for name, tc := range map[string]struct {
+		subscribers []string
+		tokens      func(userID uuid.UUID) (*inboxapi.PushTokenListResponse, error)
+		wantErr     bool
+		outcomes    map[uuid.UUID]FanoutOutcome
+		queued      []uuid.UUID
+	}{
+		"subscriber without tokens does not stop the rest": {
+			subscribers: []string{withoutTokens.String(), withTokens.String()},
+			tokens:      tokens,
+			outcomes: map[uuid.UUID]FanoutOutcome{
+				withoutTokens: FanoutSkippedNoTokens,
+				withTokens:    FanoutQueued,
+			},
+			queued: []uuid.UUID{withTokens},
+		},
+		"malformed subscriber id is skipped": {
+			subscribers: []string{"not-a-uuid", withTokens.String()},
+			tokens:      tokens,
+			outcomes: map[uuid.UUID]FanoutOutcome{
+				uuid.Nil:   FanoutSkippedInvalidUser,
+				withTokens: FanoutQueued,
+			},
+			queued: []uuid.UUID{withTokens},
+		},
+		"token lookup failure is returned after the rest are queued": {
+			subscribers: []string{withoutTokens.String(), withTokens.String()},
+			tokens: func(userID uuid.UUID) (*inboxapi.PushTokenListResponse, error) {
+				if userID == withoutTokens {
+					return nil, errors.New("unavailable")
+				}
+
+				return tokens(userID)
+			},
+			wantErr: true,
+			outcomes: map[uuid.UUID]FanoutOutcome{
+				withoutTokens: FanoutFailedTokens,
+				withTokens:    FanoutQueued,
+			},
+			queued: []uuid.UUID{withTokens},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			ctx := context.Background()
+
+			users := make([]*inboxapi.UserID, 0, len(tc.subscribers))
+			for _, id := range tc.subscribers {
+				users = append(users, &inboxapi.UserID{UserId: id})
+			}
+
+			subs := NewMockSubscriptionsFinder(ctrl)
+			subs.EXPECT().
+				FindSubscribers(gomock.Any(), &inboxapi.FindSubscribersRequest{DaoId: item.DaoID.String()}).
+				Return(&inboxapi.UserList{Users: users}, nil)
+
+			sp := NewMockSettingsProvider(ctrl)
+			sp.EXPECT().
+				GetPushTokenList(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, req *inboxapi.GetPushTokenListRequest, _ ...any) (*inboxapi.PushTokenListResponse, error) {
+					return tc.tokens(uuid.MustParse(req.GetUserId()))
+				}).
+				AnyTimes()
+
+			storage := NewMemoryStorage()
+			s := &Service{repo: storage, subscriptions: subs, settings: sp}
+
+			err := s.ProcessFeedItem(ctx, item)
+			if tc.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			logs, err := storage.FanoutLogByFilters(ctx, nil)
+			require.NoError(t, err)
+
+			outcomes := make(map[uuid.UUID]FanoutOutcome, len(logs))
+			for _, info := range logs {
+				outcomes[info.UserID] = info.Outcome
+			}
+			assert.Equal(t, tc.outcomes, outcomes)
+
+			list, err := storage.QueueByFilters(ctx, nil)
+			require.NoError(t, err)
+
+			queued := make([]uuid.UUID, 0, len(list))
+			for _, info := range list {
+				queued = append(queued, info.UserID)
+			}
+			assert.ElementsMatch(t, tc.queued, queued)
+		})
+	}
+}
+
+func TestProcessFeedEventSkipsLeasedEvent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+	item := Item{
+		FeedID:     uuid.New(),
+		DaoID:      uuid.New(),
+		ProposalID: uuid.NewString(),
+		Action:     ProposalCreated,
+	}
+
+	// the fan-out runs only for the attempt which holds the lease
+	subs := NewMockSubscriptionsFinder(ctrl)
+	subs.EXPECT().
+		FindSubscribers(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(&inboxapi.UserList{}, nil)
+
+	storage := NewMemoryStorage()
+	_, claimed, err := storage.ClaimInboxEvent(ctx, inbox.SubjectFeedUpdated, "key", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.True(t, claimed)
+
+	s := &Service{repo: storage, subscriptions: subs}
+	require.ErrorIs(t, s.ProcessFeedEvent(ctx, "key", item), ErrEventInProgress)
+
+	require.NoError(t, s.ProcessFeedEvent(ctx, "other", item))
+	require.NoError(t, s.ProcessFeedEvent(ctx, "other", item))
 }
@@ -0,0 +1,95 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2/google"
+
+	"github.com/goverland-labs/goverland-inbox-push/internal/config"
+)
+
+const firebaseMessagingScope = "https://www.googleapis.com/auth/firebase.messaging"
+
+// workerRuns keeps the last successful run of each worker for readiness checks
+var workerRuns = newWorkerRegistry(time.Now())
+
+type workerRegistry struct {
+	mu      sync.RWMutex
+	started time.Time
+	last    map[string]time.Time
+}
+
+func newWorkerRegistry(started time.Time) *workerRegistry {
+	return &workerRegistry{
+		started: started,
+		last:    make(map[string]time.Time),
+	}
+}
+
+func (r *workerRegistry) success(worker string, at time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.last[worker] = at
+}
+
+// check fails if the worker has not succeeded for longer than maxAge,
+// the age is counted from the start until the first successful run
+func (r *workerRegistry) check(worker string, maxAge time.Duration, now time.Time) error {
+	r.mu.RLock()
+	last, ok := r.last[worker]
+	r.mu.RUnlock()
+
+	if !ok {
+		if now.Sub(r.started) > maxAge {
+			return fmt.Errorf("no successful run since start %s ago", now.Sub(r.started).Round(time.Second))
+		}
+
+		return nil
+	}
+
+	if age := now.Sub(last); age > maxAge {
+		return fmt.Errorf("last successful run %s ago", age.Round(time.Second))
+	}
+
+	return nil
+}
+
+// WorkerCheck returns the readiness check of the worker last successful run
+func WorkerCheck(worker string, maxAge time.Duration) func(ctx context.Context) error {
+	return func(_ context.Context) error {
+		return workerRuns.check(worker, maxAge, time.Now())
+	}
+}
+
+// CheckCredentials checks that firebase credentials of enabled projects can be exchanged for access tokens
+func (s *Service) CheckCredentials(ctx context.Context) error {
+	if s.projects == nil {
+		return errors.New("firebase projects are not configured")
+	}
+
+	return s.projects.CheckCredentials(ctx)
+}
+
+// ValidateCredentials checks that the service account can be read and parsed, it doesn't request the token
+func ValidateCredentials(cfg config.Push) error {
+	data, _, err := readCredentials(cfg)
+	if err != nil {
+		return err
+	}
+
+	creds, err := google.CredentialsFromJSON(context.Background(), data, firebaseMessagingScope)
+	if err != nil {
+		return fmt.Errorf("parse credentials: %w", err)
+	}
+
+	if creds.ProjectID == "" {
+		return errors.New("project id is required")
+	}
+
+	return nil
+}
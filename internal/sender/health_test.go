@@ -0,0 +1,21 @@
+package sender
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkerRegistryCheck(t *testing.T) {
+	started := time.Now()
+	registry := newWorkerRegistry(started)
+
+	// the worker has time for the first run after the start
+	require.NoError(t, registry.check("outbox", time.Minute, started.Add(30*time.Second)))
+	require.Error(t, registry.check("outbox", time.Minute, started.Add(2*time.Minute)))
+
+	registry.success("outbox", started.Add(90*time.Second))
+	require.NoError(t, registry.check("outbox", time.Minute, started.Add(2*time.Minute)))
+	require.Error(t, registry.check("outbox", time.Minute, started.Add(3*time.Minute)))
+}
@@ -0,0 +1,43 @@
+package sender
+
+import (
+	"context"
+	"time"
+
+	"github.com/goverland-labs/goverland-inbox-push/internal/config"
+)
+
+type InboxWorker struct {
+	repo Storage
+	cfg  config.Inbox
+}
+
+func NewInboxWorker(r Storage, cfg config.Inbox) *InboxWorker {
+	return &InboxWorker{
+		repo: r,
+		cfg:  cfg,
+	}
+}
+
+// Start removes inbox events older than the retention periodically
+func (w *InboxWorker) Start(ctx context.Context) error {
+	for {
+		start := time.Now()
+		runCtx := workerRunContext(ctx, "inbox-cleanup")
+		deleted, err := w.repo.DeleteInboxEventsBefore(runCtx, start.Add(-w.cfg.Retention))
+		if err != nil {
+			logger(runCtx).Error().Err(err).Msg("delete old inbox events")
+		} else if deleted > 0 {
+			logger(runCtx).Info().Int64("deleted", deleted).Msg("deleted old inbox events")
+		}
+
+		collectStats("inbox", "cleanup", err)
+		observeWorkerRun("inbox-cleanup", start, err)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(w.cfg.CleanupInterval):
+		}
+	}
+}
@@ -0,0 +1,1080 @@
+package sender
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+var _ Storage = (*MemoryStorage)(nil)
+
+// memoryData holds tables of MemoryStorage, rows are stored by value so the copy is the snapshot
+type memoryData struct {
+	lastID      map[string]uint
+	histories   []History
+	queue       []SendQueue
+	fanoutLog   []FanoutLog
+	inbox       []InboxEvent
+	deadLetters []DeadLetter
+	outbox      []OutboxMessage
+	dedup       []PushDedup
+	broadcasts  []Broadcast
+	auditLog    []AuditLog
+}
+
+func (d *memoryData) clone() *memoryData {
+	return &memoryData{
+		lastID:      maps.Clone(d.lastID),
+		histories:   slices.Clone(d.histories),
+		queue:       slices.Clone(d.queue),
+		fanoutLog:   slices.Clone(d.fanoutLog),
+		inbox:       slices.Clone(d.inbox),
+		deadLetters: slices.Clone(d.deadLetters),
+		outbox:      slices.Clone(d.outbox),
+		dedup:       slices.Clone(d.dedup),
+		broadcasts:  slices.Clone(d.broadcasts),
+		auditLog:    slices.Clone(d.auditLog),
+	}
+}
+
+// newModel fills the model of the row inserted into the table the same way gorm does
+func (d *memoryData) newModel(table string, model *gorm.Model, now time.Time) {
+	d.lastID[table]++
+	model.ID = d.lastID[table]
+
+	if model.CreatedAt.IsZero() {
+		model.CreatedAt = now
+	}
+	if model.UpdatedAt.IsZero() {
+		model.UpdatedAt = now
+	}
+}
+
+// MemoryStorage keeps the state in memory with the same semantics as Repo. It is safe for concurrent use,
+// transactions are serialized and applied only if fn succeeds.
+type MemoryStorage struct {
+	mu   *sync.Mutex
+	data *memoryData
+	// inTx is set for the storage bound to the transaction, the lock is already held by it
+	inTx bool
+	now  func() time.Time
+}
+
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		mu:   &sync.Mutex{},
+		data: &memoryData{lastID: make(map[string]uint)},
+		now:  time.Now,
+	}
+}
+
+// SetClock replaces the source of the current time used for timestamps
+func (m *MemoryStorage) SetClock(now func() time.Time) {
+	unlock := m.lock()
+	defer unlock()
+
+	m.now = now
+}
+
+func (m *MemoryStorage) lock() func() {
+	if m.inTx {
+		return func() {}
+	}
+
+	m.mu.Lock()
+
+	return m.mu.Unlock
+}
+
+// timestamp returns the current time with the precision of postgres
+func (m *MemoryStorage) timestamp() time.Time {
+	return m.now().Truncate(time.Microsecond)
+}
+
+func (m *MemoryStorage) Transaction(_ context.Context, fn func(tx Storage) error) error {
+	unlock := m.lock()
+	defer unlock()
+
+	tx := &MemoryStorage{
+		mu:   m.mu,
+		data: m.data.clone(),
+		inTx: true,
+		now:  m.now,
+	}
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	*m.data = *tx.data
+
+	return nil
+}
+
+func (m *MemoryStorage) Create(_ context.Context, item *History) error {
+	unlock := m.lock()
+	defer unlock()
+
+	m.data.newModel("histories", &item.Model, m.timestamp())
+	m.data.histories = append(m.data.histories, *item)
+
+	return nil
+}
+
+func (m *MemoryStorage) GetByHash(_ context.Context, hash string) (*History, error) {
+	unlock := m.lock()
+	defer unlock()
+
+	for _, h := range m.data.histories {
+		if !h.DeletedAt.Valid && h.Hash == hash {
+			return &h, nil
+		}
+	}
+
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *MemoryStorage) MarkAsClicked(_ context.Context, messageUUID uuid.UUID) error {
+	m.updateHistories(func(h *History) bool {
+		if h.Message.ID != messageUUID {
+			return false
+		}
+
+		h.ClickedAt = ptr(m.timestamp())
+		if h.ReadAt == nil {
+			h.ReadAt = h.ClickedAt
+		}
+		return true
+	})
+
+	return nil
+}
+
+func (m *MemoryStorage) MarkAsDelivered(_ context.Context, messageUUID uuid.UUID) error {
+	m.updateHistories(func(h *History) bool {
+		if h.Message.ID != messageUUID || h.DeliveredAt != nil {
+			return false
+		}
+
+		h.DeliveredAt = ptr(m.timestamp())
+		return true
+	})
+
+	return nil
+}
+
+func (m *MemoryStorage) MarkAsDismissed(_ context.Context, messageUUID uuid.UUID) error {
+	m.updateHistories(func(h *History) bool {
+		if h.Message.ID != messageUUID || h.DismissedAt != nil {
+			return false
+		}
+
+		h.DismissedAt = ptr(m.timestamp())
+		return true
+	})
+
+	return nil
+}
+
+func (m *MemoryStorage) MarkAsRead(_ context.Context, userID uuid.UUID, ids []uuid.UUID) error {
+	m.updateHistories(func(h *History) bool {
+		if h.UserID != userID || h.ReadAt != nil {
+			return false
+		}
+		if len(ids) > 0 && !slices.Contains(ids, h.Message.ID) {
+			return false
+		}
+
+		h.ReadAt = ptr(m.timestamp())
+		return true
+	})
+
+	return nil
+}
+
+// updateHistories applies update to rows, the row is touched if update reports it is changed
+func (m *MemoryStorage) updateHistories(update func(h *History) bool) {
+	unlock := m.lock()
+	defer unlock()
+
+	for idx := range m.data.histories {
+		h := &m.data.histories[idx]
+		if !h.DeletedAt.Valid && update(h) {
+			h.UpdatedAt = m.timestamp()
+		}
+	}
+}
+
+func (m *MemoryStorage) HistoriesInBatches(_ context.Context, filters []Filter, batchSize int, fn func([]History) error) error {
+	unlock := m.lock()
+	var list []History
+	for _, h := range m.data.histories {
+		if !h.DeletedAt.Valid && matchFilters(filters, h.filterRow()) {
+			list = append(list, h)
+		}
+	}
+	unlock()
+
+	for batch := range slices.Chunk(list, batchSize) {
+		if err := fn(batch); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *MemoryStorage) Notifications(_ context.Context, userID uuid.UUID, before *NotificationCursor, limit int) ([]History, error) {
+	unlock := m.lock()
+	defer unlock()
+
+	// each device has own history row with the same message id, the first one is returned
+	var list []History
+	seen := make(map[uuid.UUID]bool)
+	for _, h := range m.data.histories {
+		if h.DeletedAt.Valid || h.UserID != userID || seen[h.Message.ID] {
+			continue
+		}
+		seen[h.Message.ID] = true
+
+		if before != nil && !notificationBefore(h, *before) {
+			continue
+		}
+
+		list = append(list, h)
+	}
+
+	slices.SortFunc(list, func(a, b History) int {
+		return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), cmp.Compare(b.ID, a.ID))
+	})
+
+	return limitRows(list, limit), nil
+}
+
+func notificationBefore(h History, cursor NotificationCursor) bool {
+	if !h.CreatedAt.Equal(cursor.CreatedAt) {
+		return h.CreatedAt.Before(cursor.CreatedAt)
+	}
+
+	return h.ID < cursor.ID
+}
+
+func (m *MemoryStorage) UnreadNotificationsCount(_ context.Context, userID uuid.UUID) (int64, error) {
+	unlock := m.lock()
+	defer unlock()
+
+	unread := make(map[uuid.UUID]bool)
+	for _, h := range m.data.histories {
+		if !h.DeletedAt.Valid && h.UserID == userID && h.ReadAt == nil {
+			unread[h.Message.ID] = true
+		}
+	}
+
+	return int64(len(unread)), nil
+}
+
+func (m *MemoryStorage) ClickStats(_ context.Context, query ClickStatsQuery) ([]ClickStats, error) {
+	if _, ok := clickStatsGroupExpr[query.GroupBy]; !ok {
+		return nil, fmt.Errorf("unsupported group: %s", query.GroupBy)
+	}
+
+	unlock := m.lock()
+	defer unlock()
+
+	// each device has own history row, but clicks are marked by message id for all of them
+	first := make(map[uuid.UUID]History)
+	var rows []History
+	for _, h := range m.data.histories {
+		if h.DeletedAt.Valid || h.CreatedAt.Before(query.From) || !h.CreatedAt.Before(query.To) {
+			continue
+		}
+		if query.ExperimentID != "" && h.Message.ExperimentID != query.ExperimentID {
+			continue
+		}
+
+		if query.GroupBy == GroupByDevice {
+			rows = append(rows, h)
+			continue
+		}
+
+		if prev, ok := first[h.Message.ID]; !ok || h.CreatedAt.Before(prev.CreatedAt) {
+			first[h.Message.ID] = h
+		}
+	}
+	for _, h := range first {
+		rows = append(rows, h)
+	}
+
+	groups := make(map[string]*ClickStats)
+	clicks := make(map[string][]float64)
+	for _, h := range rows {
+		key := clickStatsKey(query.GroupBy, h)
+		stats, ok := groups[key]
+		if !ok {
+			stats = &ClickStats{Key: key}
+			groups[key] = stats
+		}
+
+		stats.Sends++
+		if h.ClickedAt != nil {
+			stats.Clicks++
+			clicks[key] = append(clicks[key], h.ClickedAt.Sub(h.CreatedAt).Seconds())
+		}
+		if h.DeliveredAt != nil {
+			stats.Delivered++
+		}
+		if h.DismissedAt != nil {
+			stats.Dismissed++
+		}
+	}
+
+	list := make([]ClickStats, 0, len(groups))
+	for key, stats := range groups {
+		ttc := clicks[key]
+		slices.Sort(ttc)
+		stats.TimeToClickP50 = percentileCont(ttc, 0.5)
+		stats.TimeToClickP90 = percentileCont(ttc, 0.9)
+		stats.TimeToClickP99 = percentileCont(ttc, 0.99)
+
+		list = append(list, *stats)
+	}
+
+	slices.SortFunc(list, func(a, b ClickStats) int {
+		return cmp.Or(cmp.Compare(b.Sends, a.Sends), cmp.Compare(a.Key, b.Key))
+	})
+
+	return list, nil
+}
+
+// clickStatsKey evaluates clickStatsGroupExpr for the row
+func clickStatsKey(group ClickStatsGroup, h History) string {
+	switch group {
+	case GroupByTemplate:
+		return strconv.Itoa(int(h.Message.TemplateID))
+	case GroupByAction:
+		return cmp.Or(string(h.Action), "mixed")
+	case GroupByDao:
+		if h.DaoID == nil {
+			return "mixed"
+		}
+
+		return h.DaoID.String()
+	case GroupByDevice:
+		return h.Message.DeviceUUID
+	case GroupByDay:
+		return h.CreatedAt.UTC().Format(time.DateOnly)
+	case GroupByVariant:
+		return cmp.Or(h.Message.VariantID, "none")
+	}
+
+	return ""
+}
+
+// percentileCont interpolates the percentile of sorted values as percentile_cont does
+func percentileCont(sorted []float64, p float64) *float64 {
+	if len(sorted) == 0 {
+		return nil
+	}
+
+	pos := p * float64(len(sorted)-1)
+	lower := int(math.Floor(pos))
+	upper := int(math.Ceil(pos))
+	value := sorted[lower] + (sorted[upper]-sorted[lower])*(pos-float64(lower))
+
+	return &value
+}
+
+func (m *MemoryStorage) KnownUserIDs(_ context.Context) ([]uuid.UUID, error) {
+	unlock := m.lock()
+	defer unlock()
+
+	var list []uuid.UUID
+	for _, h := range m.data.histories {
+		if !h.DeletedAt.Valid && !slices.Contains(list, h.UserID) {
+			list = append(list, h.UserID)
+		}
+	}
+	for _, q := range m.data.queue {
+		if !q.DeletedAt.Valid && !slices.Contains(list, q.UserID) {
+			list = append(list, q.UserID)
+		}
+	}
+
+	return list, nil
+}
+
+func (m *MemoryStorage) QueueByFilters(_ context.Context, filters []Filter) ([]SendQueue, error) {
+	unlock := m.lock()
+	defer unlock()
+
+	return m.queueByFilters(filters), nil
+}
+
+func (m *MemoryStorage) QueueItems(_ context.Context, filters []Filter, limit int) ([]SendQueue, error) {
+	unlock := m.lock()
+	defer unlock()
+
+	list := m.queueByFilters(filters)
+	slices.Reverse(list)
+
+	return limitRows(list, limit), nil
+}
+
+func (m *MemoryStorage) queueByFilters(filters []Filter) []SendQueue {
+	var list []SendQueue
+	for _, q := range m.data.queue {
+		if !q.DeletedAt.Valid && matchFilters(filters, q.filterRow()) {
+			list = append(list, q)
+		}
+	}
+
+	return list
+}
+
+func (m *MemoryStorage) PendingQueueStats(_ context.Context) ([]PendingQueueStats, error) {
+	unlock := m.lock()
+	defer unlock()
+
+	stats := make(map[Action]*PendingQueueStats)
+	for _, q := range m.data.queue {
+		if q.DeletedAt.Valid || q.SentAt != nil {
+			continue
+		}
+
+		item, ok := stats[q.Action]
+		if !ok {
+			item = &PendingQueueStats{Action: q.Action, OldestCreatedAt: q.CreatedAt}
+			stats[q.Action] = item
+		}
+
+		item.Count++
+		if q.CreatedAt.Before(item.OldestCreatedAt) {
+			item.OldestCreatedAt = q.CreatedAt
+		}
+	}
+
+	list := make([]PendingQueueStats, 0, len(stats))
+	for _, item := range stats {
+		list = append(list, *item)
+	}
+	slices.SortFunc(list, func(a, b PendingQueueStats) int {
+		return cmp.Compare(a.Action, b.Action)
+	})
+
+	return list, nil
+}
+
+func (m *MemoryStorage) CreateSendQueueRequest(_ context.Context, item *SendQueue) (bool, error) {
+	unlock := m.lock()
+	defer unlock()
+
+	// only pending items are unique, the same as idx_send_queue_pending_user_dao_proposal_action
+	if m.hasPendingQueueItem(*item) {
+		return false, nil
+	}
+
+	m.data.newModel("send_queue", &item.Model, m.timestamp())
+	m.data.queue = append(m.data.queue, *item)
+
+	return true, nil
+}
+
+func (m *MemoryStorage) hasPendingQueueItem(item SendQueue) bool {
+	for _, q := range m.data.queue {
+		if q.DeletedAt.Valid || q.SentAt != nil {
+			continue
+		}
+
+		if q.UserID == item.UserID && q.DaoID == item.DaoID && q.ProposalID == item.ProposalID && q.Action == item.Action {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (m *MemoryStorage) MarkAsSent(ctx context.Context, ids []uint) error {
+	unlock := m.lock()
+	defer unlock()
+
+	now := m.timestamp()
+	for idx := range m.data.queue {
+		q := &m.data.queue[idx]
+		if q.DeletedAt.Valid || !slices.Contains(ids, q.ID) {
+			continue
+		}
+
+		q.SentAt = ptr(now)
+		q.SentCorrelationID = CorrelationID(ctx)
+		q.UpdatedAt = now
+	}
+
+	return nil
+}
+
+func (m *MemoryStorage) CancelQueueItems(_ context.Context, ids []uint) ([]uint, error) {
+	unlock := m.lock()
+	defer unlock()
+
+	cancelled := make([]uint, 0, len(ids))
+	for idx := range m.data.queue {
+		q := &m.data.queue[idx]
+		if q.DeletedAt.Valid || q.SentAt != nil || !slices.Contains(ids, q.ID) {
+			continue
+		}
+
+		q.DeletedAt = gorm.DeletedAt{Time: m.timestamp(), Valid: true}
+		cancelled = append(cancelled, q.ID)
+	}
+
+	return cancelled, nil
+}
+
+func (m *MemoryStorage) RequeueQueueItems(_ context.Context, ids []uint) ([]uint, error) {
+	unlock := m.lock()
+	defer unlock()
+
+	requeued := make([]uint, 0, len(ids))
+	for idx := range m.data.queue {
+		q := &m.data.queue[idx]
+		if q.DeletedAt.Valid || q.SentAt == nil || !slices.Contains(ids, q.ID) {
+			continue
+		}
+		if m.hasPendingQueueItem(*q) {
+			continue
+		}
+
+		q.SentAt = nil
+		q.SentCorrelationID = ""
+		q.UpdatedAt = m.timestamp()
+		requeued = append(requeued, q.ID)
+	}
+
+	return requeued, nil
+}
+
+func (m *MemoryStorage) CreateFanoutLog(_ context.Context, list []FanoutLog) error {
+	unlock := m.lock()
+	defer unlock()
+
+	now := m.timestamp()
+	for idx := range list {
+		m.data.newModel("fanout_log", &list[idx].Model, now)
+		m.data.fanoutLog = append(m.data.fanoutLog, list[idx])
+	}
+
+	return nil
+}
+
+func (m *MemoryStorage) FanoutLogByFilters(_ context.Context, filters []Filter) ([]FanoutLog, error) {
+	unlock := m.lock()
+	defer unlock()
+
+	var list []FanoutLog
+	for _, item := range m.data.fanoutLog {
+		if !item.DeletedAt.Valid && matchFilters(filters, item.filterRow()) {
+			list = append(list, item)
+		}
+	}
+
+	return list, nil
+}
+
+func (m *MemoryStorage) ClaimInboxEvent(_ context.Context, subject, key string, lockedUntil time.Time) (*InboxEvent, bool, error) {
+	unlock := m.lock()
+	defer unlock()
+
+	now := m.timestamp()
+	for idx := range m.data.inbox {
+		item := &m.data.inbox[idx]
+		if item.Subject != subject || item.EventKey != key {
+			continue
+		}
+
+		leaseOver := item.LockedUntil == nil || !item.LockedUntil.After(now)
+		if item.Status != InboxFailed && (item.Status != InboxProcessing || !leaseOver) {
+			found := *item
+			return &found, false, nil
+		}
+
+		item.Status = InboxProcessing
+		item.Attempts++
+		item.LockedUntil = ptr(lockedUntil)
+		item.UpdatedAt = now
+
+		found := *item
+		return &found, true, nil
+	}
+
+	item := InboxEvent{
+		Subject:     subject,
+		EventKey:    key,
+		Status:      InboxProcessing,
+		Attempts:    1,
+		LockedUntil: ptr(lockedUntil),
+	}
+	m.data.newModel("event_inbox", &item.Model, now)
+	m.data.inbox = append(m.data.inbox, item)
+
+	return &item, true, nil
+}
+
+func (m *MemoryStorage) MarkInboxEvent(_ context.Context, id uint, processErr error) error {
+	unlock := m.lock()
+	defer unlock()
+
+	for idx := range m.data.inbox {
+		item := &m.data.inbox[idx]
+		if item.DeletedAt.Valid || item.ID != id {
+			continue
+		}
+
+		now := m.timestamp()
+		item.UpdatedAt = now
+		item.LockedUntil = nil
+		if processErr != nil {
+			item.Status = InboxFailed
+			item.LastError = processErr.Error()
+			continue
+		}
+
+		item.Status = InboxProcessed
+		item.LastError = ""
+		item.ProcessedAt = ptr(now)
+	}
+
+	return nil
+}
+
+func (m *MemoryStorage) DeleteInboxEventsBefore(_ context.Context, before time.Time) (int64, error) {
+	unlock := m.lock()
+	defer unlock()
+
+	count := len(m.data.inbox)
+	m.data.inbox = slices.DeleteFunc(m.data.inbox, func(item InboxEvent) bool {
+		return item.UpdatedAt.Before(before)
+	})
+
+	return int64(count - len(m.data.inbox)), nil
+}
+
+func (m *MemoryStorage) CreateDeadLetter(_ context.Context, item *DeadLetter) error {
+	unlock := m.lock()
+	defer unlock()
+
+	m.data.newModel("dead_letters", &item.Model, m.timestamp())
+	m.data.deadLetters = append(m.data.deadLetters, *item)
+
+	return nil
+}
+
+func (m *MemoryStorage) GetDeadLetter(_ context.Context, id uint) (*DeadLetter, error) {
+	unlock := m.lock()
+	defer unlock()
+
+	for _, item := range m.data.deadLetters {
+		if !item.DeletedAt.Valid && item.ID == id {
+			return &item, nil
+		}
+	}
+
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *MemoryStorage) ListDeadLetters(_ context.Context, subject string, limit int) ([]DeadLetter, error) {
+	unlock := m.lock()
+	defer unlock()
+
+	var list []DeadLetter
+	for _, item := range slices.Backward(m.data.deadLetters) {
+		if !item.DeletedAt.Valid && (subject == "" || item.Subject == subject) {
+			list = append(list, item)
+		}
+	}
+
+	return limitRows(list, limit), nil
+}
+
+func (m *MemoryStorage) MarkDeadLetterReplayed(_ context.Context, id uint) error {
+	unlock := m.lock()
+	defer unlock()
+
+	for idx := range m.data.deadLetters {
+		item := &m.data.deadLetters[idx]
+		if !item.DeletedAt.Valid && item.ID == id {
+			now := m.timestamp()
+			item.ReplayedAt = ptr(now)
+			item.UpdatedAt = now
+		}
+	}
+
+	return nil
+}
+
+func (m *MemoryStorage) CreateOutboxMessages(_ context.Context, list []OutboxMessage) error {
+	unlock := m.lock()
+	defer unlock()
+
+	now := m.timestamp()
+	for idx := range list {
+		m.data.newModel("outbox", &list[idx].Model, now)
+		m.data.outbox = append(m.data.outbox, list[idx])
+	}
+
+	return nil
+}
+
+func (m *MemoryStorage) PublishOutbox(_ context.Context, limit int, publish func(OutboxMessage) error) (int, error) {
+	claimed := m.claimOutbox(limit)
+
+	published := 0
+	for idx, msg := range claimed {
+		if err := publish(msg); err != nil {
+			// keep the order: the rest of messages will be published on the next run
+			m.finishOutbox(claimed[:idx], claimed[idx:], err)
+
+			return published, nil
+		}
+
+		published++
+	}
+
+	m.finishOutbox(claimed, nil, nil)
+
+	return published, nil
+}
+
+// claimOutbox claims pending messages, they are published without holding the lock the same way
+// as postgres publishes them without holding row locks
+func (m *MemoryStorage) claimOutbox(limit int) []OutboxMessage {
+	unlock := m.lock()
+	defer unlock()
+
+	now := m.timestamp()
+	claimed := make([]OutboxMessage, 0, limit)
+	for idx := range m.data.outbox {
+		msg := &m.data.outbox[idx]
+		if msg.DeletedAt.Valid || msg.PublishedAt != nil || msg.FailedAt != nil || msg.LockedUntil != nil && msg.LockedUntil.After(now) {
+			continue
+		}
+		if len(claimed) == limit {
+			break
+		}
+
+		msg.LockedUntil = ptr(now.Add(outboxClaimLease))
+		msg.UpdatedAt = now
+		claimed = append(claimed, *msg)
+	}
+
+	return claimed
+}
+
+func (m *MemoryStorage) finishOutbox(published, rest []OutboxMessage, publishErr error) {
+	unlock := m.lock()
+	defer unlock()
+
+	now := m.timestamp()
+	for idx := range m.data.outbox {
+		msg := &m.data.outbox[idx]
+
+		switch {
+		case slices.ContainsFunc(published, func(item OutboxMessage) bool { return item.ID == msg.ID }):
+			msg.PublishedAt = ptr(now)
+		case len(rest) > 0 && rest[0].ID == msg.ID:
+			msg.Attempts++
+			msg.LastError = publishErr.Error()
+			if msg.Attempts >= outboxMaxAttempts {
+				msg.FailedAt = ptr(now)
+			}
+		case slices.ContainsFunc(rest, func(item OutboxMessage) bool { return item.ID == msg.ID }):
+		default:
+			continue
+		}
+
+		msg.LockedUntil = nil
+		msg.UpdatedAt = now
+	}
+}
+
+func (m *MemoryStorage) DeleteOutboxPublishedBefore(_ context.Context, before time.Time) error {
+	unlock := m.lock()
+	defer unlock()
+
+	m.data.outbox = slices.DeleteFunc(m.data.outbox, func(msg OutboxMessage) bool {
+		return msg.PublishedAt != nil && msg.PublishedAt.Before(before)
+	})
+
+	return nil
+}
+
+func (m *MemoryStorage) ReserveDedupKey(_ context.Context, key string, expiresAt *time.Time) (bool, error) {
+	unlock := m.lock()
+	defer unlock()
+
+	now := m.timestamp()
+	for idx := range m.data.dedup {
+		item := &m.data.dedup[idx]
+		if item.Key != key {
+			continue
+		}
+
+		// the key is taken over only after it is expired
+		if item.ExpiresAt == nil || item.ExpiresAt.After(now) {
+			return false, nil
+		}
+
+		item.CreatedAt = now
+		item.UpdatedAt = now
+		item.ExpiresAt = expiresAt
+
+		return true, nil
+	}
+
+	item := PushDedup{Key: key, ExpiresAt: expiresAt}
+	m.data.newModel("push_dedup", &item.Model, now)
+	m.data.dedup = append(m.data.dedup, item)
+
+	return true, nil
+}
+
+func (m *MemoryStorage) ReleaseDedupKey(_ context.Context, key string) error {
+	unlock := m.lock()
+	defer unlock()
+
+	m.data.dedup = slices.DeleteFunc(m.data.dedup, func(item PushDedup) bool {
+		return item.Key == key
+	})
+
+	return nil
+}
+
+func (m *MemoryStorage) DeleteExpiredDedupKeys(_ context.Context, now time.Time) (int64, error) {
+	unlock := m.lock()
+	defer unlock()
+
+	before := len(m.data.dedup)
+	m.data.dedup = slices.DeleteFunc(m.data.dedup, func(item PushDedup) bool {
+		return item.ExpiresAt != nil && !item.ExpiresAt.After(now)
+	})
+
+	return int64(before - len(m.data.dedup)), nil
+}
+
+func (m *MemoryStorage) CreateBroadcast(_ context.Context, item *Broadcast) error {
+	unlock := m.lock()
+	defer unlock()
+
+	m.data.newModel("broadcasts", &item.Model, m.timestamp())
+	m.data.broadcasts = append(m.data.broadcasts, *item)
+
+	return nil
+}
+
+func (m *MemoryStorage) GetBroadcast(_ context.Context, id uint) (*Broadcast, error) {
+	unlock := m.lock()
+	defer unlock()
+
+	for _, item := range m.data.broadcasts {
+		if !item.DeletedAt.Valid && item.ID == id {
+			return &item, nil
+		}
+	}
+
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *MemoryStorage) ListBroadcasts(_ context.Context, limit int) ([]Broadcast, error) {
+	unlock := m.lock()
+	defer unlock()
+
+	var list []Broadcast
+	for _, item := range slices.Backward(m.data.broadcasts) {
+		if !item.DeletedAt.Valid {
+			list = append(list, item)
+		}
+	}
+
+	return limitRows(list, limit), nil
+}
+
+func (m *MemoryStorage) UpdateBroadcast(_ context.Context, id uint, in []BroadcastStatus, updates map[string]any) (bool, error) {
+	unlock := m.lock()
+	defer unlock()
+
+	for idx := range m.data.broadcasts {
+		item := m.data.broadcasts[idx]
+		if item.DeletedAt.Valid || item.ID != id || !slices.Contains(in, item.Status) {
+			continue
+		}
+
+		// the row is not changed if any of updates is not applied
+		if err := applyUpdates(&item, updates); err != nil {
+			return false, err
+		}
+		item.UpdatedAt = m.timestamp()
+		m.data.broadcasts[idx] = item
+
+		return true, nil
+	}
+
+	return false, nil
+}
+
+func (m *MemoryStorage) ClaimDueBroadcast(_ context.Context, now, staleBefore time.Time) (*Broadcast, error) {
+	unlock := m.lock()
+	defer unlock()
+
+	var due *Broadcast
+	for idx := range m.data.broadcasts {
+		item := &m.data.broadcasts[idx]
+		if item.DeletedAt.Valid || item.ScheduledAt == nil {
+			continue
+		}
+
+		scheduled := item.Status == BroadcastScheduled && !item.ScheduledAt.After(now)
+		stale := item.Status == BroadcastSending && !item.UpdatedAt.After(staleBefore)
+		if !scheduled && !stale {
+			continue
+		}
+
+		if due == nil || item.ScheduledAt.Before(*due.ScheduledAt) {
+			due = item
+		}
+	}
+	if due == nil {
+		return nil, nil
+	}
+
+	due.Status = BroadcastSending
+	due.StartedAt = ptr(now)
+	due.UpdatedAt = now
+
+	claimed := *due
+	return &claimed, nil
+}
+
+func (m *MemoryStorage) CreateAuditLog(_ context.Context, item *AuditLog) error {
+	unlock := m.lock()
+	defer unlock()
+
+	m.data.newModel("audit_log", &item.Model, m.timestamp())
+	m.data.auditLog = append(m.data.auditLog, *item)
+
+	return nil
+}
+
+func (m *MemoryStorage) ListAuditLog(_ context.Context, action string, limit int) ([]AuditLog, error) {
+	unlock := m.lock()
+	defer unlock()
+
+	var list []AuditLog
+	for _, item := range slices.Backward(m.data.auditLog) {
+		if !item.DeletedAt.Valid && (action == "" || item.Action == action) {
+			list = append(list, item)
+		}
+	}
+
+	return limitRows(list, limit), nil
+}
+
+// applyUpdates sets fields of the row by column names the same way as gorm Updates with the map
+func applyUpdates(row any, updates map[string]any) error {
+	value := reflect.ValueOf(row).Elem()
+	naming := schema.NamingStrategy{}
+
+	fields := make(map[string]reflect.Value)
+	for _, field := range reflect.VisibleFields(value.Type()) {
+		if !field.Anonymous {
+			fields[naming.ColumnName("", field.Name)] = value.FieldByIndex(field.Index)
+		}
+	}
+
+	for column, update := range updates {
+		field, ok := fields[column]
+		if !ok {
+			return fmt.Errorf("unknown column: %s", column)
+		}
+
+		if update == nil {
+			field.SetZero()
+			continue
+		}
+
+		src := reflect.ValueOf(update)
+		switch {
+		case src.Type().ConvertibleTo(field.Type()):
+			field.Set(src.Convert(field.Type()))
+		case field.Kind() == reflect.Pointer && src.Type().ConvertibleTo(field.Type().Elem()):
+			ptrValue := reflect.New(field.Type().Elem())
+			ptrValue.Elem().Set(src.Convert(field.Type().Elem()))
+			field.Set(ptrValue)
+		default:
+			return fmt.Errorf("column %s: can't set %T", column, update)
+		}
+	}
+
+	return nil
+}
+
+// limitRows limits the list the same way as the sql limit does, the negative limit is ignored
+func limitRows[T any](list []T, limit int) []T {
+	if limit >= 0 && len(list) > limit {
+		return list[:limit]
+	}
+
+	return list
+}
+
+func ptr[T any](value T) *T {
+	return &value
+}
+
+func (q SendQueue) filterRow() filterRow {
+	return filterRow{
+		ID:            q.ID,
+		UserID:        q.UserID.String(),
+		DaoID:         q.DaoID.String(),
+		ProposalID:    q.ProposalID,
+		Action:        string(q.Action),
+		CreatedAt:     q.CreatedAt,
+		SentAt:        q.SentAt,
+		CorrelationID: q.CorrelationID,
+	}
+}
+
+func (h History) filterRow() filterRow {
+	row := filterRow{
+		ID:            h.ID,
+		UserID:        h.UserID.String(),
+		Action:        string(h.Action),
+		CreatedAt:     h.CreatedAt,
+		TemplateID:    int(h.Message.TemplateID),
+		CorrelationID: h.CorrelationID,
+	}
+	if h.DaoID != nil {
+		row.DaoID = h.DaoID.String()
+	}
+
+	return row
+}
+
+func (f FanoutLog) filterRow() filterRow {
+	return filterRow{
+		ID:         f.ID,
+		UserID:     f.UserID.String(),
+		DaoID:      f.DaoID.String(),
+		ProposalID: f.ProposalID,
+		Action:     string(f.Action),
+		CreatedAt:  f.CreatedAt,
+	}
+}
@@ -1,6 +1,10 @@
 package sender
 
 import (
+	"slices"
+	"strconv"
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 
@@ -26,8 +30,159 @@ var metricPushCounter = promauto.NewCounterVec(
 	}, []string{"subject", "method", "error"},
 )
 
+const (
+	funnelStageSent      = "sent"
+	funnelStageDelivered = "delivered"
+	funnelStageOpened    = "opened"
+	funnelStageDismissed = "dismissed"
+)
+
+var metricFunnelCounter = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "sender",
+		Name:      "funnel_total",
+		Help:      "Push funnel: sent, delivered, opened or dismissed pushes",
+	}, []string{"stage"},
+)
+
 func collectStats(subject, method string, err error) {
 	metricPushCounter.
 		WithLabelValues(subject, method, metrics.ErrLabelValue(err)).
 		Inc()
 }
+
+var metricClickStatsSends = promauto.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "analytics",
+		Name:      "sends",
+		Help:      "Number of sent pushes for the last 7 days",
+	}, []string{"group", "key"},
+)
+
+var metricClickStatsCTR = promauto.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "analytics",
+		Name:      "ctr",
+		Help:      "Click-through rate of pushes for the last 7 days",
+	}, []string{"group", "key"},
+)
+
+var metricClickStatsTimeToClick = promauto.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "analytics",
+		Name:      "time_to_click_seconds",
+		Help:      "Time to click percentiles of pushes for the last 7 days",
+	}, []string{"group", "key", "quantile"},
+)
+
+var metricQueuePending = promauto.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "queue",
+		Name:      "pending",
+		Help:      "Number of unsent send queue items",
+	}, []string{"action"},
+)
+
+var metricQueueOldestPendingAge = promauto.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "queue",
+		Name:      "oldest_pending_age_seconds",
+		Help:      "Age of the oldest unsent send queue item",
+	}, []string{"action"},
+)
+
+var metricSendDelayHistogram = promauto.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "queue",
+		Name:      "send_delay_seconds",
+		Help:      "Time from adding the item to the queue to sending the push",
+		Buckets:   []float64{1, 10, 30, 60, 300, 600, 1800, 3600, 4 * 3600, 12 * 3600, 24 * 3600},
+	}, []string{"action", "template"},
+)
+
+var metricWorkerRunHistogram = promauto.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "worker",
+		Name:      "run_duration_seconds",
+		Help:      "Duration of a single worker run",
+		Buckets:   []float64{.01, .05, .1, .5, 1, 5, 10, 30, 60, 300},
+	}, []string{"worker", "error"},
+)
+
+var metricWorkerLastSuccess = promauto.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "worker",
+		Name:      "last_success_timestamp_seconds",
+		Help:      "Unix timestamp of the last successful worker run",
+	}, []string{"worker"},
+)
+
+var metricFirebaseErrors = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "firebase",
+		Name:      "errors_total",
+		Help:      "Firebase send errors by class",
+	}, []string{"class"},
+)
+
+var metricFirebaseSends = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "firebase",
+		Name:      "sends_total",
+		Help:      "Firebase sends by project and result: ok or error class",
+	}, []string{"project", "result"},
+)
+
+var metricFanoutSize = promauto.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "fanout",
+		Name:      "subscribers",
+		Help:      "Number of DAO subscribers per feed event",
+		Buckets:   prometheus.ExponentialBuckets(1, 4, 9),
+	}, []string{"action"},
+)
+
+// observeWorkerRun collects the run duration and the time of the last successful run
+func observeWorkerRun(worker string, start time.Time, err error) {
+	metricWorkerRunHistogram.
+		WithLabelValues(worker, metrics.ErrLabelValue(err)).
+		Observe(time.Since(start).Seconds())
+
+	if err == nil {
+		metricWorkerLastSuccess.WithLabelValues(worker).SetToCurrentTime()
+		workerRuns.success(worker, time.Now())
+	}
+}
+
+// observeSendDelay collects the time the items have been waiting in the queue before sending
+func observeSendDelay(items []SendQueue, template templateID) {
+	label := strconv.Itoa(int(template))
+	for _, info := range items {
+		metricSendDelayHistogram.
+			WithLabelValues(actionLabel(info.Action), label).
+			Observe(time.Since(info.CreatedAt).Seconds())
+	}
+}
+
+const otherActionLabel = "other"
+
+// actionLabel keeps label values bounded by known actions
+func actionLabel(action Action) string {
+	if slices.Contains(knownActions, action) {
+		return string(action)
+	}
+
+	return otherActionLabel
+}
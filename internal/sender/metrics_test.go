@@ -0,0 +1,41 @@
+package sender
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActionLabel(t *testing.T) {
+	require.Equal(t, string(ProposalCreated), actionLabel(ProposalCreated))
+	require.Equal(t, "other", actionLabel("unknown.action"))
+	require.Equal(t, "other", actionLabel(""))
+}
+
+func TestSetQueueMetrics(t *testing.T) {
+	now := time.Now()
+	setQueueMetrics([]PendingQueueStats{
+		{Action: ProposalCreated, Count: 5, OldestCreatedAt: now.Add(-time.Minute)},
+		{Action: "unknown.action", Count: 2, OldestCreatedAt: now.Add(-time.Hour)},
+	}, now)
+
+	require.Equal(t, float64(5), testutil.ToFloat64(metricQueuePending.WithLabelValues(string(ProposalCreated))))
+	require.Equal(t, float64(60), testutil.ToFloat64(metricQueueOldestPendingAge.WithLabelValues(string(ProposalCreated))))
+	require.Equal(t, float64(2), testutil.ToFloat64(metricQueuePending.WithLabelValues("other")))
+
+	// drained queue is reported as zero
+	setQueueMetrics(nil, now)
+	require.Equal(t, float64(0), testutil.ToFloat64(metricQueuePending.WithLabelValues(string(ProposalCreated))))
+	require.Equal(t, float64(0), testutil.ToFloat64(metricQueueOldestPendingAge.WithLabelValues(string(ProposalCreated))))
+}
+
+func TestObserveWorkerRun(t *testing.T) {
+	observeWorkerRun("test-worker", time.Now(), errors.New("failed"))
+	require.Equal(t, float64(0), testutil.ToFloat64(metricWorkerLastSuccess.WithLabelValues("test-worker")))
+
+	observeWorkerRun("test-worker", time.Now(), nil)
+	require.InDelta(t, float64(time.Now().Unix()), testutil.ToFloat64(metricWorkerLastSuccess.WithLabelValues("test-worker")), 2)
+}
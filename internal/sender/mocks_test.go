@@ -1,5 +1,5 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/goverland-labs/goverland-inbox-push/internal/sender (interfaces: UsersFinder,SettingsProvider,CoreDataProvider,DataManipulator,MessageSender,PushManipulator)
+// Source: github.com/goverland-labs/goverland-inbox-push/internal/sender (interfaces: SubscriptionsFinder,UsersFinder,SettingsProvider,CoreDataProvider,Storage,MessageSender,PushManipulator,DeadLetterSink,FeedReplayer)
 
 // Package sender is a generated GoMock package.
 package sender
@@ -7,17 +7,81 @@ package sender
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	messaging "firebase.google.com/go/v4/messaging"
 	gomock "github.com/golang/mock/gomock"
 	uuid "github.com/google/uuid"
-	core_web_sdk "github.com/goverland-labs/goverland-core-sdk-go"
+	goverland_core_sdk_go "github.com/goverland-labs/goverland-core-sdk-go"
 	dao "github.com/goverland-labs/goverland-core-sdk-go/dao"
 	proposal "github.com/goverland-labs/goverland-core-sdk-go/proposal"
 	inboxapi "github.com/goverland-labs/goverland-inbox-api-protocol/protobuf/inboxapi"
 	grpc "google.golang.org/grpc"
 )
 
+// MockSubscriptionsFinder is a mock of SubscriptionsFinder interface.
+type MockSubscriptionsFinder struct {
+	ctrl     *gomock.Controller
+	recorder *MockSubscriptionsFinderMockRecorder
+}
+
+// MockSubscriptionsFinderMockRecorder is the mock recorder for MockSubscriptionsFinder.
+type MockSubscriptionsFinderMockRecorder struct {
+	mock *MockSubscriptionsFinder
+}
+
+// NewMockSubscriptionsFinder creates a new mock instance.
+func NewMockSubscriptionsFinder(ctrl *gomock.Controller) *MockSubscriptionsFinder {
+	mock := &MockSubscriptionsFinder{ctrl: ctrl}
+	mock.recorder = &MockSubscriptionsFinderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSubscriptionsFinder) EXPECT() *MockSubscriptionsFinderMockRecorder {
+	return m.recorder
+}
+
+// FindSubscribers mocks base method.
+func (m *MockSubscriptionsFinder) FindSubscribers(arg0 context.Context, arg1 *inboxapi.FindSubscribersRequest, arg2 ...grpc.CallOption) (*inboxapi.UserList, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "FindSubscribers", varargs...)
+	ret0, _ := ret[0].(*inboxapi.UserList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindSubscribers indicates an expected call of FindSubscribers.
+func (mr *MockSubscriptionsFinderMockRecorder) FindSubscribers(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindSubscribers", reflect.TypeOf((*MockSubscriptionsFinder)(nil).FindSubscribers), varargs...)
+}
+
+// ListSubscriptions mocks base method.
+func (m *MockSubscriptionsFinder) ListSubscriptions(arg0 context.Context, arg1 *inboxapi.ListSubscriptionRequest, arg2 ...grpc.CallOption) (*inboxapi.ListSubscriptionResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ListSubscriptions", varargs...)
+	ret0, _ := ret[0].(*inboxapi.ListSubscriptionResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSubscriptions indicates an expected call of ListSubscriptions.
+func (mr *MockSubscriptionsFinderMockRecorder) ListSubscriptions(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSubscriptions", reflect.TypeOf((*MockSubscriptionsFinder)(nil).ListSubscriptions), varargs...)
+}
+
 // MockUsersFinder is a mock of UsersFinder interface.
 type MockUsersFinder struct {
 	ctrl     *gomock.Controller
@@ -188,7 +252,7 @@ func (m *MockCoreDataProvider) EXPECT() *MockCoreDataProviderMockRecorder {
 }
 
 // GetDao mocks base method.
-func (m *MockCoreDataProvider) GetDao(arg0 context.Context, arg1 uuid.UUID) (*dao.Dao, error) {
+func (m *MockCoreDataProvider) GetDao(arg0 context.Context, arg1 string) (*dao.Dao, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetDao", arg0, arg1)
 	ret0, _ := ret[0].(*dao.Dao)
@@ -218,7 +282,7 @@ func (mr *MockCoreDataProviderMockRecorder) GetProposal(arg0, arg1 interface{})
 }
 
 // GetUserVotes mocks base method.
-func (m *MockCoreDataProvider) GetUserVotes(arg0 context.Context, arg1 string, arg2 core_web_sdk.GetUserVotesRequest) (*proposal.VoteList, error) {
+func (m *MockCoreDataProvider) GetUserVotes(arg0 context.Context, arg1 string, arg2 goverland_core_sdk_go.GetUserVotesRequest) (*proposal.VoteList, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetUserVotes", arg0, arg1, arg2)
 	ret0, _ := ret[0].(*proposal.VoteList)
@@ -232,88 +296,425 @@ func (mr *MockCoreDataProviderMockRecorder) GetUserVotes(arg0, arg1, arg2 interf
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserVotes", reflect.TypeOf((*MockCoreDataProvider)(nil).GetUserVotes), arg0, arg1, arg2)
 }
 
-// MockDataManipulator is a mock of DataManipulator interface.
-type MockDataManipulator struct {
+// MockStorage is a mock of Storage interface.
+type MockStorage struct {
 	ctrl     *gomock.Controller
-	recorder *MockDataManipulatorMockRecorder
+	recorder *MockStorageMockRecorder
 }
 
-// MockDataManipulatorMockRecorder is the mock recorder for MockDataManipulator.
-type MockDataManipulatorMockRecorder struct {
-	mock *MockDataManipulator
+// MockStorageMockRecorder is the mock recorder for MockStorage.
+type MockStorageMockRecorder struct {
+	mock *MockStorage
 }
 
-// NewMockDataManipulator creates a new mock instance.
-func NewMockDataManipulator(ctrl *gomock.Controller) *MockDataManipulator {
-	mock := &MockDataManipulator{ctrl: ctrl}
-	mock.recorder = &MockDataManipulatorMockRecorder{mock}
+// NewMockStorage creates a new mock instance.
+func NewMockStorage(ctrl *gomock.Controller) *MockStorage {
+	mock := &MockStorage{ctrl: ctrl}
+	mock.recorder = &MockStorageMockRecorder{mock}
 	return mock
 }
 
 // EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MockDataManipulator) EXPECT() *MockDataManipulatorMockRecorder {
+func (m *MockStorage) EXPECT() *MockStorageMockRecorder {
 	return m.recorder
 }
 
+// CancelQueueItems mocks base method.
+func (m *MockStorage) CancelQueueItems(arg0 context.Context, arg1 []uint) ([]uint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelQueueItems", arg0, arg1)
+	ret0, _ := ret[0].([]uint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelQueueItems indicates an expected call of CancelQueueItems.
+func (mr *MockStorageMockRecorder) CancelQueueItems(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelQueueItems", reflect.TypeOf((*MockStorage)(nil).CancelQueueItems), arg0, arg1)
+}
+
+// ClaimDueBroadcast mocks base method.
+func (m *MockStorage) ClaimDueBroadcast(arg0 context.Context, arg1, arg2 time.Time) (*Broadcast, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimDueBroadcast", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*Broadcast)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimDueBroadcast indicates an expected call of ClaimDueBroadcast.
+func (mr *MockStorageMockRecorder) ClaimDueBroadcast(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimDueBroadcast", reflect.TypeOf((*MockStorage)(nil).ClaimDueBroadcast), arg0, arg1, arg2)
+}
+
+// ClaimInboxEvent mocks base method.
+func (m *MockStorage) ClaimInboxEvent(arg0 context.Context, arg1, arg2 string, arg3 time.Time) (*InboxEvent, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimInboxEvent", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*InboxEvent)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ClaimInboxEvent indicates an expected call of ClaimInboxEvent.
+func (mr *MockStorageMockRecorder) ClaimInboxEvent(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimInboxEvent", reflect.TypeOf((*MockStorage)(nil).ClaimInboxEvent), arg0, arg1, arg2, arg3)
+}
+
+// ClickStats mocks base method.
+func (m *MockStorage) ClickStats(arg0 context.Context, arg1 ClickStatsQuery) ([]ClickStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClickStats", arg0, arg1)
+	ret0, _ := ret[0].([]ClickStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClickStats indicates an expected call of ClickStats.
+func (mr *MockStorageMockRecorder) ClickStats(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClickStats", reflect.TypeOf((*MockStorage)(nil).ClickStats), arg0, arg1)
+}
+
 // Create mocks base method.
-func (m *MockDataManipulator) Create(arg0 *History) error {
+func (m *MockStorage) Create(arg0 context.Context, arg1 *History) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", arg0)
+	ret := m.ctrl.Call(m, "Create", arg0, arg1)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Create indicates an expected call of Create.
-func (mr *MockDataManipulatorMockRecorder) Create(arg0 interface{}) *gomock.Call {
+func (mr *MockStorageMockRecorder) Create(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockStorage)(nil).Create), arg0, arg1)
+}
+
+// CreateAuditLog mocks base method.
+func (m *MockStorage) CreateAuditLog(arg0 context.Context, arg1 *AuditLog) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAuditLog", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAuditLog indicates an expected call of CreateAuditLog.
+func (mr *MockStorageMockRecorder) CreateAuditLog(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAuditLog", reflect.TypeOf((*MockStorage)(nil).CreateAuditLog), arg0, arg1)
+}
+
+// CreateBroadcast mocks base method.
+func (m *MockStorage) CreateBroadcast(arg0 context.Context, arg1 *Broadcast) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateBroadcast", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateBroadcast indicates an expected call of CreateBroadcast.
+func (mr *MockStorageMockRecorder) CreateBroadcast(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBroadcast", reflect.TypeOf((*MockStorage)(nil).CreateBroadcast), arg0, arg1)
+}
+
+// CreateDeadLetter mocks base method.
+func (m *MockStorage) CreateDeadLetter(arg0 context.Context, arg1 *DeadLetter) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateDeadLetter", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateDeadLetter indicates an expected call of CreateDeadLetter.
+func (mr *MockStorageMockRecorder) CreateDeadLetter(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDeadLetter", reflect.TypeOf((*MockStorage)(nil).CreateDeadLetter), arg0, arg1)
+}
+
+// CreateFanoutLog mocks base method.
+func (m *MockStorage) CreateFanoutLog(arg0 context.Context, arg1 []FanoutLog) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateFanoutLog", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateFanoutLog indicates an expected call of CreateFanoutLog.
+func (mr *MockStorageMockRecorder) CreateFanoutLog(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFanoutLog", reflect.TypeOf((*MockStorage)(nil).CreateFanoutLog), arg0, arg1)
+}
+
+// CreateOutboxMessages mocks base method.
+func (m *MockStorage) CreateOutboxMessages(arg0 context.Context, arg1 []OutboxMessage) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOutboxMessages", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateOutboxMessages indicates an expected call of CreateOutboxMessages.
+func (mr *MockStorageMockRecorder) CreateOutboxMessages(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockDataManipulator)(nil).Create), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOutboxMessages", reflect.TypeOf((*MockStorage)(nil).CreateOutboxMessages), arg0, arg1)
 }
 
 // CreateSendQueueRequest mocks base method.
-func (m *MockDataManipulator) CreateSendQueueRequest(arg0 context.Context, arg1 *SendQueue) error {
+func (m *MockStorage) CreateSendQueueRequest(arg0 context.Context, arg1 *SendQueue) (bool, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CreateSendQueueRequest", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSendQueueRequest indicates an expected call of CreateSendQueueRequest.
+func (mr *MockStorageMockRecorder) CreateSendQueueRequest(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSendQueueRequest", reflect.TypeOf((*MockStorage)(nil).CreateSendQueueRequest), arg0, arg1)
+}
+
+// DeleteExpiredDedupKeys mocks base method.
+func (m *MockStorage) DeleteExpiredDedupKeys(arg0 context.Context, arg1 time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredDedupKeys", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpiredDedupKeys indicates an expected call of DeleteExpiredDedupKeys.
+func (mr *MockStorageMockRecorder) DeleteExpiredDedupKeys(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredDedupKeys", reflect.TypeOf((*MockStorage)(nil).DeleteExpiredDedupKeys), arg0, arg1)
+}
+
+// DeleteInboxEventsBefore mocks base method.
+func (m *MockStorage) DeleteInboxEventsBefore(arg0 context.Context, arg1 time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteInboxEventsBefore", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteInboxEventsBefore indicates an expected call of DeleteInboxEventsBefore.
+func (mr *MockStorageMockRecorder) DeleteInboxEventsBefore(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteInboxEventsBefore", reflect.TypeOf((*MockStorage)(nil).DeleteInboxEventsBefore), arg0, arg1)
+}
+
+// DeleteOutboxPublishedBefore mocks base method.
+func (m *MockStorage) DeleteOutboxPublishedBefore(arg0 context.Context, arg1 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteOutboxPublishedBefore", arg0, arg1)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// CreateSendQueueRequest indicates an expected call of CreateSendQueueRequest.
-func (mr *MockDataManipulatorMockRecorder) CreateSendQueueRequest(arg0, arg1 interface{}) *gomock.Call {
+// DeleteOutboxPublishedBefore indicates an expected call of DeleteOutboxPublishedBefore.
+func (mr *MockStorageMockRecorder) DeleteOutboxPublishedBefore(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOutboxPublishedBefore", reflect.TypeOf((*MockStorage)(nil).DeleteOutboxPublishedBefore), arg0, arg1)
+}
+
+// FanoutLogByFilters mocks base method.
+func (m *MockStorage) FanoutLogByFilters(arg0 context.Context, arg1 []Filter) ([]FanoutLog, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FanoutLogByFilters", arg0, arg1)
+	ret0, _ := ret[0].([]FanoutLog)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FanoutLogByFilters indicates an expected call of FanoutLogByFilters.
+func (mr *MockStorageMockRecorder) FanoutLogByFilters(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FanoutLogByFilters", reflect.TypeOf((*MockStorage)(nil).FanoutLogByFilters), arg0, arg1)
+}
+
+// GetBroadcast mocks base method.
+func (m *MockStorage) GetBroadcast(arg0 context.Context, arg1 uint) (*Broadcast, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBroadcast", arg0, arg1)
+	ret0, _ := ret[0].(*Broadcast)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBroadcast indicates an expected call of GetBroadcast.
+func (mr *MockStorageMockRecorder) GetBroadcast(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSendQueueRequest", reflect.TypeOf((*MockDataManipulator)(nil).CreateSendQueueRequest), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBroadcast", reflect.TypeOf((*MockStorage)(nil).GetBroadcast), arg0, arg1)
 }
 
 // GetByHash mocks base method.
-func (m *MockDataManipulator) GetByHash(arg0 string) (*History, error) {
+func (m *MockStorage) GetByHash(arg0 context.Context, arg1 string) (*History, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetByHash", arg0)
+	ret := m.ctrl.Call(m, "GetByHash", arg0, arg1)
 	ret0, _ := ret[0].(*History)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetByHash indicates an expected call of GetByHash.
-func (mr *MockDataManipulatorMockRecorder) GetByHash(arg0 interface{}) *gomock.Call {
+func (mr *MockStorageMockRecorder) GetByHash(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByHash", reflect.TypeOf((*MockStorage)(nil).GetByHash), arg0, arg1)
+}
+
+// GetDeadLetter mocks base method.
+func (m *MockStorage) GetDeadLetter(arg0 context.Context, arg1 uint) (*DeadLetter, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeadLetter", arg0, arg1)
+	ret0, _ := ret[0].(*DeadLetter)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeadLetter indicates an expected call of GetDeadLetter.
+func (mr *MockStorageMockRecorder) GetDeadLetter(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeadLetter", reflect.TypeOf((*MockStorage)(nil).GetDeadLetter), arg0, arg1)
+}
+
+// HistoriesInBatches mocks base method.
+func (m *MockStorage) HistoriesInBatches(arg0 context.Context, arg1 []Filter, arg2 int, arg3 func([]History) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HistoriesInBatches", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// HistoriesInBatches indicates an expected call of HistoriesInBatches.
+func (mr *MockStorageMockRecorder) HistoriesInBatches(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HistoriesInBatches", reflect.TypeOf((*MockStorage)(nil).HistoriesInBatches), arg0, arg1, arg2, arg3)
+}
+
+// KnownUserIDs mocks base method.
+func (m *MockStorage) KnownUserIDs(arg0 context.Context) ([]uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "KnownUserIDs", arg0)
+	ret0, _ := ret[0].([]uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// KnownUserIDs indicates an expected call of KnownUserIDs.
+func (mr *MockStorageMockRecorder) KnownUserIDs(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "KnownUserIDs", reflect.TypeOf((*MockStorage)(nil).KnownUserIDs), arg0)
+}
+
+// ListAuditLog mocks base method.
+func (m *MockStorage) ListAuditLog(arg0 context.Context, arg1 string, arg2 int) ([]AuditLog, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAuditLog", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]AuditLog)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAuditLog indicates an expected call of ListAuditLog.
+func (mr *MockStorageMockRecorder) ListAuditLog(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAuditLog", reflect.TypeOf((*MockStorage)(nil).ListAuditLog), arg0, arg1, arg2)
+}
+
+// ListBroadcasts mocks base method.
+func (m *MockStorage) ListBroadcasts(arg0 context.Context, arg1 int) ([]Broadcast, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBroadcasts", arg0, arg1)
+	ret0, _ := ret[0].([]Broadcast)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBroadcasts indicates an expected call of ListBroadcasts.
+func (mr *MockStorageMockRecorder) ListBroadcasts(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByHash", reflect.TypeOf((*MockDataManipulator)(nil).GetByHash), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBroadcasts", reflect.TypeOf((*MockStorage)(nil).ListBroadcasts), arg0, arg1)
+}
+
+// ListDeadLetters mocks base method.
+func (m *MockStorage) ListDeadLetters(arg0 context.Context, arg1 string, arg2 int) ([]DeadLetter, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDeadLetters", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]DeadLetter)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDeadLetters indicates an expected call of ListDeadLetters.
+func (mr *MockStorageMockRecorder) ListDeadLetters(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDeadLetters", reflect.TypeOf((*MockStorage)(nil).ListDeadLetters), arg0, arg1, arg2)
 }
 
 // MarkAsClicked mocks base method.
-func (m *MockDataManipulator) MarkAsClicked(arg0 uuid.UUID) error {
+func (m *MockStorage) MarkAsClicked(arg0 context.Context, arg1 uuid.UUID) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "MarkAsClicked", arg0)
+	ret := m.ctrl.Call(m, "MarkAsClicked", arg0, arg1)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // MarkAsClicked indicates an expected call of MarkAsClicked.
-func (mr *MockDataManipulatorMockRecorder) MarkAsClicked(arg0 interface{}) *gomock.Call {
+func (mr *MockStorageMockRecorder) MarkAsClicked(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkAsClicked", reflect.TypeOf((*MockStorage)(nil).MarkAsClicked), arg0, arg1)
+}
+
+// MarkAsDelivered mocks base method.
+func (m *MockStorage) MarkAsDelivered(arg0 context.Context, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkAsDelivered", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkAsDelivered indicates an expected call of MarkAsDelivered.
+func (mr *MockStorageMockRecorder) MarkAsDelivered(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkAsDelivered", reflect.TypeOf((*MockStorage)(nil).MarkAsDelivered), arg0, arg1)
+}
+
+// MarkAsDismissed mocks base method.
+func (m *MockStorage) MarkAsDismissed(arg0 context.Context, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkAsDismissed", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkAsDismissed indicates an expected call of MarkAsDismissed.
+func (mr *MockStorageMockRecorder) MarkAsDismissed(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkAsDismissed", reflect.TypeOf((*MockStorage)(nil).MarkAsDismissed), arg0, arg1)
+}
+
+// MarkAsRead mocks base method.
+func (m *MockStorage) MarkAsRead(arg0 context.Context, arg1 uuid.UUID, arg2 []uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkAsRead", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkAsRead indicates an expected call of MarkAsRead.
+func (mr *MockStorageMockRecorder) MarkAsRead(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkAsClicked", reflect.TypeOf((*MockDataManipulator)(nil).MarkAsClicked), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkAsRead", reflect.TypeOf((*MockStorage)(nil).MarkAsRead), arg0, arg1, arg2)
 }
 
 // MarkAsSent mocks base method.
-func (m *MockDataManipulator) MarkAsSent(arg0 context.Context, arg1 []uint) error {
+func (m *MockStorage) MarkAsSent(arg0 context.Context, arg1 []uint) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "MarkAsSent", arg0, arg1)
 	ret0, _ := ret[0].(error)
@@ -321,13 +722,86 @@ func (m *MockDataManipulator) MarkAsSent(arg0 context.Context, arg1 []uint) erro
 }
 
 // MarkAsSent indicates an expected call of MarkAsSent.
-func (mr *MockDataManipulatorMockRecorder) MarkAsSent(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockStorageMockRecorder) MarkAsSent(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkAsSent", reflect.TypeOf((*MockStorage)(nil).MarkAsSent), arg0, arg1)
+}
+
+// MarkDeadLetterReplayed mocks base method.
+func (m *MockStorage) MarkDeadLetterReplayed(arg0 context.Context, arg1 uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkDeadLetterReplayed", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkDeadLetterReplayed indicates an expected call of MarkDeadLetterReplayed.
+func (mr *MockStorageMockRecorder) MarkDeadLetterReplayed(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkDeadLetterReplayed", reflect.TypeOf((*MockStorage)(nil).MarkDeadLetterReplayed), arg0, arg1)
+}
+
+// MarkInboxEvent mocks base method.
+func (m *MockStorage) MarkInboxEvent(arg0 context.Context, arg1 uint, arg2 error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkInboxEvent", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkInboxEvent indicates an expected call of MarkInboxEvent.
+func (mr *MockStorageMockRecorder) MarkInboxEvent(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkInboxEvent", reflect.TypeOf((*MockStorage)(nil).MarkInboxEvent), arg0, arg1, arg2)
+}
+
+// Notifications mocks base method.
+func (m *MockStorage) Notifications(arg0 context.Context, arg1 uuid.UUID, arg2 *NotificationCursor, arg3 int) ([]History, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Notifications", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]History)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Notifications indicates an expected call of Notifications.
+func (mr *MockStorageMockRecorder) Notifications(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Notifications", reflect.TypeOf((*MockStorage)(nil).Notifications), arg0, arg1, arg2, arg3)
+}
+
+// PendingQueueStats mocks base method.
+func (m *MockStorage) PendingQueueStats(arg0 context.Context) ([]PendingQueueStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PendingQueueStats", arg0)
+	ret0, _ := ret[0].([]PendingQueueStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PendingQueueStats indicates an expected call of PendingQueueStats.
+func (mr *MockStorageMockRecorder) PendingQueueStats(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PendingQueueStats", reflect.TypeOf((*MockStorage)(nil).PendingQueueStats), arg0)
+}
+
+// PublishOutbox mocks base method.
+func (m *MockStorage) PublishOutbox(arg0 context.Context, arg1 int, arg2 func(OutboxMessage) error) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishOutbox", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PublishOutbox indicates an expected call of PublishOutbox.
+func (mr *MockStorageMockRecorder) PublishOutbox(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkAsSent", reflect.TypeOf((*MockDataManipulator)(nil).MarkAsSent), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishOutbox", reflect.TypeOf((*MockStorage)(nil).PublishOutbox), arg0, arg1, arg2)
 }
 
 // QueueByFilters mocks base method.
-func (m *MockDataManipulator) QueueByFilters(arg0 context.Context, arg1 []Filter) ([]SendQueue, error) {
+func (m *MockStorage) QueueByFilters(arg0 context.Context, arg1 []Filter) ([]SendQueue, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "QueueByFilters", arg0, arg1)
 	ret0, _ := ret[0].([]SendQueue)
@@ -336,9 +810,112 @@ func (m *MockDataManipulator) QueueByFilters(arg0 context.Context, arg1 []Filter
 }
 
 // QueueByFilters indicates an expected call of QueueByFilters.
-func (mr *MockDataManipulatorMockRecorder) QueueByFilters(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockStorageMockRecorder) QueueByFilters(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueueByFilters", reflect.TypeOf((*MockStorage)(nil).QueueByFilters), arg0, arg1)
+}
+
+// QueueItems mocks base method.
+func (m *MockStorage) QueueItems(arg0 context.Context, arg1 []Filter, arg2 int) ([]SendQueue, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QueueItems", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]SendQueue)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueueItems indicates an expected call of QueueItems.
+func (mr *MockStorageMockRecorder) QueueItems(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueueItems", reflect.TypeOf((*MockStorage)(nil).QueueItems), arg0, arg1, arg2)
+}
+
+// ReleaseDedupKey mocks base method.
+func (m *MockStorage) ReleaseDedupKey(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseDedupKey", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseDedupKey indicates an expected call of ReleaseDedupKey.
+func (mr *MockStorageMockRecorder) ReleaseDedupKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseDedupKey", reflect.TypeOf((*MockStorage)(nil).ReleaseDedupKey), arg0, arg1)
+}
+
+// RequeueQueueItems mocks base method.
+func (m *MockStorage) RequeueQueueItems(arg0 context.Context, arg1 []uint) ([]uint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequeueQueueItems", arg0, arg1)
+	ret0, _ := ret[0].([]uint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RequeueQueueItems indicates an expected call of RequeueQueueItems.
+func (mr *MockStorageMockRecorder) RequeueQueueItems(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueueByFilters", reflect.TypeOf((*MockDataManipulator)(nil).QueueByFilters), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequeueQueueItems", reflect.TypeOf((*MockStorage)(nil).RequeueQueueItems), arg0, arg1)
+}
+
+// ReserveDedupKey mocks base method.
+func (m *MockStorage) ReserveDedupKey(arg0 context.Context, arg1 string, arg2 *time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReserveDedupKey", arg0, arg1, arg2)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReserveDedupKey indicates an expected call of ReserveDedupKey.
+func (mr *MockStorageMockRecorder) ReserveDedupKey(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReserveDedupKey", reflect.TypeOf((*MockStorage)(nil).ReserveDedupKey), arg0, arg1, arg2)
+}
+
+// Transaction mocks base method.
+func (m *MockStorage) Transaction(arg0 context.Context, arg1 func(Storage) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Transaction", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Transaction indicates an expected call of Transaction.
+func (mr *MockStorageMockRecorder) Transaction(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Transaction", reflect.TypeOf((*MockStorage)(nil).Transaction), arg0, arg1)
+}
+
+// UnreadNotificationsCount mocks base method.
+func (m *MockStorage) UnreadNotificationsCount(arg0 context.Context, arg1 uuid.UUID) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnreadNotificationsCount", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UnreadNotificationsCount indicates an expected call of UnreadNotificationsCount.
+func (mr *MockStorageMockRecorder) UnreadNotificationsCount(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnreadNotificationsCount", reflect.TypeOf((*MockStorage)(nil).UnreadNotificationsCount), arg0, arg1)
+}
+
+// UpdateBroadcast mocks base method.
+func (m *MockStorage) UpdateBroadcast(arg0 context.Context, arg1 uint, arg2 []BroadcastStatus, arg3 map[string]interface{}) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateBroadcast", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateBroadcast indicates an expected call of UpdateBroadcast.
+func (mr *MockStorageMockRecorder) UpdateBroadcast(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBroadcast", reflect.TypeOf((*MockStorage)(nil).UpdateBroadcast), arg0, arg1, arg2, arg3)
 }
 
 // MockMessageSender is a mock of MessageSender interface.
@@ -403,17 +980,59 @@ func (m *MockPushManipulator) EXPECT() *MockPushManipulatorMockRecorder {
 }
 
 // MarkAsClicked mocks base method.
-func (m *MockPushManipulator) MarkAsClicked(arg0 uuid.UUID) error {
+func (m *MockPushManipulator) MarkAsClicked(arg0 context.Context, arg1 uuid.UUID) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "MarkAsClicked", arg0)
+	ret := m.ctrl.Call(m, "MarkAsClicked", arg0, arg1)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // MarkAsClicked indicates an expected call of MarkAsClicked.
-func (mr *MockPushManipulatorMockRecorder) MarkAsClicked(arg0 interface{}) *gomock.Call {
+func (mr *MockPushManipulatorMockRecorder) MarkAsClicked(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkAsClicked", reflect.TypeOf((*MockPushManipulator)(nil).MarkAsClicked), arg0, arg1)
+}
+
+// MarkAsDelivered mocks base method.
+func (m *MockPushManipulator) MarkAsDelivered(arg0 context.Context, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkAsDelivered", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkAsDelivered indicates an expected call of MarkAsDelivered.
+func (mr *MockPushManipulatorMockRecorder) MarkAsDelivered(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkAsDelivered", reflect.TypeOf((*MockPushManipulator)(nil).MarkAsDelivered), arg0, arg1)
+}
+
+// MarkAsDismissed mocks base method.
+func (m *MockPushManipulator) MarkAsDismissed(arg0 context.Context, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkAsDismissed", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkAsDismissed indicates an expected call of MarkAsDismissed.
+func (mr *MockPushManipulatorMockRecorder) MarkAsDismissed(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkAsClicked", reflect.TypeOf((*MockPushManipulator)(nil).MarkAsClicked), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkAsDismissed", reflect.TypeOf((*MockPushManipulator)(nil).MarkAsDismissed), arg0, arg1)
+}
+
+// ProcessFeedEvent mocks base method.
+func (m *MockPushManipulator) ProcessFeedEvent(arg0 context.Context, arg1 string, arg2 Item) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProcessFeedEvent", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ProcessFeedEvent indicates an expected call of ProcessFeedEvent.
+func (mr *MockPushManipulatorMockRecorder) ProcessFeedEvent(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessFeedEvent", reflect.TypeOf((*MockPushManipulator)(nil).ProcessFeedEvent), arg0, arg1, arg2)
 }
 
 // ProcessFeedItem mocks base method.
@@ -429,3 +1048,78 @@ func (mr *MockPushManipulatorMockRecorder) ProcessFeedItem(arg0, arg1 interface{
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessFeedItem", reflect.TypeOf((*MockPushManipulator)(nil).ProcessFeedItem), arg0, arg1)
 }
+
+// MockDeadLetterSink is a mock of DeadLetterSink interface.
+type MockDeadLetterSink struct {
+	ctrl     *gomock.Controller
+	recorder *MockDeadLetterSinkMockRecorder
+}
+
+// MockDeadLetterSinkMockRecorder is the mock recorder for MockDeadLetterSink.
+type MockDeadLetterSinkMockRecorder struct {
+	mock *MockDeadLetterSink
+}
+
+// NewMockDeadLetterSink creates a new mock instance.
+func NewMockDeadLetterSink(ctrl *gomock.Controller) *MockDeadLetterSink {
+	mock := &MockDeadLetterSink{ctrl: ctrl}
+	mock.recorder = &MockDeadLetterSinkMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDeadLetterSink) EXPECT() *MockDeadLetterSinkMockRecorder {
+	return m.recorder
+}
+
+// Put mocks base method.
+func (m *MockDeadLetterSink) Put(arg0 context.Context, arg1 *DeadLetter) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Put", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Put indicates an expected call of Put.
+func (mr *MockDeadLetterSinkMockRecorder) Put(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Put", reflect.TypeOf((*MockDeadLetterSink)(nil).Put), arg0, arg1)
+}
+
+// MockFeedReplayer is a mock of FeedReplayer interface.
+type MockFeedReplayer struct {
+	ctrl     *gomock.Controller
+	recorder *MockFeedReplayerMockRecorder
+}
+
+// MockFeedReplayerMockRecorder is the mock recorder for MockFeedReplayer.
+type MockFeedReplayerMockRecorder struct {
+	mock *MockFeedReplayer
+}
+
+// NewMockFeedReplayer creates a new mock instance.
+func NewMockFeedReplayer(ctrl *gomock.Controller) *MockFeedReplayer {
+	mock := &MockFeedReplayer{ctrl: ctrl}
+	mock.recorder = &MockFeedReplayerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFeedReplayer) EXPECT() *MockFeedReplayerMockRecorder {
+	return m.recorder
+}
+
+// ReplayFeedItem mocks base method.
+func (m *MockFeedReplayer) ReplayFeedItem(arg0 context.Context, arg1 Item, arg2 bool, arg3 int) ([]FanoutLog, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplayFeedItem", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]FanoutLog)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReplayFeedItem indicates an expected call of ReplayFeedItem.
+func (mr *MockFeedReplayerMockRecorder) ReplayFeedItem(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplayFeedItem", reflect.TypeOf((*MockFeedReplayer)(nil).ReplayFeedItem), arg0, arg1, arg2, arg3)
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
 	"gorm.io/gorm"
 )
 
@@ -22,8 +23,25 @@ const (
 	DelegateCreateProposal      Action = "delegate.proposal.created"
 	DelegateVotingVoted         Action = "delegate.voting.voted"
 	DelegateVotingSkipVote      Action = "delegate.voting.skip_vote"
+	BroadcastAnnounced          Action = "broadcast.announced"
 )
 
+var knownActions = []Action{
+	DaoCreated,
+	DaoUpdated,
+	ProposalCreated,
+	ProposalUpdated,
+	ProposalVotingStartsSoon,
+	ProposalVotingEndsSoon,
+	ProposalVotingStarted,
+	ProposalVotingQuorumReached,
+	ProposalVotingEnded,
+	DelegateCreateProposal,
+	DelegateVotingVoted,
+	DelegateVotingSkipVote,
+	BroadcastAnnounced,
+}
+
 const (
 	templateIDVoteFinishesSoon       templateID = 1
 	templateIDOneDaoOneProposal      templateID = 2
@@ -33,6 +51,39 @@ const (
 	templateIDDelegateCreateProposal templateID = 6
 	templateIDDelegateVotingVoted    templateID = 7
 	templateIDDelegateVotingSkipVote templateID = 8
+	templateIDBroadcast              templateID = 9
+)
+
+const (
+	FanoutQueued          FanoutOutcome = "queued"
+	FanoutSkippedNoTokens FanoutOutcome = "skipped_no_tokens"
+	FanoutSkippedAction   FanoutOutcome = "skipped_action"
+	FanoutDuplicate       FanoutOutcome = "duplicate"
+	// FanoutSkippedInvalidUser is stored with an empty user id since the id can't be parsed
+	FanoutSkippedInvalidUser FanoutOutcome = "skipped_invalid_user"
+	// FanoutFailedTokens is stored when the token lookup fails, the subscriber is decided again on the retry of the event
+	FanoutFailedTokens FanoutOutcome = "failed_tokens"
+)
+
+const (
+	BroadcastSegmentUsers BroadcastSegment = "users"
+	BroadcastSegmentDao   BroadcastSegment = "dao"
+	BroadcastSegmentAll   BroadcastSegment = "all"
+)
+
+const (
+	BroadcastDraft     BroadcastStatus = "draft"
+	BroadcastScheduled BroadcastStatus = "scheduled"
+	BroadcastSending   BroadcastStatus = "sending"
+	BroadcastSent      BroadcastStatus = "sent"
+	BroadcastFailed    BroadcastStatus = "failed"
+	BroadcastCancelled BroadcastStatus = "cancelled"
+)
+
+const (
+	InboxProcessing InboxStatus = "processing"
+	InboxProcessed  InboxStatus = "processed"
+	InboxFailed     InboxStatus = "failed"
 )
 
 type Type string
@@ -54,7 +105,44 @@ type request struct {
 	userID     uuid.UUID
 	deviceUUID string
 	proposals  []string
+	daos       []uuid.UUID
+	actions    []Action
 	template   templateID
+	// vars are values the copy is built from, experiment variants render own copy with them
+	vars         copyVars
+	experimentID string
+	variantID    string
+	// links to traces of the feed events queued the request items
+	links         []trace.Link
+	correlationID string
+	// deepLink and broadcastID are set for admin broadcasts only
+	deepLink    string
+	broadcastID uint
+}
+
+type copyVars struct {
+	DaoName       string
+	DaoNames      string
+	ProposalTitle string
+	Proposals     int
+}
+
+// action returns the action of the request if there is only one
+func (r request) action() Action {
+	if len(r.actions) != 1 {
+		return ""
+	}
+
+	return r.actions[0]
+}
+
+// dao returns the dao of the request if there is only one
+func (r request) dao() *uuid.UUID {
+	if len(r.daos) != 1 {
+		return nil
+	}
+
+	return &r.daos[0]
 }
 
 type Message struct {
@@ -65,6 +153,10 @@ type Message struct {
 	Payload    json.RawMessage `json:"payload"`
 	TemplateID templateID      `json:"template_id"`
 	DeviceUUID string          `json:"device_uuid"`
+	// ExperimentID and VariantID are set if the copy was chosen by the template experiment
+	ExperimentID string `json:"experiment_id,omitempty"`
+	VariantID    string `json:"variant_id,omitempty"`
+	DeepLink     string `json:"deep_link,omitempty"`
 }
 
 type History struct {
@@ -75,9 +167,19 @@ type History struct {
 	PushResponse string
 	Hash         string
 	ClickedAt    *time.Time
+	DeliveredAt  *time.Time
+	DismissedAt  *time.Time
+	// ReadAt is set by the notification center and by the click, only the latter is counted in analytics
+	ReadAt *time.Time
+	// Action and DaoID are empty for the push about a few actions or DAOs
+	Action Action
+	DaoID  *uuid.UUID
+	// CorrelationID is the id of the worker run sent the push
+	CorrelationID string
 }
 
 type Item struct {
+	FeedID     uuid.UUID `json:"feed_id"`
 	DaoID      uuid.UUID `json:"dao_id"`
 	ProposalID string    `json:"proposal_id"`
 	Action     Action    `json:"action"`
@@ -118,6 +220,12 @@ type SendQueue struct {
 	ProposalID string
 	Action     Action
 	SentAt     *time.Time
+	// TraceParent links the send to the trace of the feed event queued the item
+	TraceParent string
+	// CorrelationID is the id of the feed event queued the item,
+	// SentCorrelationID is the id of the worker run sent it
+	CorrelationID     string
+	SentCorrelationID string
 }
 
 func (SendQueue) TableName() string {
@@ -128,3 +236,147 @@ type TokenDetails struct {
 	Token      string
 	DeviceUUID string
 }
+
+type FanoutOutcome string
+
+// FanoutLog keeps the decision made for a single subscriber while processing a feed event.
+// Events skipped before looking up subscribers are stored with an empty user id.
+type FanoutLog struct {
+	gorm.Model
+
+	FeedID     uuid.UUID
+	UserID     uuid.UUID
+	DaoID      uuid.UUID
+	ProposalID string
+	Action     Action
+	Outcome    FanoutOutcome
+}
+
+func (FanoutLog) TableName() string {
+	return "fanout_log"
+}
+
+func newFanoutLog(item Item, userID uuid.UUID, outcome FanoutOutcome) FanoutLog {
+	return FanoutLog{
+		FeedID:     item.FeedID,
+		UserID:     userID,
+		DaoID:      item.DaoID,
+		ProposalID: item.ProposalID,
+		Action:     item.Action,
+		Outcome:    outcome,
+	}
+}
+
+type InboxStatus string
+
+// InboxEvent tracks processing state of the incoming event to handle it only once
+// regardless of redeliveries and replays.
+type InboxEvent struct {
+	gorm.Model
+
+	Subject     string
+	EventKey    string
+	Status      InboxStatus
+	Attempts    int
+	LastError   string
+	ProcessedAt *time.Time
+	// LockedUntil is the end of the lease of the attempt in progress
+	LockedUntil *time.Time
+}
+
+func (InboxEvent) TableName() string {
+	return "event_inbox"
+}
+
+// DeadLetter keeps the event which could not be processed after max deliveries
+type DeadLetter struct {
+	gorm.Model
+
+	Subject    string
+	Payload    string
+	Error      string
+	Deliveries int
+	ReplayedAt *time.Time
+}
+
+// OutboxMessage is the event stored in the same transaction as the state change
+// and published to nats asynchronously
+type OutboxMessage struct {
+	gorm.Model
+
+	Subject     string
+	Payload     string
+	Attempts    int
+	LastError   string
+	PublishedAt *time.Time
+	// LockedUntil is the end of the claim of the publisher
+	LockedUntil *time.Time
+	// FailedAt is set once the message failed outboxMaxAttempts times, it is not published anymore
+	FailedAt *time.Time
+}
+
+func (OutboxMessage) TableName() string {
+	return "outbox"
+}
+
+// PushDedup reserves the push key until it expires, the key without expiration is kept forever
+type PushDedup struct {
+	gorm.Model
+
+	Key       string
+	ExpiresAt *time.Time
+}
+
+func (PushDedup) TableName() string {
+	return "push_dedup"
+}
+
+// PendingQueueStats describes unsent queue items of the action
+type PendingQueueStats struct {
+	Action          Action
+	Count           int64
+	OldestCreatedAt time.Time
+}
+
+type BroadcastSegment string
+
+type BroadcastStatus string
+
+// Broadcast is the admin announcement to the segment of users
+type Broadcast struct {
+	gorm.Model
+
+	Title    string
+	Body     string
+	ImageURL string
+	DeepLink string
+	Segment  BroadcastSegment
+	// UserIDs are set for the users segment, DaoID for the dao one
+	UserIDs     []uuid.UUID `gorm:"serializer:json"`
+	DaoID       *uuid.UUID
+	Status      BroadcastStatus
+	ScheduledAt *time.Time
+	ConfirmedAt *time.Time
+	StartedAt   *time.Time
+	FinishedAt  *time.Time
+	Recipients  int
+	Processed   int
+	Skipped     int
+	Failed      int
+	Error       string
+}
+
+// AuditLog records the manual change made through the admin api
+type AuditLog struct {
+	gorm.Model
+
+	Actor         string
+	Action        string
+	Params        json.RawMessage `gorm:"serializer:json"`
+	AffectedIDs   []uint          `gorm:"serializer:json"`
+	CorrelationID string
+}
+
+func (AuditLog) TableName() string {
+	return "audit_log"
+}
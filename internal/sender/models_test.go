@@ -102,3 +102,39 @@ func TestItem_VotingEndsSoon(t *testing.T) {
 		})
 	}
 }
+
+func TestNewFanoutLog(t *testing.T) {
+	item := Item{
+		FeedID:     uuid.New(),
+		DaoID:      uuid.New(),
+		ProposalID: uuid.New().String(),
+		Action:     ProposalCreated,
+	}
+	userID := uuid.New()
+
+	actual := newFanoutLog(item, userID, FanoutSkippedNoTokens)
+	require.Equal(t, item.FeedID, actual.FeedID)
+	require.Equal(t, userID, actual.UserID)
+	require.Equal(t, item.DaoID, actual.DaoID)
+	require.Equal(t, item.ProposalID, actual.ProposalID)
+	require.Equal(t, item.Action, actual.Action)
+	require.Equal(t, FanoutSkippedNoTokens, actual.Outcome)
+}
+
+func TestRequest_ActionAndDao(t *testing.T) {
+	daoID := uuid.New()
+
+	single := request{
+		daos:    []uuid.UUID{daoID},
+		actions: []Action{ProposalCreated},
+	}
+	require.Equal(t, ProposalCreated, single.action())
+	require.Equal(t, &daoID, single.dao())
+
+	few := request{
+		daos:    []uuid.UUID{daoID, uuid.New()},
+		actions: []Action{ProposalCreated, ProposalVotingEnded},
+	}
+	require.Equal(t, Action(""), few.action())
+	require.Nil(t, few.dao())
+}
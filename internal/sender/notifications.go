@@ -0,0 +1,157 @@
+package sender
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultNotificationsLimit = 20
+	maxNotificationsLimit     = 100
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// NotificationCursor points to the last returned history row
+type NotificationCursor struct {
+	CreatedAt time.Time
+	ID        uint
+}
+
+func (c NotificationCursor) String() string {
+	raw := fmt.Sprintf("%d:%d", c.CreatedAt.UnixMicro(), c.ID)
+
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func ParseNotificationCursor(value string) (*NotificationCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	ts, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+
+	micro, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	parsedID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &NotificationCursor{
+		CreatedAt: time.UnixMicro(micro).UTC(),
+		ID:        uint(parsedID),
+	}, nil
+}
+
+type NotificationsQuery struct {
+	UserID uuid.UUID
+	Cursor string
+	Limit  int
+}
+
+type Notification struct {
+	ID         uuid.UUID  `json:"id"`
+	Title      string     `json:"title"`
+	Body       string     `json:"body"`
+	ImageURL   string     `json:"image_url"`
+	Proposals  []string   `json:"proposals"`
+	TemplateID int        `json:"template_id"`
+	Action     Action     `json:"action,omitempty"`
+	DaoID      *uuid.UUID `json:"dao_id,omitempty"`
+	DeepLink   string     `json:"deep_link,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ReadAt     *time.Time `json:"read_at,omitempty"`
+}
+
+type NotificationsPage struct {
+	Items      []Notification `json:"items"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+// Notifications returns user pushes from the newest one, each message only once regardless of devices
+func (s *Service) Notifications(ctx context.Context, query NotificationsQuery) (NotificationsPage, error) {
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultNotificationsLimit
+	}
+	limit = min(limit, maxNotificationsLimit)
+
+	var (
+		before *NotificationCursor
+		err    error
+	)
+	if query.Cursor != "" {
+		if before, err = ParseNotificationCursor(query.Cursor); err != nil {
+			return NotificationsPage{}, err
+		}
+	}
+
+	// fetch one more row to know whether the next page exists
+	list, err := s.repo.Notifications(ctx, query.UserID, before, limit+1)
+	if err != nil {
+		return NotificationsPage{}, fmt.Errorf("s.repo.Notifications: %w", err)
+	}
+
+	page := NotificationsPage{
+		Items: make([]Notification, 0, min(len(list), limit)),
+	}
+	for i, item := range list {
+		if i == limit {
+			last := list[i-1]
+			page.NextCursor = NotificationCursor{CreatedAt: last.CreatedAt, ID: last.ID}.String()
+
+			break
+		}
+
+		page.Items = append(page.Items, convertHistoryToNotification(item))
+	}
+
+	return page, nil
+}
+
+func (s *Service) UnreadNotificationsCount(ctx context.Context, userID uuid.UUID) (int64, error) {
+	return s.repo.UnreadNotificationsCount(ctx, userID)
+}
+
+// MarkAsRead marks user messages as read without counting them as clicks.
+// All user messages are marked if ids are empty.
+func (s *Service) MarkAsRead(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) error {
+	return s.repo.MarkAsRead(ctx, userID, ids)
+}
+
+func convertHistoryToNotification(h History) Notification {
+	var proposals []string
+	if len(h.Message.Payload) > 0 {
+		_ = json.Unmarshal(h.Message.Payload, &proposals)
+	}
+
+	return Notification{
+		ID:         h.Message.ID,
+		Title:      h.Message.Title,
+		Body:       h.Message.Body,
+		ImageURL:   h.Message.ImageURL,
+		Proposals:  proposals,
+		TemplateID: int(h.Message.TemplateID),
+		Action:     h.Action,
+		DaoID:      h.DaoID,
+		DeepLink:   h.Message.DeepLink,
+		CreatedAt:  h.CreatedAt,
+		ReadAt:     h.ReadAt,
+	}
+}
@@ -0,0 +1,62 @@
+package sender
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestNotificationCursor(t *testing.T) {
+	cursor := NotificationCursor{
+		CreatedAt: time.Date(2024, 12, 1, 10, 11, 12, 123456000, time.UTC),
+		ID:        42,
+	}
+
+	parsed, err := ParseNotificationCursor(cursor.String())
+	require.NoError(t, err)
+	require.Equal(t, cursor, *parsed)
+
+	for _, value := range []string{"wrong!", "MTIz", "YTox", "MToy1"} {
+		_, err := ParseNotificationCursor(value)
+		require.ErrorIs(t, err, ErrInvalidCursor, value)
+	}
+}
+
+func TestConvertHistoryToNotification(t *testing.T) {
+	now := time.Now()
+	daoID := uuid.New()
+	payload, _ := json.Marshal([]string{"p1", "p2"})
+
+	h := History{
+		Model:  gorm.Model{ID: 1, CreatedAt: now},
+		UserID: uuid.New(),
+		Message: Message{
+			ID:         uuid.New(),
+			Title:      "title",
+			Body:       "body",
+			ImageURL:   "image",
+			Payload:    payload,
+			TemplateID: templateIDOneDaoFewProposal,
+		},
+		ReadAt: &now,
+		Action: ProposalCreated,
+		DaoID:  &daoID,
+	}
+
+	require.Equal(t, Notification{
+		ID:         h.Message.ID,
+		Title:      "title",
+		Body:       "body",
+		ImageURL:   "image",
+		Proposals:  []string{"p1", "p2"},
+		TemplateID: int(templateIDOneDaoFewProposal),
+		Action:     ProposalCreated,
+		DaoID:      &daoID,
+		CreatedAt:  now,
+		ReadAt:     &now,
+	}, convertHistoryToNotification(h))
+}
@@ -0,0 +1,76 @@
+package sender
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+const (
+	outboxPublishDelay = 5 * time.Second
+	outboxBatchSize    = 100
+	outboxRetention    = 7 * 24 * time.Hour
+	// outboxClaimLease is how long the publisher owns claimed messages
+	outboxClaimLease = time.Minute
+	// outboxMaxAttempts is how many times the message is published before it is marked failed
+	outboxMaxAttempts = 10
+)
+
+type OutboxWorker struct {
+	repo      Storage
+	publisher EventPublisher
+}
+
+func NewOutboxWorker(r Storage, p EventPublisher) *OutboxWorker {
+	return &OutboxWorker{
+		repo:      r,
+		publisher: p,
+	}
+}
+
+func (w *OutboxWorker) Start(ctx context.Context) error {
+	for {
+		start := time.Now()
+		runCtx := workerRunContext(ctx, "outbox")
+		err := w.publish(runCtx)
+		if err != nil {
+			logger(runCtx).Error().Err(err).Msg("publish outbox")
+		}
+
+		observeWorkerRun("outbox", start, err)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(outboxPublishDelay):
+		}
+	}
+}
+
+func (w *OutboxWorker) publish(ctx context.Context) error {
+	for {
+		published, err := w.repo.PublishOutbox(ctx, outboxBatchSize, func(msg OutboxMessage) error {
+			err := w.publisher.PublishJSON(ctx, msg.Subject, json.RawMessage(msg.Payload))
+
+			collectStats("outbox", msg.Subject, err)
+			if err != nil && msg.Attempts+1 >= outboxMaxAttempts {
+				logger(ctx).Error().
+					Err(err).
+					Uint("outbox_id", msg.ID).
+					Str("subject", msg.Subject).
+					Msg("outbox message is marked failed after max attempts")
+			}
+
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		if published < outboxBatchSize {
+			break
+		}
+	}
+
+	return w.repo.DeleteOutboxPublishedBefore(ctx, time.Now().Add(-outboxRetention))
+}
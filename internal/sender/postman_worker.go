@@ -4,68 +4,93 @@ import (
 	"context"
 	"time"
 
-	"github.com/rs/zerolog/log"
-)
-
-const (
-	reSendDelay            = 5 * time.Minute
-	reSendImmediatelyDelay = 5 * time.Minute
+	"github.com/goverland-labs/goverland-inbox-push/internal/config"
 )
 
 type PostmanWorker struct {
 	service *Service
+	cfg     func() config.Postman
 }
 
-func NewPostmanWorker(s *Service) *PostmanWorker {
+// NewPostmanWorker creates the worker, cfg is called on each run to pick up reloaded settings.
+// A disabled postman keeps items in the queue until it is enabled again.
+func NewPostmanWorker(s *Service, cfg func() config.Postman) *PostmanWorker {
 	return &PostmanWorker{
 		service: s,
+		cfg:     cfg,
 	}
 }
 
 func (w *PostmanWorker) StartRegular(ctx context.Context) error {
 	for {
+		cfg := w.cfg()
 		start := time.Now()
-		err := w.service.sendBatch(ctx)
+		runCtx := workerRunContext(ctx, "postman-regular")
+
+		var err error
+		if cfg.Enabled {
+			err = w.service.sendBatch(runCtx)
+		}
 		if err != nil {
-			log.Error().Err(err).Msg("send batch")
+			logger(runCtx).Error().Err(err).Msg("send batch")
 		}
 
-		log.Debug().Msgf("send batch completed: %v", time.Since(start))
+		logger(runCtx).Debug().Dur("duration", time.Since(start)).Msg("send batch completed")
+
+		observeWorkerRun("postman-regular", start, err)
 
 		select {
 		case <-ctx.Done():
 			return nil
-		case <-time.After(reSendDelay):
+		case <-time.After(cfg.Interval):
 		}
 	}
 }
 
 func (w *PostmanWorker) StartVotingEndsSoon(ctx context.Context) error {
 	for {
-		err := w.service.sendVotingEndsSoon(ctx)
+		cfg := w.cfg()
+		start := time.Now()
+		runCtx := workerRunContext(ctx, "postman-voting-ends-soon")
+
+		var err error
+		if cfg.Enabled {
+			err = w.service.sendVotingEndsSoon(runCtx)
+		}
 		if err != nil {
-			log.Error().Err(err).Msg("send immediately")
+			logger(runCtx).Error().Err(err).Msg("send immediately")
 		}
 
+		observeWorkerRun("postman-voting-ends-soon", start, err)
+
 		select {
 		case <-ctx.Done():
 			return nil
-		case <-time.After(reSendImmediatelyDelay):
+		case <-time.After(cfg.ImmediateInterval):
 		}
 	}
 }
 
 func (w *PostmanWorker) StartDelegates(ctx context.Context) error {
 	for {
-		err := w.service.sendDelegates(ctx)
+		cfg := w.cfg()
+		start := time.Now()
+		runCtx := workerRunContext(ctx, "postman-delegate")
+
+		var err error
+		if cfg.Enabled {
+			err = w.service.sendDelegates(runCtx)
+		}
 		if err != nil {
-			log.Error().Err(err).Msg("send immediately")
+			logger(runCtx).Error().Err(err).Msg("send immediately")
 		}
 
+		observeWorkerRun("postman-delegate", start, err)
+
 		select {
 		case <-ctx.Done():
 			return nil
-		case <-time.After(reSendImmediatelyDelay):
+		case <-time.After(cfg.ImmediateInterval):
 		}
 	}
 }
@@ -0,0 +1,191 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"firebase.google.com/go/v4/messaging"
+
+	"github.com/goverland-labs/goverland-inbox-push/internal/config"
+)
+
+var ErrProjectDisabled = errors.New("firebase project is disabled")
+
+// credentialsReloader is the sender which can reload its credentials after auth errors
+type credentialsReloader interface {
+	MessageSender
+	Reload(ctx context.Context, force bool) (bool, error)
+}
+
+type firebaseProject struct {
+	name    string
+	prefix  string
+	enabled bool
+	sender  credentialsReloader
+}
+
+// send sends the message, an auth error reloads credentials and retries the send once
+func (p *firebaseProject) send(ctx context.Context, message *messaging.Message) (string, error) {
+	response, err := p.sender.Send(ctx, message)
+	if err == nil || classifyError(err) != errorClassAuth {
+		return response, err
+	}
+
+	swapped, reloadErr := p.sender.Reload(ctx, true)
+	if reloadErr != nil {
+		logger(ctx).Error().Err(reloadErr).Str("project", p.name).Msg("reload firebase credentials after auth error")
+		return response, err
+	}
+	if !swapped {
+		return response, err
+	}
+
+	logger(ctx).Info().Str("project", p.name).Msg("firebase credentials are reloaded after auth error")
+
+	// the item is failed only if it is not sent with reloaded credentials too
+	return p.sender.Send(ctx, message)
+}
+
+// FirebaseProjects sends messages through firebase projects of different app builds.
+// The project is chosen by the token prefix, tokens without known prefix go to the default project.
+type FirebaseProjects struct {
+	fallback *firebaseProject
+	// prefixed are sorted by the prefix length, so the longest matching prefix wins
+	prefixed []*firebaseProject
+}
+
+func NewFirebaseProjects(cfg config.Push) (*FirebaseProjects, error) {
+	projects := &FirebaseProjects{
+		fallback: &firebaseProject{name: config.DefaultPushProject, enabled: cfg.Enabled},
+	}
+
+	// credentials of disabled projects are not required
+	if cfg.Enabled {
+		sender, err := NewFirebaseSender(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("project %s: %w", config.DefaultPushProject, err)
+		}
+
+		projects.fallback.sender = sender
+	}
+
+	for _, item := range cfg.Projects {
+		project := &firebaseProject{
+			name:    item.Name,
+			prefix:  item.TokenPrefix,
+			enabled: item.Enabled,
+		}
+
+		if item.Enabled {
+			sender, err := NewFirebaseSender(config.Push{
+				CredentialsFile:          item.CredentialsFile,
+				CredentialsWatchInterval: cfg.CredentialsWatchInterval,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("project %s: %w", item.Name, err)
+			}
+
+			project.sender = sender
+		}
+
+		projects.prefixed = append(projects.prefixed, project)
+	}
+
+	sort.SliceStable(projects.prefixed, func(i, j int) bool {
+		return len(projects.prefixed[i].prefix) > len(projects.prefixed[j].prefix)
+	})
+
+	return projects, nil
+}
+
+// route returns the project of the token and the firebase token without the project prefix
+func (p *FirebaseProjects) route(token string) (*firebaseProject, string) {
+	for _, project := range p.prefixed {
+		if strings.HasPrefix(token, project.prefix) {
+			return project, strings.TrimPrefix(token, project.prefix)
+		}
+	}
+
+	return p.fallback, token
+}
+
+// Project returns the name of the token project and whether sending through it is enabled
+func (p *FirebaseProjects) Project(token string) (string, bool) {
+	project, _ := p.route(token)
+
+	return project.name, project.enabled
+}
+
+func (p *FirebaseProjects) Send(ctx context.Context, message *messaging.Message) (string, error) {
+	project, token := p.route(message.Token)
+	if !project.enabled {
+		metricFirebaseSends.WithLabelValues(project.name, classifyError(ErrProjectDisabled)).Inc()
+		return "", fmt.Errorf("%w: %s", ErrProjectDisabled, project.name)
+	}
+
+	routed := *message
+	routed.Token = token
+
+	response, err := project.send(ctx, &routed)
+	metricFirebaseSends.WithLabelValues(project.name, sendResult(err)).Inc()
+
+	return response, err
+}
+
+// CheckCredentials checks credentials of all enabled projects
+func (p *FirebaseProjects) CheckCredentials(ctx context.Context) error {
+	var errs []error
+	for _, project := range p.all() {
+		tokens, ok := project.sender.(interface{ CheckCredentials(context.Context) error })
+		if !project.enabled || !ok {
+			continue
+		}
+
+		if err := tokens.CheckCredentials(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("project %s: %w", project.name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Start watches credentials files of enabled projects
+func (p *FirebaseProjects) Start(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for _, project := range p.all() {
+		watcher, ok := project.sender.(interface{ Start(context.Context) error })
+		if !project.enabled || !ok {
+			continue
+		}
+
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+
+			if err := watcher.Start(ctx); err != nil {
+				logger(ctx).Error().Err(err).Str("project", name).Msg("watch firebase credentials")
+			}
+		}(project.name)
+	}
+
+	wg.Wait()
+	<-ctx.Done()
+
+	return nil
+}
+
+func (p *FirebaseProjects) all() []*firebaseProject {
+	return append([]*firebaseProject{p.fallback}, p.prefixed...)
+}
+
+func sendResult(err error) string {
+	if err == nil {
+		return "ok"
+	}
+
+	return classifyError(err)
+}
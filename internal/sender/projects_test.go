@@ -0,0 +1,53 @@
+package sender
+
+import (
+	"context"
+	"testing"
+
+	"firebase.google.com/go/v4/messaging"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingSender keeps tokens of sent messages
+type recordingSender struct {
+	tokens []string
+}
+
+func (r *recordingSender) Send(_ context.Context, message *messaging.Message) (string, error) {
+	r.tokens = append(r.tokens, message.Token)
+	return "message-id", nil
+}
+
+func (r *recordingSender) Reload(_ context.Context, _ bool) (bool, error) {
+	return false, nil
+}
+
+func TestFirebaseProjectsSend(t *testing.T) {
+	production, beta, betaPartner := &recordingSender{}, &recordingSender{}, &recordingSender{}
+	projects := &FirebaseProjects{
+		fallback: &firebaseProject{name: "default", enabled: true, sender: production},
+		// sorted by the prefix length
+		prefixed: []*firebaseProject{
+			{name: "beta-partner", prefix: "beta:partner:", enabled: true, sender: betaPartner},
+			{name: "beta", prefix: "beta:", enabled: true, sender: beta},
+			{name: "partner", prefix: "partner:", enabled: false},
+		},
+	}
+
+	for _, token := range []string{"token-1", "beta:token-2", "beta:partner:token-3"} {
+		_, err := projects.Send(context.Background(), &messaging.Message{Token: token})
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, []string{"token-1"}, production.tokens)
+	require.Equal(t, []string{"token-2"}, beta.tokens)
+	require.Equal(t, []string{"token-3"}, betaPartner.tokens)
+
+	_, err := projects.Send(context.Background(), &messaging.Message{Token: "partner:token-4"})
+	require.ErrorIs(t, err, ErrProjectDisabled)
+	require.Equal(t, errorClassProjectDisabled, classifyError(err))
+
+	name, enabled := projects.Project("partner:token-4")
+	require.Equal(t, "partner", name)
+	require.False(t, enabled)
+}
@@ -0,0 +1,272 @@
+package sender
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultQueueItemsLimit = 100
+	maxQueueItemsLimit     = 1000
+	defaultAuditLogLimit   = 50
+)
+
+const (
+	QueueStatusPending QueueStatus = "pending"
+	QueueStatusSent    QueueStatus = "sent"
+)
+
+const (
+	AuditQueueCancel    = "queue.cancel"
+	AuditQueueRequeue   = "queue.requeue"
+	AuditQueueForceSend = "queue.force_send"
+)
+
+var ErrInvalidQueueQuery = errors.New("invalid queue query")
+
+type QueueStatus string
+
+// QueueQuery selects queue items, empty fields are not applied
+type QueueQuery struct {
+	IDs           []uint
+	UserID        *uuid.UUID
+	DaoID         *uuid.UUID
+	ProposalID    string
+	Actions       []Action
+	Status        QueueStatus
+	CorrelationID string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	Limit         int
+}
+
+func (q QueueQuery) filters() ([]Filter, error) {
+	var filters []Filter
+	if len(q.IDs) > 0 {
+		filters = append(filters, IDIn(q.IDs...))
+	}
+	if q.UserID != nil {
+		filters = append(filters, UserIDIn(q.UserID.String()))
+	}
+	if q.DaoID != nil {
+		filters = append(filters, DaoIDIn(q.DaoID.String()))
+	}
+	if q.ProposalID != "" {
+		filters = append(filters, ProposalIDIn(q.ProposalID))
+	}
+	if len(q.Actions) > 0 {
+		actions := make([]string, 0, len(q.Actions))
+		for _, action := range q.Actions {
+			actions = append(actions, string(action))
+		}
+
+		filters = append(filters, ActionIn(actions...))
+	}
+	if q.CorrelationID != "" {
+		filters = append(filters, CorrelationIDIn(q.CorrelationID))
+	}
+	if !q.CreatedAfter.IsZero() {
+		filters = append(filters, CreatedAfter(q.CreatedAfter))
+	}
+	if !q.CreatedBefore.IsZero() {
+		filters = append(filters, CreatedBefore(q.CreatedBefore))
+	}
+
+	switch q.Status {
+	case "":
+	case QueueStatusPending:
+		filters = append(filters, AvailableForSending())
+	case QueueStatusSent:
+		filters = append(filters, AlreadySent())
+	default:
+		return nil, fmt.Errorf("%w: unsupported status %q", ErrInvalidQueueQuery, q.Status)
+	}
+
+	return filters, nil
+}
+
+type QueueItem struct {
+	ID                uint       `json:"id"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UserID            uuid.UUID  `json:"user_id"`
+	DaoID             uuid.UUID  `json:"dao_id"`
+	ProposalID        string     `json:"proposal_id"`
+	Action            Action     `json:"action"`
+	SentAt            *time.Time `json:"sent_at,omitempty"`
+	CorrelationID     string     `json:"correlation_id,omitempty"`
+	SentCorrelationID string     `json:"sent_correlation_id,omitempty"`
+}
+
+type AuditRecord struct {
+	ID            uint            `json:"id"`
+	CreatedAt     time.Time       `json:"created_at"`
+	Actor         string          `json:"actor"`
+	Action        string          `json:"action"`
+	Params        json.RawMessage `json:"params,omitempty"`
+	AffectedIDs   []uint          `json:"affected_ids"`
+	CorrelationID string          `json:"correlation_id,omitempty"`
+}
+
+// QueueItems returns the latest queue items matched by the query
+func (s *Service) QueueItems(ctx context.Context, query QueueQuery) ([]QueueItem, error) {
+	filters, err := query.filters()
+	if err != nil {
+		return nil, err
+	}
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultQueueItemsLimit
+	}
+
+	list, err := s.repo.QueueItems(ctx, filters, min(limit, maxQueueItemsLimit))
+	if err != nil {
+		return nil, fmt.Errorf("s.repo.QueueItems: %w", err)
+	}
+
+	result := make([]QueueItem, 0, len(list))
+	for _, item := range list {
+		result = append(result, convertQueueToItem(item))
+	}
+
+	return result, nil
+}
+
+// CancelQueueItems cancels pending items, already sent ones are kept as is
+func (s *Service) CancelQueueItems(ctx context.Context, actor string, ids []uint) ([]uint, error) {
+	return s.changeQueue(ctx, actor, AuditQueueCancel, ids, Storage.CancelQueueItems)
+}
+
+// RequeueQueueItems makes sent items pending again, so the postman sends them on the next run.
+// Devices which already got the push are skipped by dedup within its window.
+func (s *Service) RequeueQueueItems(ctx context.Context, actor string, ids []uint) ([]uint, error) {
+	return s.changeQueue(ctx, actor, AuditQueueRequeue, ids, Storage.RequeueQueueItems)
+}
+
+func (s *Service) changeQueue(
+	ctx context.Context,
+	actor, action string,
+	ids []uint,
+	change func(tx Storage, ctx context.Context, ids []uint) ([]uint, error),
+) ([]uint, error) {
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%w: ids are required", ErrInvalidQueueQuery)
+	}
+
+	ctx = ensureCorrelationID(ctx)
+
+	var affected []uint
+	err := s.repo.Transaction(ctx, func(tx Storage) error {
+		var err error
+		if affected, err = change(tx, ctx, ids); err != nil {
+			return err
+		}
+
+		return tx.CreateAuditLog(ctx, newAuditLog(ctx, actor, action, map[string]any{"ids": ids}, affected))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", action, err)
+	}
+
+	logger(ctx).Info().
+		Str("actor", actor).
+		Str("audit_action", action).
+		Uints("ids", affected).
+		Msg("queue is changed by admin")
+
+	return affected, nil
+}
+
+// ForceSend sends pending items of the user right away through the same pipeline as the postman
+func (s *Service) ForceSend(ctx context.Context, actor string, userID uuid.UUID) ([]uint, error) {
+	ctx = ensureCorrelationID(ctx)
+
+	pending, err := s.repo.QueueItems(ctx, []Filter{UserIDIn(userID.String()), AvailableForSending()}, maxQueueItemsLimit)
+	if err != nil {
+		return nil, fmt.Errorf("s.repo.QueueItems: %w", err)
+	}
+
+	ids := make([]uint, 0, len(pending))
+	for _, item := range pending {
+		ids = append(ids, item.ID)
+	}
+
+	err = s.repo.CreateAuditLog(ctx, newAuditLog(ctx, actor, AuditQueueForceSend, map[string]any{"user_id": userID}, ids))
+	if err != nil {
+		return nil, fmt.Errorf("s.repo.CreateAuditLog: %w", err)
+	}
+
+	if len(ids) == 0 {
+		return ids, nil
+	}
+
+	byUser := UserIDIn(userID.String())
+	err = errors.Join(
+		s.sendBatch(ctx, byUser),
+		s.sendVotingEndsSoon(ctx, byUser),
+		s.sendDelegates(ctx, byUser),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("force send: %w", err)
+	}
+
+	return ids, nil
+}
+
+func (s *Service) AuditLog(ctx context.Context, action string, limit int) ([]AuditRecord, error) {
+	if limit <= 0 {
+		limit = defaultAuditLogLimit
+	}
+
+	list, err := s.repo.ListAuditLog(ctx, action, limit)
+	if err != nil {
+		return nil, fmt.Errorf("s.repo.ListAuditLog: %w", err)
+	}
+
+	result := make([]AuditRecord, 0, len(list))
+	for _, item := range list {
+		result = append(result, AuditRecord{
+			ID:            item.ID,
+			CreatedAt:     item.CreatedAt,
+			Actor:         item.Actor,
+			Action:        item.Action,
+			Params:        item.Params,
+			AffectedIDs:   item.AffectedIDs,
+			CorrelationID: item.CorrelationID,
+		})
+	}
+
+	return result, nil
+}
+
+func newAuditLog(ctx context.Context, actor, action string, params map[string]any, affected []uint) *AuditLog {
+	// params consist of plain types only, so marshaling can't fail
+	data, _ := json.Marshal(params)
+
+	return &AuditLog{
+		Actor:         actor,
+		Action:        action,
+		Params:        data,
+		AffectedIDs:   affected,
+		CorrelationID: CorrelationID(ctx),
+	}
+}
+
+func convertQueueToItem(item SendQueue) QueueItem {
+	return QueueItem{
+		ID:                item.ID,
+		CreatedAt:         item.CreatedAt,
+		UserID:            item.UserID,
+		DaoID:             item.DaoID,
+		ProposalID:        item.ProposalID,
+		Action:            item.Action,
+		SentAt:            item.SentAt,
+		CorrelationID:     item.CorrelationID,
+		SentCorrelationID: item.SentCorrelationID,
+	}
+}
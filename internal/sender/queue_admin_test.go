@@ -0,0 +1,56 @@
+package sender
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueueQueryFilters(t *testing.T) {
+	userID := uuid.New()
+
+	filters, err := QueueQuery{}.filters()
+	require.NoError(t, err)
+	require.Empty(t, filters)
+
+	filters, err = QueueQuery{
+		IDs:          []uint{1},
+		UserID:       &userID,
+		Actions:      []Action{ProposalCreated},
+		Status:       QueueStatusSent,
+		CreatedAfter: time.Now(),
+	}.filters()
+	require.NoError(t, err)
+	require.Len(t, filters, 5)
+
+	_, err = QueueQuery{Status: "lost"}.filters()
+	require.ErrorIs(t, err, ErrInvalidQueueQuery)
+}
+
+func TestChangeQueueRequiresIDs(t *testing.T) {
+	s := &Service{}
+
+	_, err := s.CancelQueueItems(context.Background(), "admin", nil)
+	require.ErrorIs(t, err, ErrInvalidQueueQuery)
+
+	_, err = s.RequeueQueueItems(context.Background(), "admin", []uint{})
+	require.ErrorIs(t, err, ErrInvalidQueueQuery)
+}
+
+func TestNewAuditLog(t *testing.T) {
+	ctx := withCorrelationID(context.Background(), "run")
+
+	item := newAuditLog(ctx, "alice", AuditQueueCancel, map[string]any{"ids": []uint{1, 2}}, []uint{2})
+	require.Equal(t, "alice", item.Actor)
+	require.Equal(t, AuditQueueCancel, item.Action)
+	require.Equal(t, []uint{2}, item.AffectedIDs)
+	require.Equal(t, "run", item.CorrelationID)
+
+	var params map[string][]uint
+	require.NoError(t, json.Unmarshal(item.Params, &params))
+	require.Equal(t, []uint{1, 2}, params["ids"])
+}
@@ -0,0 +1,70 @@
+package sender
+
+import (
+	"context"
+	"time"
+)
+
+const queueMetricsDelay = 30 * time.Second
+
+// QueueMetricsWorker exports the depth of the send queue and the age of the oldest unsent item
+type QueueMetricsWorker struct {
+	repo Storage
+}
+
+func NewQueueMetricsWorker(r Storage) *QueueMetricsWorker {
+	return &QueueMetricsWorker{
+		repo: r,
+	}
+}
+
+func (w *QueueMetricsWorker) Start(ctx context.Context) error {
+	for {
+		start := time.Now()
+		runCtx := workerRunContext(ctx, "queue-metrics")
+		err := w.refresh(runCtx)
+		if err != nil {
+			logger(runCtx).Error().Err(err).Msg("refresh queue metrics")
+		}
+
+		observeWorkerRun("queue-metrics", start, err)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(queueMetricsDelay):
+		}
+	}
+}
+
+func (w *QueueMetricsWorker) refresh(ctx context.Context) error {
+	list, err := w.repo.PendingQueueStats(ctx)
+	if err != nil {
+		return err
+	}
+
+	setQueueMetrics(list, time.Now())
+
+	return nil
+}
+
+// setQueueMetrics resets all known actions to zero, so drained queues are reported as well
+func setQueueMetrics(list []PendingQueueStats, now time.Time) {
+	pending := map[string]float64{otherActionLabel: 0}
+	age := map[string]float64{otherActionLabel: 0}
+	for _, action := range knownActions {
+		pending[string(action)] = 0
+		age[string(action)] = 0
+	}
+
+	for _, info := range list {
+		label := actionLabel(info.Action)
+		pending[label] += float64(info.Count)
+		age[label] = max(age[label], now.Sub(info.OldestCreatedAt).Seconds())
+	}
+
+	for label, value := range pending {
+		metricQueuePending.WithLabelValues(label).Set(value)
+		metricQueueOldestPendingAge.WithLabelValues(label).Set(age[label])
+	}
+}
@@ -0,0 +1,47 @@
+package sender
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/goverland-labs/goverland-inbox-push/internal/metrics"
+)
+
+// Receipts are emitted by the mobile client with the message id from the push custom data
+const (
+	SubjectPushDelivered = "push.delivered"
+	SubjectPushDismissed = "push.dismissed"
+)
+
+type PushReceiptPayload struct {
+	ID uuid.UUID `json:"id"`
+}
+
+type PushReceiptHandler func(ctx context.Context, payload PushReceiptPayload) error
+
+func (c *Consumer) deliveredHandler() PushReceiptHandler {
+	return c.receiptHandler("delivered_push", "delivered", c.service.MarkAsDelivered)
+}
+
+func (c *Consumer) dismissedHandler() PushReceiptHandler {
+	return c.receiptHandler("dismissed_push", "dismissed", c.service.MarkAsDismissed)
+}
+
+func (c *Consumer) receiptHandler(name, stat string, mark func(ctx context.Context, id uuid.UUID) error) PushReceiptHandler {
+	return func(ctx context.Context, payload PushReceiptPayload) error {
+		var err error
+		defer func(start time.Time) {
+			metricHandleHistogram.
+				WithLabelValues(name, metrics.ErrLabelValue(err)).
+				Observe(time.Since(start).Seconds())
+		}(time.Now())
+
+		err = mark(ctx, payload.ID)
+
+		collectStats("mark", stat, err)
+
+		return err
+	}
+}
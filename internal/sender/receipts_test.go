@@ -0,0 +1,66 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReceiptHandlers(t *testing.T) {
+	id := uuid.New()
+	errMark := errors.New("mark")
+
+	for name, tc := range map[string]struct {
+		handler func(c *Consumer) PushReceiptHandler
+		expect  func(pm *MockPushManipulator)
+		err     error
+	}{
+		"delivered": {
+			handler: (*Consumer).deliveredHandler,
+			expect: func(pm *MockPushManipulator) {
+				pm.EXPECT().MarkAsDelivered(gomock.Any(), id).Times(1).Return(nil)
+			},
+		},
+		"dismissed": {
+			handler: (*Consumer).dismissedHandler,
+			expect: func(pm *MockPushManipulator) {
+				pm.EXPECT().MarkAsDismissed(gomock.Any(), id).Times(1).Return(nil)
+			},
+		},
+		"failed": {
+			handler: (*Consumer).deliveredHandler,
+			expect: func(pm *MockPushManipulator) {
+				pm.EXPECT().MarkAsDelivered(gomock.Any(), id).Times(1).Return(errMark)
+			},
+			err: errMark,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			pm := NewMockPushManipulator(ctrl)
+			tc.expect(pm)
+
+			c := &Consumer{service: pm}
+			err := tc.handler(c)(context.Background(), PushReceiptPayload{ID: id})
+			require.ErrorIs(t, err, tc.err)
+		})
+	}
+}
+
+func TestEnsureStream(t *testing.T) {
+	nc := runNatsServer(t)
+	js, err := nc.JetStream()
+	require.NoError(t, err)
+
+	require.NoError(t, ensureStream(js, SubjectPushDelivered))
+	// existing stream is reused
+	require.NoError(t, ensureStream(js, SubjectPushDelivered))
+
+	name, err := js.StreamNameBySubject(SubjectPushDelivered)
+	require.NoError(t, err)
+	require.Equal(t, "str_push_delivered", name)
+}
@@ -0,0 +1,191 @@
+package sender
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/goverland-labs/goverland-platform-events/events/inbox"
+	"github.com/nats-io/nats.go"
+)
+
+const replayIdleTimeout = 5 * time.Second
+
+type FeedReplayer interface {
+	ReplayFeedItem(ctx context.Context, item Item, dryRun bool, limit int) ([]FanoutLog, error)
+}
+
+type ReplayFilter struct {
+	DaoIDs      []uuid.UUID
+	ProposalIDs []string
+	Actions     []Action
+}
+
+func (f ReplayFilter) Match(item Item) bool {
+	if len(f.DaoIDs) > 0 && !slices.Contains(f.DaoIDs, item.DaoID) {
+		return false
+	}
+
+	if len(f.ProposalIDs) > 0 && !slices.Contains(f.ProposalIDs, item.ProposalID) {
+		return false
+	}
+
+	if len(f.Actions) > 0 && !slices.Contains(f.Actions, item.Action) {
+		return false
+	}
+
+	return true
+}
+
+type ReplayOptions struct {
+	Filter ReplayFilter
+	// StartSeq has priority over StartTime, all stream events are replayed if both are empty
+	StartSeq  uint64
+	StartTime time.Time
+	DryRun    bool
+	// MaxPushes is the max number of queued items produced by the replay, zero means no limit
+	MaxPushes int
+}
+
+type ReplayStats struct {
+	Events  int
+	Matched int
+	Queued  int
+}
+
+// ReplayReporter receives the fan-out outcomes of each replayed event
+type ReplayReporter func(seq uint64, item Item, outcomes []FanoutLog)
+
+// Replay reads feed events from the stream with an ephemeral consumer and processes them again
+type Replay struct {
+	conn        *nats.Conn
+	service     FeedReplayer
+	idleTimeout time.Duration
+}
+
+func NewReplay(nc *nats.Conn, s FeedReplayer) *Replay {
+	return &Replay{
+		conn:        nc,
+		service:     s,
+		idleTimeout: replayIdleTimeout,
+	}
+}
+
+func (r *Replay) Run(ctx context.Context, opts ReplayOptions, report ReplayReporter) (ReplayStats, error) {
+	var stats ReplayStats
+
+	js, err := r.conn.JetStream()
+	if err != nil {
+		return stats, fmt.Errorf("jet stream: %w", err)
+	}
+
+	stream, err := js.StreamNameBySubject(inbox.SubjectFeedUpdated)
+	if err != nil {
+		return stats, fmt.Errorf("find stream by subject: %w", err)
+	}
+
+	info, err := js.StreamInfo(stream)
+	if err != nil {
+		return stats, fmt.Errorf("stream info: %w", err)
+	}
+
+	// replay only events published before the start to finish at some point
+	lastSeq := info.State.LastSeq
+	if info.State.Msgs == 0 || opts.StartSeq > lastSeq {
+		return stats, nil
+	}
+
+	subOpts := []nats.SubOpt{nats.OrderedConsumer()}
+	switch {
+	case opts.StartSeq > 0:
+		subOpts = append(subOpts, nats.StartSequence(opts.StartSeq))
+	case !opts.StartTime.IsZero():
+		subOpts = append(subOpts, nats.StartTime(opts.StartTime))
+	default:
+		subOpts = append(subOpts, nats.DeliverAll())
+	}
+
+	sub, err := js.SubscribeSync(inbox.SubjectFeedUpdated, subOpts...)
+	if err != nil {
+		return stats, fmt.Errorf("subscribe: %w", err)
+	}
+	defer func() {
+		if err := sub.Unsubscribe(); err != nil {
+			logger(ctx).Error().Err(err).Msg("unsubscribe replay consumer")
+		}
+	}()
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+
+		msg, err := sub.NextMsg(r.idleTimeout)
+		if errors.Is(err, nats.ErrTimeout) {
+			return stats, nil
+		}
+		if err != nil {
+			return stats, fmt.Errorf("next message: %w", err)
+		}
+
+		meta, err := msg.Metadata()
+		if err != nil {
+			return stats, fmt.Errorf("message metadata: %w", err)
+		}
+
+		stats.Events++
+
+		if err := r.replay(ctx, meta.Sequence.Stream, msg.Data, opts, &stats, report); err != nil {
+			return stats, err
+		}
+
+		if meta.Sequence.Stream >= lastSeq {
+			return stats, nil
+		}
+	}
+}
+
+func (r *Replay) replay(ctx context.Context, seq uint64, data []byte, opts ReplayOptions, stats *ReplayStats, report ReplayReporter) error {
+	var payload inbox.FeedPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		logger(ctx).Warn().Err(err).Uint64("seq", seq).Msg("skip invalid feed event")
+
+		return nil
+	}
+
+	item := convertPayloadToInternal(ctx, payload)
+	if !opts.Filter.Match(item) {
+		return nil
+	}
+
+	stats.Matched++
+
+	limit := 0
+	if opts.MaxPushes > 0 {
+		limit = opts.MaxPushes - stats.Queued
+		if limit <= 0 {
+			return ErrFanoutLimitReached
+		}
+	}
+
+	outcomes, err := r.service.ReplayFeedItem(ctx, item, opts.DryRun, limit)
+	for _, info := range outcomes {
+		if info.Outcome == FanoutQueued {
+			stats.Queued++
+		}
+	}
+
+	if report != nil {
+		report(seq, item, outcomes)
+	}
+
+	if err != nil {
+		return fmt.Errorf("replay feed event %d: %w", seq, err)
+	}
+
+	return nil
+}
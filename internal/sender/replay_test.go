@@ -0,0 +1,178 @@
+package sender
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/goverland-labs/goverland-platform-events/events/inbox"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/require"
+)
+
+func runNatsServer(t *testing.T) *nats.Conn {
+	t.Helper()
+
+	srv, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      server.RANDOM_PORT,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	require.NoError(t, err)
+
+	go srv.Start()
+	require.True(t, srv.ReadyForConnections(5*time.Second))
+
+	nc, err := nats.Connect(srv.ClientURL())
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		nc.Close()
+		srv.Shutdown()
+	})
+
+	return nc
+}
+
+func publishFeed(t *testing.T, nc *nats.Conn, list ...inbox.FeedPayload) {
+	t.Helper()
+
+	js, err := nc.JetStream()
+	require.NoError(t, err)
+
+	_, err = js.AddStream(&nats.StreamConfig{
+		Name:     "str_inbox_feed_updated",
+		Subjects: []string{inbox.SubjectFeedUpdated},
+	})
+	require.NoError(t, err)
+
+	for _, payload := range list {
+		data, err := json.Marshal(payload)
+		require.NoError(t, err)
+
+		_, err = js.Publish(inbox.SubjectFeedUpdated, data)
+		require.NoError(t, err)
+	}
+}
+
+func TestReplayRun(t *testing.T) {
+	daoID := uuid.New()
+	events := []inbox.FeedPayload{
+		{ID: uuid.New(), DaoID: daoID, ProposalID: "proposal_1", Action: inbox.ProposalCreated},
+		{ID: uuid.New(), DaoID: uuid.New(), ProposalID: "proposal_2", Action: inbox.ProposalCreated},
+		{ID: uuid.New(), DaoID: daoID, ProposalID: "proposal_1", Action: inbox.ProposalVotingEnded},
+		{ID: uuid.New(), DaoID: daoID, ProposalID: "proposal_3", Action: inbox.ProposalCreated},
+	}
+
+	queued := func(item Item, users int) []FanoutLog {
+		list := make([]FanoutLog, 0, users)
+		for i := 0; i < users; i++ {
+			list = append(list, newFanoutLog(item, uuid.New(), FanoutQueued))
+		}
+
+		return list
+	}
+
+	for name, tc := range map[string]struct {
+		opts     ReplayOptions
+		replayer func(ctrl *gomock.Controller) FeedReplayer
+		stats    ReplayStats
+		err      error
+	}{
+		"filter by dao in dry run": {
+			opts: ReplayOptions{
+				Filter: ReplayFilter{DaoIDs: []uuid.UUID{daoID}},
+				DryRun: true,
+			},
+			replayer: func(ctrl *gomock.Controller) FeedReplayer {
+				m := NewMockFeedReplayer(ctrl)
+				m.EXPECT().
+					ReplayFeedItem(gomock.Any(), gomock.Any(), true, 0).
+					Times(3).
+					DoAndReturn(func(_ context.Context, item Item, _ bool, _ int) ([]FanoutLog, error) {
+						require.Equal(t, daoID, item.DaoID)
+
+						return queued(item, 1), nil
+					})
+
+				return m
+			},
+			stats: ReplayStats{Events: 4, Matched: 3, Queued: 3},
+		},
+		"filter by proposal and action from sequence": {
+			opts: ReplayOptions{
+				Filter: ReplayFilter{
+					ProposalIDs: []string{"proposal_1"},
+					Actions:     []Action{ProposalVotingEnded},
+				},
+				StartSeq: 2,
+			},
+			replayer: func(ctrl *gomock.Controller) FeedReplayer {
+				m := NewMockFeedReplayer(ctrl)
+				m.EXPECT().
+					ReplayFeedItem(gomock.Any(), convertPayloadToInternal(context.Background(), events[2]), false, 0).
+					Times(1).
+					Return(nil, nil)
+
+				return m
+			},
+			stats: ReplayStats{Events: 3, Matched: 1},
+		},
+		"stop on max pushes": {
+			opts: ReplayOptions{
+				MaxPushes: 3,
+			},
+			replayer: func(ctrl *gomock.Controller) FeedReplayer {
+				m := NewMockFeedReplayer(ctrl)
+				gomock.InOrder(
+					m.EXPECT().
+						ReplayFeedItem(gomock.Any(), gomock.Any(), false, 3).
+						DoAndReturn(func(_ context.Context, item Item, _ bool, _ int) ([]FanoutLog, error) {
+							return queued(item, 2), nil
+						}),
+					m.EXPECT().
+						ReplayFeedItem(gomock.Any(), gomock.Any(), false, 1).
+						DoAndReturn(func(_ context.Context, item Item, _ bool, _ int) ([]FanoutLog, error) {
+							return queued(item, 1), ErrFanoutLimitReached
+						}),
+				)
+
+				return m
+			},
+			stats: ReplayStats{Events: 2, Matched: 2, Queued: 3},
+			err:   ErrFanoutLimitReached,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			nc := runNatsServer(t)
+			publishFeed(t, nc, events...)
+
+			ctrl := gomock.NewController(t)
+			replay := NewReplay(nc, tc.replayer(ctrl))
+			replay.idleTimeout = time.Second
+
+			stats, err := replay.Run(context.Background(), tc.opts, nil)
+			require.ErrorIs(t, err, tc.err)
+			require.Equal(t, tc.stats, stats)
+		})
+	}
+}
+
+func TestReplayRunEmptyStream(t *testing.T) {
+	nc := runNatsServer(t)
+	publishFeed(t, nc)
+
+	ctrl := gomock.NewController(t)
+	replay := NewReplay(nc, NewMockFeedReplayer(ctrl))
+
+	stats, err := replay.Run(context.Background(), ReplayOptions{}, nil)
+	require.NoError(t, err)
+	require.Equal(t, ReplayStats{}, stats)
+}
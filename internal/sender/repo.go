@@ -2,6 +2,7 @@ package sender
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -9,6 +10,8 @@ import (
 	"gorm.io/gorm/clause"
 )
 
+var _ Storage = (*Repo)(nil)
+
 type Repo struct {
 	conn *gorm.DB
 }
@@ -19,13 +22,21 @@ func NewRepo(db *gorm.DB) *Repo {
 	}
 }
 
-func (r *Repo) Create(item *History) error {
-	return r.conn.Create(item).Error
+// Transaction runs fn with the repo bound to the single database transaction
+func (r *Repo) Transaction(ctx context.Context, fn func(tx Storage) error) error {
+	return r.conn.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&Repo{conn: tx})
+	})
+}
+
+func (r *Repo) Create(ctx context.Context, item *History) error {
+	return r.conn.WithContext(ctx).Create(item).Error
 }
 
-func (r *Repo) GetByHash(hash string) (*History, error) {
+func (r *Repo) GetByHash(ctx context.Context, hash string) (*History, error) {
 	var h History
 	err := r.conn.
+		WithContext(ctx).
 		Model(&History{}).
 		Where(&History{
 			Hash: hash,
@@ -40,23 +51,60 @@ func (r *Repo) GetByHash(hash string) (*History, error) {
 	return &h, nil
 }
 
-func (r *Repo) MarkAsClicked(messageUUID uuid.UUID) error {
+// MarkAsClicked marks the message as clicked, the clicked message is read as well
+func (r *Repo) MarkAsClicked(ctx context.Context, messageUUID uuid.UUID) error {
 	var (
 		h History
 		_ = h.Message.ID
 		_ = h.ClickedAt
+		_ = h.ReadAt
+	)
+
+	return r.conn.
+		WithContext(ctx).
+		Model(&History{}).
+		Where("message->>'id' = ?", messageUUID.String()).
+		Updates(map[string]any{
+			"clicked_at": gorm.Expr("now()"),
+			"read_at":    gorm.Expr("coalesce(read_at, now())"),
+		}).
+		Error
+}
+
+func (r *Repo) MarkAsDelivered(ctx context.Context, messageUUID uuid.UUID) error {
+	var (
+		h History
+		_ = h.Message.ID
+		_ = h.DeliveredAt
 	)
 
-	return r.conn.Exec(`
-		update histories set clicked_at = now() 
-		where message->>'id' = ?
-	`, messageUUID).Error
+	return r.conn.
+		WithContext(ctx).
+		Model(&History{}).
+		Where("message->>'id' = ? and delivered_at is null", messageUUID.String()).
+		Update("delivered_at", gorm.Expr("now()")).
+		Error
 }
 
-func (r *Repo) QueueByFilters(_ context.Context, filters []Filter) ([]SendQueue, error) {
-	query := r.conn.Model(&SendQueue{})
+func (r *Repo) MarkAsDismissed(ctx context.Context, messageUUID uuid.UUID) error {
+	var (
+		h History
+		_ = h.Message.ID
+		_ = h.DismissedAt
+	)
+
+	return r.conn.
+		WithContext(ctx).
+		Model(&History{}).
+		Where("message->>'id' = ? and dismissed_at is null", messageUUID.String()).
+		Update("dismissed_at", gorm.Expr("now()")).
+		Error
+}
+
+func (r *Repo) QueueByFilters(ctx context.Context, filters []Filter) ([]SendQueue, error) {
+	query := r.conn.WithContext(ctx).Model(&SendQueue{})
 	for _, f := range filters {
-		f(query)
+		f.apply(query)
 	}
 
 	var list []SendQueue
@@ -65,8 +113,45 @@ func (r *Repo) QueueByFilters(_ context.Context, filters []Filter) ([]SendQueue,
 	return list, err
 }
 
-func (r *Repo) CreateSendQueueRequest(_ context.Context, item *SendQueue) error {
-	return r.conn.
+// HistoriesInBatches passes history rows matched by filters to fn batch by batch in the order of creation
+func (r *Repo) HistoriesInBatches(ctx context.Context, filters []Filter, batchSize int, fn func([]History) error) error {
+	query := r.conn.WithContext(ctx).Model(&History{})
+	for _, f := range filters {
+		f.apply(query)
+	}
+
+	var batch []History
+	return query.FindInBatches(&batch, batchSize, func(_ *gorm.DB, _ int) error {
+		return fn(batch)
+	}).Error
+}
+
+func (r *Repo) PendingQueueStats(ctx context.Context) ([]PendingQueueStats, error) {
+	var (
+		dummy SendQueue
+		_     = dummy.Action
+		_     = dummy.SentAt
+		_     = dummy.CreatedAt
+	)
+
+	var list []PendingQueueStats
+	err := r.conn.
+		WithContext(ctx).
+		Model(&SendQueue{}).
+		Select("action, count(*) as count, min(created_at) as oldest_created_at").
+		Where("sent_at is null").
+		Group("action").
+		Scan(&list).
+		Error
+
+	return list, err
+}
+
+// CreateSendQueueRequest adds the item to the queue and reports whether it was created.
+// The item is not created if the same one is already in the queue.
+func (r *Repo) CreateSendQueueRequest(ctx context.Context, item *SendQueue) (bool, error) {
+	res := r.conn.
+		WithContext(ctx).
 		Model(&SendQueue{}).
 		Clauses(clause.OnConflict{
 			Columns: []clause.Column{
@@ -75,13 +160,22 @@ func (r *Repo) CreateSendQueueRequest(_ context.Context, item *SendQueue) error
 				{Name: "proposal_id"},
 				{Name: "action"},
 			},
+			// only pending items are unique, see idx_send_queue_pending_user_dao_proposal_action
+			TargetWhere: clause.Where{Exprs: []clause.Expression{
+				clause.Expr{SQL: "sent_at is null and deleted_at is null"},
+			}},
 			DoNothing: true,
 		}).
-		Create(item).
-		Error
+		Create(item)
+	if res.Error != nil {
+		return false, res.Error
+	}
+
+	return res.RowsAffected > 0, nil
 }
 
-func (r *Repo) MarkAsSent(_ context.Context, ids []uint) error {
+// MarkAsSent marks queue items as sent by the worker run from the context
+func (r *Repo) MarkAsSent(ctx context.Context, ids []uint) error {
 	if len(ids) == 0 {
 		return nil
 	}
@@ -90,11 +184,771 @@ func (r *Repo) MarkAsSent(_ context.Context, ids []uint) error {
 		dummy SendQueue
 		_     = dummy.ID
 		_     = dummy.SentAt
+		_     = dummy.SentCorrelationID
 	)
 
 	return r.conn.
+		WithContext(ctx).
 		Model(&SendQueue{}).
 		Where("id IN ?", ids).
-		Update("sent_at", time.Now()).
+		Updates(map[string]any{
+			"sent_at":             time.Now(),
+			"sent_correlation_id": CorrelationID(ctx),
+		}).
 		Error
 }
+
+func (r *Repo) CreateFanoutLog(ctx context.Context, list []FanoutLog) error {
+	if len(list) == 0 {
+		return nil
+	}
+
+	return r.conn.WithContext(ctx).Create(&list).Error
+}
+
+func (r *Repo) FanoutLogByFilters(ctx context.Context, filters []Filter) ([]FanoutLog, error) {
+	query := r.conn.WithContext(ctx).Model(&FanoutLog{})
+	for _, f := range filters {
+		f.apply(query)
+	}
+
+	var list []FanoutLog
+	err := query.Order("id").Find(&list).Error
+
+	return list, err
+}
+
+// ClaimInboxEvent stores the event as processing and leases it until lockedUntil. The event which
+// failed before or whose lease is over is claimed again with incremented attempts counter.
+// Processed events and events leased by another attempt are returned unchanged and not claimed.
+func (r *Repo) ClaimInboxEvent(ctx context.Context, subject, key string, lockedUntil time.Time) (*InboxEvent, bool, error) {
+	var (
+		dummy InboxEvent
+		_     = dummy.Subject
+		_     = dummy.EventKey
+		_     = dummy.Status
+		_     = dummy.Attempts
+		_     = dummy.LockedUntil
+	)
+
+	var list []InboxEvent
+	err := r.conn.
+		WithContext(ctx).
+		Raw(`
+			insert into event_inbox (created_at, updated_at, subject, event_key, status, attempts, locked_until)
+			values (now(), now(), @subject, @key, @processing, 1, @locked_until)
+			on conflict (subject, event_key) do update
+			set status = excluded.status, attempts = event_inbox.attempts + 1,
+				locked_until = excluded.locked_until, updated_at = now()
+			where event_inbox.status = @failed
+				or (event_inbox.status = @processing and coalesce(event_inbox.locked_until, now()) <= now())
+			returning *
+		`, map[string]any{
+			"subject":      subject,
+			"key":          key,
+			"processing":   InboxProcessing,
+			"failed":       InboxFailed,
+			"locked_until": lockedUntil,
+		}).
+		Scan(&list).
+		Error
+	if err != nil {
+		return nil, false, err
+	}
+	if len(list) > 0 {
+		return &list[0], true, nil
+	}
+
+	var item InboxEvent
+	err = r.conn.
+		WithContext(ctx).
+		Model(&InboxEvent{}).
+		Where("subject = ? and event_key = ?", subject, key).
+		First(&item).
+		Error
+	if err != nil {
+		return nil, false, err
+	}
+
+	return &item, false, nil
+}
+
+// MarkInboxEvent stores the result of processing the event
+func (r *Repo) MarkInboxEvent(ctx context.Context, id uint, processErr error) error {
+	var (
+		dummy InboxEvent
+		_     = dummy.Status
+		_     = dummy.LastError
+		_     = dummy.ProcessedAt
+		_     = dummy.LockedUntil
+	)
+
+	updates := map[string]any{
+		"status":       InboxProcessed,
+		"last_error":   "",
+		"processed_at": time.Now(),
+		"locked_until": nil,
+	}
+	if processErr != nil {
+		updates = map[string]any{
+			"status":       InboxFailed,
+			"last_error":   processErr.Error(),
+			"locked_until": nil,
+		}
+	}
+
+	return r.conn.
+		WithContext(ctx).
+		Model(&InboxEvent{}).
+		Where("id = ?", id).
+		Updates(updates).
+		Error
+}
+
+// DeleteInboxEventsBefore removes events which were not updated since before, redeliveries
+// of them are not expected anymore
+func (r *Repo) DeleteInboxEventsBefore(ctx context.Context, before time.Time) (int64, error) {
+	var (
+		dummy InboxEvent
+		_     = dummy.UpdatedAt
+	)
+
+	res := r.conn.
+		WithContext(ctx).
+		Unscoped().
+		Where("updated_at < ?", before).
+		Delete(&InboxEvent{})
+
+	return res.RowsAffected, res.Error
+}
+
+func (r *Repo) CreateDeadLetter(ctx context.Context, item *DeadLetter) error {
+	return r.conn.WithContext(ctx).Create(item).Error
+}
+
+func (r *Repo) GetDeadLetter(ctx context.Context, id uint) (*DeadLetter, error) {
+	var item DeadLetter
+	err := r.conn.
+		WithContext(ctx).
+		Model(&DeadLetter{}).
+		Where("id = ?", id).
+		First(&item).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &item, nil
+}
+
+func (r *Repo) ListDeadLetters(ctx context.Context, subject string, limit int) ([]DeadLetter, error) {
+	var (
+		dummy DeadLetter
+		_     = dummy.Subject
+	)
+
+	query := r.conn.WithContext(ctx).Model(&DeadLetter{})
+	if subject != "" {
+		query = query.Where("subject = ?", subject)
+	}
+
+	var list []DeadLetter
+	err := query.
+		Order("id desc").
+		Limit(limit).
+		Find(&list).
+		Error
+
+	return list, err
+}
+
+func (r *Repo) MarkDeadLetterReplayed(ctx context.Context, id uint) error {
+	var (
+		dummy DeadLetter
+		_     = dummy.ReplayedAt
+	)
+
+	return r.conn.
+		WithContext(ctx).
+		Model(&DeadLetter{}).
+		Where("id = ?", id).
+		Update("replayed_at", time.Now()).
+		Error
+}
+
+func (r *Repo) CreateOutboxMessages(ctx context.Context, list []OutboxMessage) error {
+	if len(list) == 0 {
+		return nil
+	}
+
+	return r.conn.WithContext(ctx).Create(&list).Error
+}
+
+// PublishOutbox publishes pending outbox messages in order. Messages are claimed for outboxClaimLease
+// and published after the claim is committed, so a few publishers may run at the same time without
+// holding row locks during publishing. Messages of the crashed publisher are published again once the
+// claim is over, the delivery is at least once. The message which failed outboxMaxAttempts times is
+// marked failed and skipped. It returns the number of published messages.
+func (r *Repo) PublishOutbox(ctx context.Context, limit int, publish func(OutboxMessage) error) (int, error) {
+	var (
+		dummy OutboxMessage
+		_     = dummy.PublishedAt
+		_     = dummy.LockedUntil
+		_     = dummy.FailedAt
+	)
+
+	var list []OutboxMessage
+	err := r.conn.
+		WithContext(ctx).
+		Raw(`
+			with claimed as (
+				update outbox set locked_until = @locked_until, updated_at = now()
+				where id in (
+					select id from outbox
+					where deleted_at is null and published_at is null and failed_at is null
+						and coalesce(locked_until, now()) <= now()
+					order by id
+					limit @limit
+					for update skip locked
+				)
+				returning *
+			)
+			select * from claimed order by id
+		`, map[string]any{
+			"locked_until": time.Now().Add(outboxClaimLease),
+			"limit":        limit,
+		}).
+		Scan(&list).
+		Error
+	if err != nil {
+		return 0, err
+	}
+
+	published := make([]uint, 0, len(list))
+	for idx, msg := range list {
+		if err := publish(msg); err != nil {
+			// keep the order: the rest of messages will be published on the next run
+			return len(published), r.finishOutbox(ctx, published, list[idx:], err)
+		}
+
+		published = append(published, msg.ID)
+	}
+
+	return len(published), r.finishOutbox(ctx, published, nil, nil)
+}
+
+// finishOutbox marks published messages and releases the claim of not published ones,
+// the first of them is the one which failed with publishErr
+func (r *Repo) finishOutbox(ctx context.Context, published []uint, rest []OutboxMessage, publishErr error) error {
+	var (
+		dummy OutboxMessage
+		_     = dummy.PublishedAt
+		_     = dummy.Attempts
+		_     = dummy.LastError
+		_     = dummy.LockedUntil
+		_     = dummy.FailedAt
+	)
+
+	return r.conn.WithContext(context.WithoutCancel(ctx)).Transaction(func(tx *gorm.DB) error {
+		if len(published) > 0 {
+			err := tx.
+				Model(&OutboxMessage{}).
+				Where("id in ?", published).
+				Updates(map[string]any{
+					"published_at": time.Now(),
+					"locked_until": nil,
+				}).
+				Error
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(rest) == 0 {
+			return nil
+		}
+
+		updates := map[string]any{
+			"attempts":     gorm.Expr("attempts + 1"),
+			"last_error":   publishErr.Error(),
+			"locked_until": nil,
+		}
+		if rest[0].Attempts+1 >= outboxMaxAttempts {
+			updates["failed_at"] = time.Now()
+		}
+
+		err := tx.
+			Model(&OutboxMessage{}).
+			Where("id = ?", rest[0].ID).
+			Updates(updates).
+			Error
+		if err != nil {
+			return err
+		}
+
+		ids := make([]uint, 0, len(rest))
+		for _, msg := range rest[1:] {
+			ids = append(ids, msg.ID)
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		return tx.
+			Model(&OutboxMessage{}).
+			Where("id in ?", ids).
+			Update("locked_until", nil).
+			Error
+	})
+}
+
+func (r *Repo) DeleteOutboxPublishedBefore(ctx context.Context, before time.Time) error {
+	var (
+		dummy OutboxMessage
+		_     = dummy.PublishedAt
+	)
+
+	return r.conn.
+		WithContext(ctx).
+		Unscoped().
+		Where("published_at < ?", before).
+		Delete(&OutboxMessage{}).
+		Error
+}
+
+// ReserveDedupKey stores the key and reports whether it was reserved. The key is not reserved
+// if it is already stored and not expired yet.
+func (r *Repo) ReserveDedupKey(ctx context.Context, key string, expiresAt *time.Time) (bool, error) {
+	var (
+		dummy PushDedup
+		_     = dummy.Key
+		_     = dummy.ExpiresAt
+	)
+
+	res := r.conn.
+		WithContext(ctx).
+		Exec(`
+			insert into push_dedup (created_at, updated_at, key, expires_at)
+			values (now(), now(), @key, @expires_at)
+			on conflict (key) do update
+			set created_at = now(), updated_at = now(), expires_at = excluded.expires_at
+			where push_dedup.expires_at is not null and push_dedup.expires_at <= now()
+		`, map[string]any{
+			"key":        key,
+			"expires_at": expiresAt,
+		})
+
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *Repo) ReleaseDedupKey(ctx context.Context, key string) error {
+	var (
+		dummy PushDedup
+		_     = dummy.Key
+	)
+
+	return r.conn.
+		WithContext(ctx).
+		Unscoped().
+		Where("key = ?", key).
+		Delete(&PushDedup{}).
+		Error
+}
+
+func (r *Repo) DeleteExpiredDedupKeys(ctx context.Context, now time.Time) (int64, error) {
+	var (
+		dummy PushDedup
+		_     = dummy.ExpiresAt
+	)
+
+	res := r.conn.
+		WithContext(ctx).
+		Unscoped().
+		Where("expires_at <= ?", now).
+		Delete(&PushDedup{})
+
+	return res.RowsAffected, res.Error
+}
+
+var clickStatsGroupExpr = map[ClickStatsGroup]string{
+	GroupByTemplate: "message->>'template_id'",
+	GroupByAction:   "coalesce(nullif(action, ''), 'mixed')",
+	GroupByDao:      "coalesce(dao_id, 'mixed')",
+	GroupByDevice:   "message->>'device_uuid'",
+	GroupByDay:      "to_char(created_at at time zone 'UTC', 'YYYY-MM-DD')",
+	GroupByVariant:  "coalesce(message->>'variant_id', 'none')",
+}
+
+func (r *Repo) ClickStats(ctx context.Context, query ClickStatsQuery) ([]ClickStats, error) {
+	var (
+		dummy History
+		_     = dummy.Message.ID
+		_     = dummy.Message.TemplateID
+		_     = dummy.Message.DeviceUUID
+		_     = dummy.Action
+		_     = dummy.DaoID
+		_     = dummy.ClickedAt
+	)
+
+	groupExpr, ok := clickStatsGroupExpr[query.GroupBy]
+	if !ok {
+		return nil, fmt.Errorf("unsupported group: %s", query.GroupBy)
+	}
+
+	// each device has own history row, but clicks are marked by message id for all of them
+	cond := "deleted_at is null and created_at >= @from and created_at < @to"
+	if query.ExperimentID != "" {
+		cond += " and message->>'experiment_id' = @experiment_id"
+	}
+
+	source := fmt.Sprintf(`(
+		select distinct on (message->>'id') *
+		from histories
+		where %s
+		order by message->>'id', created_at
+	) h`, cond)
+	if query.GroupBy == GroupByDevice {
+		source = fmt.Sprintf(`(
+			select * from histories
+			where %s
+		) h`, cond)
+	}
+
+	ttc := "extract(epoch from clicked_at - created_at)"
+	sql := fmt.Sprintf(`
+		select %[1]s as key,
+			count(*) as sends,
+			count(clicked_at) as clicks,
+			count(delivered_at) as delivered,
+			count(dismissed_at) as dismissed,
+			percentile_cont(0.5) within group (order by %[3]s) filter (where clicked_at is not null) as time_to_click_p50,
+			percentile_cont(0.9) within group (order by %[3]s) filter (where clicked_at is not null) as time_to_click_p90,
+			percentile_cont(0.99) within group (order by %[3]s) filter (where clicked_at is not null) as time_to_click_p99
+		from %[2]s
+		group by 1
+		order by sends desc
+	`, groupExpr, source, ttc)
+
+	var list []ClickStats
+	err := r.conn.
+		WithContext(ctx).
+		Raw(sql, map[string]any{
+			"from":          query.From,
+			"to":            query.To,
+			"experiment_id": query.ExperimentID,
+		}).
+		Scan(&list).
+		Error
+
+	return list, err
+}
+
+// Notifications returns the latest history rows of the user, one per message,
+// created before the cursor position.
+func (r *Repo) Notifications(ctx context.Context, userID uuid.UUID, before *NotificationCursor, limit int) ([]History, error) {
+	var (
+		dummy History
+		_     = dummy.UserID
+		_     = dummy.Message.ID
+	)
+
+	cond := ""
+	params := map[string]any{
+		"user_id": userID,
+		"limit":   limit,
+	}
+	if before != nil {
+		cond = "where (h.created_at, h.id) < (@created_at, @id)"
+		params["created_at"] = before.CreatedAt
+		params["id"] = before.ID
+	}
+
+	// each device has own history row with the same message id
+	sql := fmt.Sprintf(`
+		select * from (
+			select distinct on (message->>'id') *
+			from histories
+			where deleted_at is null and user_id = @user_id
+			order by message->>'id', id
+		) h
+		%s
+		order by h.created_at desc, h.id desc
+		limit @limit
+	`, cond)
+
+	var list []History
+	err := r.conn.
+		WithContext(ctx).
+		Raw(sql, params).
+		Scan(&list).
+		Error
+
+	return list, err
+}
+
+func (r *Repo) UnreadNotificationsCount(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var (
+		dummy History
+		_     = dummy.UserID
+		_     = dummy.Message.ID
+		_     = dummy.ReadAt
+	)
+
+	var count int64
+	err := r.conn.
+		WithContext(ctx).
+		Raw(`
+			select count(distinct message->>'id')
+			from histories
+			where deleted_at is null and user_id = ? and read_at is null
+		`, userID).
+		Scan(&count).
+		Error
+
+	return count, err
+}
+
+// MarkAsRead marks user messages as read. All user messages are marked if ids are empty.
+func (r *Repo) MarkAsRead(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) error {
+	var (
+		dummy History
+		_     = dummy.UserID
+		_     = dummy.Message.ID
+		_     = dummy.ReadAt
+	)
+
+	query := r.conn.
+		WithContext(ctx).
+		Model(&History{}).
+		Where("user_id = ? and read_at is null", userID)
+
+	if len(ids) > 0 {
+		list := make([]string, 0, len(ids))
+		for _, id := range ids {
+			list = append(list, id.String())
+		}
+
+		query = query.Where("message->>'id' in ?", list)
+	}
+
+	return query.Update("read_at", gorm.Expr("now()")).Error
+}
+
+func (r *Repo) CreateBroadcast(ctx context.Context, item *Broadcast) error {
+	return r.conn.WithContext(ctx).Create(item).Error
+}
+
+func (r *Repo) GetBroadcast(ctx context.Context, id uint) (*Broadcast, error) {
+	var item Broadcast
+	err := r.conn.
+		WithContext(ctx).
+		Model(&Broadcast{}).
+		Where("id = ?", id).
+		First(&item).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &item, nil
+}
+
+func (r *Repo) ListBroadcasts(ctx context.Context, limit int) ([]Broadcast, error) {
+	var list []Broadcast
+	err := r.conn.
+		WithContext(ctx).
+		Model(&Broadcast{}).
+		Order("id desc").
+		Limit(limit).
+		Find(&list).
+		Error
+
+	return list, err
+}
+
+// UpdateBroadcast updates the broadcast only if it is in one of the statuses,
+// it reports whether the broadcast was updated
+func (r *Repo) UpdateBroadcast(ctx context.Context, id uint, in []BroadcastStatus, updates map[string]any) (bool, error) {
+	var (
+		dummy Broadcast
+		_     = dummy.Status
+	)
+
+	res := r.conn.
+		WithContext(ctx).
+		Model(&Broadcast{}).
+		Where("id = ? and status in ?", id, in).
+		Updates(updates)
+	if res.Error != nil {
+		return false, res.Error
+	}
+
+	return res.RowsAffected > 0, nil
+}
+
+// ClaimDueBroadcast moves the earliest scheduled broadcast to sending, so only one instance sends it.
+// The broadcast which is sending without progress since staleBefore is claimed again, e.g. after
+// the crash of the instance which was sending it. It returns nil if there is nothing to send.
+func (r *Repo) ClaimDueBroadcast(ctx context.Context, now, staleBefore time.Time) (*Broadcast, error) {
+	var (
+		dummy Broadcast
+		_     = dummy.Status
+		_     = dummy.ScheduledAt
+		_     = dummy.StartedAt
+		_     = dummy.UpdatedAt
+	)
+
+	var list []Broadcast
+	err := r.conn.
+		WithContext(ctx).
+		Raw(`
+			update broadcasts
+			set status = @sending, started_at = @now, updated_at = @now
+			where id = (
+				select id from broadcasts
+				where deleted_at is null and (
+					(status = @scheduled and scheduled_at <= @now) or
+					(status = @sending and updated_at <= @stale_before)
+				)
+				order by scheduled_at, id
+				limit 1
+				for update skip locked
+			)
+			returning *
+		`, map[string]any{
+			"sending":      BroadcastSending,
+			"scheduled":    BroadcastScheduled,
+			"now":          now,
+			"stale_before": staleBefore,
+		}).
+		Scan(&list).
+		Error
+	if err != nil || len(list) == 0 {
+		return nil, err
+	}
+
+	return &list[0], nil
+}
+
+// KnownUserIDs returns users the service has ever queued or sent a push to, users who registered
+// devices but never got a push are unknown to the service
+func (r *Repo) KnownUserIDs(ctx context.Context) ([]uuid.UUID, error) {
+	var (
+		dummyHistory History
+		_            = dummyHistory.UserID
+		dummyQueue   SendQueue
+		_            = dummyQueue.UserID
+	)
+
+	var list []uuid.UUID
+	err := r.conn.
+		WithContext(ctx).
+		Raw(`
+			select user_id from histories where deleted_at is null
+			union
+			select user_id from send_queue where deleted_at is null
+		`).
+		Scan(&list).
+		Error
+
+	return list, err
+}
+
+// QueueItems returns the latest queue items matched by filters
+func (r *Repo) QueueItems(ctx context.Context, filters []Filter, limit int) ([]SendQueue, error) {
+	query := r.conn.WithContext(ctx).Model(&SendQueue{})
+	for _, f := range filters {
+		f.apply(query)
+	}
+
+	var list []SendQueue
+	err := query.
+		Order("id desc").
+		Limit(limit).
+		Find(&list).
+		Error
+
+	return list, err
+}
+
+// CancelQueueItems soft deletes pending items and returns ids of cancelled ones
+func (r *Repo) CancelQueueItems(ctx context.Context, ids []uint) ([]uint, error) {
+	var (
+		dummy SendQueue
+		_     = dummy.SentAt
+		_     = dummy.DeletedAt
+	)
+
+	cancelled := make([]uint, 0, len(ids))
+	err := r.conn.
+		WithContext(ctx).
+		Raw(`
+			update send_queue set deleted_at = now()
+			where id in ? and sent_at is null and deleted_at is null
+			returning id
+		`, ids).
+		Scan(&cancelled).
+		Error
+
+	return cancelled, err
+}
+
+// RequeueQueueItems resets sent items to pending and returns ids of requeued ones.
+// The item is not requeued if the same one is already pending, of the same items in ids
+// only the one with the lowest id is requeued.
+func (r *Repo) RequeueQueueItems(ctx context.Context, ids []uint) ([]uint, error) {
+	var (
+		dummy SendQueue
+		_     = dummy.SentAt
+		_     = dummy.SentCorrelationID
+	)
+
+	requeued := make([]uint, 0, len(ids))
+	err := r.conn.
+		WithContext(ctx).
+		Raw(`
+			update send_queue q set sent_at = null, sent_correlation_id = null, updated_at = now()
+			where q.id in (
+					select distinct on (c.user_id, c.dao_id, c.proposal_id, c.action) c.id
+					from send_queue c
+					where c.id in ? and c.sent_at is not null and c.deleted_at is null
+					order by c.user_id, c.dao_id, c.proposal_id, c.action, c.id
+				)
+				and not exists (
+					select 1 from send_queue p
+					where p.user_id = q.user_id and p.dao_id = q.dao_id
+						and p.proposal_id = q.proposal_id and p.action = q.action
+						and p.sent_at is null and p.deleted_at is null
+				)
+			returning q.id
+		`, ids).
+		Scan(&requeued).
+		Error
+
+	return requeued, err
+}
+
+func (r *Repo) CreateAuditLog(ctx context.Context, item *AuditLog) error {
+	return r.conn.WithContext(ctx).Create(item).Error
+}
+
+func (r *Repo) ListAuditLog(ctx context.Context, action string, limit int) ([]AuditLog, error) {
+	var (
+		dummy AuditLog
+		_     = dummy.Action
+	)
+
+	query := r.conn.WithContext(ctx).Model(&AuditLog{})
+	if action != "" {
+		query = query.Where("action = ?", action)
+	}
+
+	var list []AuditLog
+	err := query.
+		Order("id desc").
+		Limit(limit).
+		Find(&list).
+		Error
+
+	return list, err
+}
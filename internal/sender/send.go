@@ -11,14 +11,19 @@ import (
 	"github.com/goverland-labs/goverland-core-sdk-go/dao"
 	"github.com/goverland-labs/goverland-core-sdk-go/proposal"
 	"github.com/goverland-labs/goverland-inbox-api-protocol/protobuf/inboxapi"
-	"github.com/rs/zerolog/log"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
-func (s *Service) sendBatch(ctx context.Context) error {
+// sendBatch sends pending items grouped by user, extra filters narrow down the items, e.g. to one user
+func (s *Service) sendBatch(ctx context.Context, extra ...Filter) (err error) {
+	ctx, span := tracer().Start(ctx, "send batch")
+	defer func() {
+		endSpan(span, err)
+	}()
+
 	// get list from queue
-	list, err := s.repo.QueueByFilters(ctx, []Filter{
+	list, err := s.repo.QueueByFilters(ctx, append([]Filter{
 		AvailableForSending(),
 		ActionNotIn(
 			string(ProposalVotingEndsSoon),
@@ -26,7 +31,7 @@ func (s *Service) sendBatch(ctx context.Context) error {
 			string(DelegateVotingVoted),
 			string(DelegateVotingSkipVote),
 		),
-	})
+	}, extra...))
 	if err != nil {
 		return fmt.Errorf("s.repo.GetInQueue: %w", err)
 	}
@@ -43,11 +48,12 @@ func (s *Service) sendBatch(ctx context.Context) error {
 	}
 
 	sent := make([]uint, 0, len(list))
+	skipped := make([]SendQueue, 0)
 	defer func() {
-		log.Info().Msgf("marking as sent ids: %v", sent)
+		logger(ctx).Info().Uints("ids", sent).Msg("marking as sent")
 
-		if err := s.repo.MarkAsSent(context.TODO(), sent); err != nil {
-			log.Error().Err(err).Msg("mark as sent")
+		if err := s.markAsSent(context.WithoutCancel(ctx), sent, skipped); err != nil {
+			logger(ctx).Error().Err(err).Msg("mark as sent")
 		}
 	}()
 
@@ -58,30 +64,31 @@ func (s *Service) sendBatch(ctx context.Context) error {
 			return fmt.Errorf("s.usrs.AllowSendingPush: %w", err)
 		}
 		if !res.Allow {
-			log.Info().Msgf("user is not allow to recieve push: %s", userID.String())
+			logger(ctx).Info().Stringer("user_id", userID).Msg("user is not allowed to receive push")
 
 			continue
 		}
 
-		allowedActions, err := s.getAllowedSendActions(userID)
+		allowedActions, err := s.getAllowedSendActions(ctx, userID)
 		if err != nil {
 			return fmt.Errorf("s.getAllowedSendActions: %w", err)
 		}
 
-		log.Info().Msgf("user %s allowed actions: %v", userID.String(), allowedActions)
+		logger(ctx).Info().Stringer("user_id", userID).Any("actions", allowedActions).Msg("user allowed actions")
 
 		// filter only supported actions by user cfg
 		supported := make([]SendQueue, 0, len(details))
 		for _, info := range details {
 			if !allowedActions.Contains(info.Action) {
 				sent = append(sent, info.ID)
+				skipped = append(skipped, info)
 				continue
 			}
 
 			supported = append(supported, info)
 		}
 
-		log.Info().Msgf("user %s supported to recieve: %v", userID.String(), supported)
+		logger(ctx).Info().Stringer("user_id", userID).Uints("ids", queueIDs(supported)).Msg("user supported to receive")
 
 		// if no supported, do not send anything
 		if len(supported) == 0 {
@@ -92,10 +99,13 @@ func (s *Service) sendBatch(ctx context.Context) error {
 		if err != nil {
 			return fmt.Errorf("s.prepareBatchReq: %w", err)
 		}
+		req.links = queueLinks(supported)
 		if err := s.Send(ctx, req); err != nil {
 			return fmt.Errorf("s.Send: %w", err)
 		}
 
+		observeSendDelay(supported, req.template)
+
 		collectStats("send", "batch", err)
 
 		for _, info := range details {
@@ -112,7 +122,11 @@ func (s *Service) prepareBatchReq(ctx context.Context, userID uuid.UUID, details
 	}
 
 	req := request{
-		userID: userID,
+		userID:  userID,
+		actions: uniqueActions(details),
+		vars: copyVars{
+			Proposals: len(details),
+		},
 	}
 
 	daoByID := map[uuid.UUID]struct{}{}
@@ -127,6 +141,7 @@ func (s *Service) prepareBatchReq(ctx context.Context, userID uuid.UUID, details
 		proposals = append(proposals, info.ProposalID)
 		req.proposals = append(req.proposals, info.ProposalID)
 	}
+	req.daos = daos
 
 	if len(daos) >= 2 {
 		req.title = "Goverland"
@@ -142,6 +157,7 @@ func (s *Service) prepareBatchReq(ctx context.Context, userID uuid.UUID, details
 
 			names[idx] = dd.Name
 		}
+		req.vars.DaoNames = prepareVotingEndsSoonNames(names)
 		if len(daos) > 2 {
 			req.body = fmt.Sprintf("%s, %s, and more have updates on proposals.", names[0], names[1])
 		} else {
@@ -157,6 +173,7 @@ func (s *Service) prepareBatchReq(ctx context.Context, userID uuid.UUID, details
 		return req, fmt.Errorf("s.getDao: %w", err)
 	}
 	req.imageURL = generateDaoIcon(dd.Alias)
+	req.vars.DaoName = dd.Name
 
 	if len(proposals) > 1 {
 		req.template = templateIDOneDaoFewProposal
@@ -173,6 +190,7 @@ func (s *Service) prepareBatchReq(ctx context.Context, userID uuid.UUID, details
 
 	req.title = fmt.Sprintf("%s: %s", dd.Name, convertActionToTitle(details[0].Action))
 	req.body = pr.Title
+	req.vars.ProposalTitle = pr.Title
 	req.template = templateIDOneDaoOneProposal
 
 	return req, nil
@@ -191,11 +209,16 @@ func convertActionToTitle(action Action) string {
 	}
 }
 
-func (s *Service) sendVotingEndsSoon(ctx context.Context) error {
-	list, err := s.repo.QueueByFilters(ctx, []Filter{
+func (s *Service) sendVotingEndsSoon(ctx context.Context, extra ...Filter) (err error) {
+	ctx, span := tracer().Start(ctx, "send voting ends soon")
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	list, err := s.repo.QueueByFilters(ctx, append([]Filter{
 		AvailableForSending(),
 		ActionIn(string(ProposalVotingEndsSoon)),
-	})
+	}, extra...))
 	if err != nil {
 		return fmt.Errorf("s.repo.GetInQueue: %w", err)
 	}
@@ -205,11 +228,12 @@ func (s *Service) sendVotingEndsSoon(ctx context.Context) error {
 	}
 
 	sent := make([]uint, 0, len(list))
-	defer func(ids []uint) {
-		if err := s.repo.MarkAsSent(context.TODO(), sent); err != nil {
-			log.Error().Err(err).Msg("mark as sent")
+	skipped := make([]SendQueue, 0)
+	defer func() {
+		if err := s.markAsSent(context.WithoutCancel(ctx), sent, skipped); err != nil {
+			logger(ctx).Error().Err(err).Msg("mark as sent")
 		}
-	}(sent)
+	}()
 
 	// group by user_id
 	batches := make(map[uuid.UUID][]SendQueue)
@@ -226,31 +250,46 @@ func (s *Service) sendVotingEndsSoon(ctx context.Context) error {
 			return fmt.Errorf("s.prepareVotingEndsSoonReq: %s: %w", userID, err)
 		}
 		if req != nil {
+			req.links = queueLinks(details)
 			err = s.Send(ctx, *req)
 			if err != nil {
 				return fmt.Errorf("s.Send: %w", err)
 			}
 
+			observeSendDelay(slices.DeleteFunc(slices.Clone(details), func(info SendQueue) bool {
+				return !slices.Contains(req.proposals, info.ProposalID)
+			}), req.template)
+
 			collectStats("send", "voting_ends_soon", err)
 		}
 
 		for _, info := range details {
 			sent = append(sent, info.ID)
+
+			// the user has already voted for the proposal
+			if req == nil || !slices.Contains(req.proposals, info.ProposalID) {
+				skipped = append(skipped, info)
+			}
 		}
 	}
 
 	return nil
 }
 
-func (s *Service) sendDelegates(ctx context.Context) error {
-	list, err := s.repo.QueueByFilters(ctx, []Filter{
+func (s *Service) sendDelegates(ctx context.Context, extra ...Filter) (err error) {
+	ctx, span := tracer().Start(ctx, "send delegates")
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	list, err := s.repo.QueueByFilters(ctx, append([]Filter{
 		AvailableForSending(),
 		ActionIn(
 			string(DelegateCreateProposal),
 			string(DelegateVotingVoted),
 			string(DelegateVotingSkipVote),
 		),
-	})
+	}, extra...))
 	if err != nil {
 		return fmt.Errorf("s.repo.GetInQueue: %w", err)
 	}
@@ -260,23 +299,26 @@ func (s *Service) sendDelegates(ctx context.Context) error {
 	}
 
 	sent := make([]uint, 0, len(list))
-	defer func(ids []uint) {
-		if err := s.repo.MarkAsSent(context.TODO(), sent); err != nil {
-			log.Error().Err(err).Msg("mark as sent")
+	defer func() {
+		if err := s.markAsSent(context.WithoutCancel(ctx), sent, nil); err != nil {
+			logger(ctx).Error().Err(err).Msg("mark as sent")
 		}
-	}(sent)
+	}()
 
 	for _, info := range list {
 		req, err := s.prepareDelegationPush(ctx, info)
 		if err != nil {
 			return fmt.Errorf("s.prepareDelegationPush: %d: %w", info.ID, err)
 		}
+		req.links = queueLinks([]SendQueue{info})
 
 		err = s.Send(ctx, req)
 		if err != nil {
 			return fmt.Errorf("s.Send: %w", err)
 		}
 
+		observeSendDelay([]SendQueue{info}, req.template)
+
 		collectStats("send", string(info.Action), err)
 
 		sent = append(sent, info.ID)
@@ -301,6 +343,10 @@ func (s *Service) prepareVotingEndsSoonReq(ctx context.Context, userID uuid.UUID
 	req := request{
 		userID:   userID,
 		template: templateIDVoteFinishesSoon,
+		actions:  []Action{ProposalVotingEndsSoon},
+		vars: copyVars{
+			Proposals: len(filtered),
+		},
 	}
 
 	daoByID := map[uuid.UUID]struct{}{}
@@ -315,6 +361,7 @@ func (s *Service) prepareVotingEndsSoonReq(ctx context.Context, userID uuid.UUID
 		proposals = append(proposals, info.ProposalID)
 		req.proposals = append(req.proposals, info.ProposalID)
 	}
+	req.daos = daos
 
 	if len(daos) >= 2 {
 		req.title = "Votes finish soon"
@@ -329,7 +376,8 @@ func (s *Service) prepareVotingEndsSoonReq(ctx context.Context, userID uuid.UUID
 			names = append(names, dd.Name)
 		}
 
-		req.body = fmt.Sprintf("%d active proposals in %s will finish soon.", len(req.proposals), prepareVotingEndsSoonNames(names))
+		req.vars.DaoNames = prepareVotingEndsSoonNames(names)
+		req.body = fmt.Sprintf("%d active proposals in %s will finish soon.", len(req.proposals), req.vars.DaoNames)
 
 		return &req, nil
 	}
@@ -340,6 +388,7 @@ func (s *Service) prepareVotingEndsSoonReq(ctx context.Context, userID uuid.UUID
 	}
 	req.imageURL = generateDaoIcon(dd.Alias)
 	req.title = fmt.Sprintf("%s: Votes finish soon", dd.Name)
+	req.vars.DaoName = dd.Name
 
 	if len(proposals) > 1 {
 		req.body = fmt.Sprintf("%d active proposals in %s will finish soon.", len(req.proposals), dd.Name)
@@ -352,6 +401,7 @@ func (s *Service) prepareVotingEndsSoonReq(ctx context.Context, userID uuid.UUID
 		return nil, fmt.Errorf("s.getProposal: %w", err)
 	}
 	req.body = pr.Title
+	req.vars.ProposalTitle = pr.Title
 
 	return &req, nil
 }
@@ -360,6 +410,8 @@ func (s *Service) prepareDelegationPush(ctx context.Context, info SendQueue) (re
 	req := request{
 		userID:    info.UserID,
 		proposals: []string{info.ProposalID},
+		daos:      []uuid.UUID{info.DaoID},
+		actions:   []Action{info.Action},
 	}
 
 	dd, err := s.getDao(ctx, info.DaoID)
@@ -375,6 +427,11 @@ func (s *Service) prepareDelegationPush(ctx context.Context, info SendQueue) (re
 	}
 
 	req.title = dd.Name
+	req.vars = copyVars{
+		DaoName:       dd.Name,
+		ProposalTitle: pr.Title,
+		Proposals:     1,
+	}
 
 	switch info.Action {
 	case DelegateCreateProposal:
@@ -391,6 +448,42 @@ func (s *Service) prepareDelegationPush(ctx context.Context, info SendQueue) (re
 	return req, nil
 }
 
+// markAsSent marks queue items as processed and stores events about the skipped ones
+func (s *Service) markAsSent(ctx context.Context, ids []uint, skipped []SendQueue) error {
+	events := make([]OutboxMessage, 0, len(skipped))
+	for _, info := range skipped {
+		events = append(events, queueItemEvent(SubjectPushSkipped, info))
+	}
+
+	return s.repo.Transaction(ctx, func(tx Storage) error {
+		if err := tx.MarkAsSent(ctx, ids); err != nil {
+			return err
+		}
+
+		return tx.CreateOutboxMessages(ctx, events)
+	})
+}
+
+func queueIDs(details []SendQueue) []uint {
+	ids := make([]uint, 0, len(details))
+	for _, info := range details {
+		ids = append(ids, info.ID)
+	}
+
+	return ids
+}
+
+func uniqueActions(details []SendQueue) []Action {
+	actions := make([]Action, 0, len(details))
+	for _, info := range details {
+		if !slices.Contains(actions, info.Action) {
+			actions = append(actions, info.Action)
+		}
+	}
+
+	return actions
+}
+
 func prepareVotingEndsSoonNames(names []string) string {
 	switch len(names) {
 	case 0:
@@ -447,9 +540,9 @@ func (s *Service) getNotVotedDetails(ctx context.Context, userID uuid.UUID, deta
 	return response, nil
 }
 
-func (s *Service) getAllowedSendActions(userID uuid.UUID) (Actions, error) {
+func (s *Service) getAllowedSendActions(ctx context.Context, userID uuid.UUID) (Actions, error) {
 	result := make(Actions, 0, 10)
-	details, err := s.settings.GetPushDetails(context.Background(), &inboxapi.GetPushDetailsRequest{UserId: userID.String()})
+	details, err := s.settings.GetPushDetails(ctx, &inboxapi.GetPushDetailsRequest{UserId: userID.String()})
 	if err != nil {
 		return nil, fmt.Errorf("s.settings.GetPushDetails: %w", err)
 	}
@@ -485,7 +578,7 @@ func (s *Service) getDao(ctx context.Context, id uuid.UUID) (*dao.Dao, error) {
 	defer s.mu.Unlock()
 
 	val, ok := s.cache[key]
-	if ok && time.Now().Before(val.expireAt) {
+	if ok && s.now().Before(val.expireAt) {
 		return val.data.(*dao.Dao), nil
 	}
 
@@ -495,7 +588,7 @@ func (s *Service) getDao(ctx context.Context, id uuid.UUID) (*dao.Dao, error) {
 	}
 
 	s.cache[key] = cacheItem{
-		expireAt: time.Now().Add(time.Hour),
+		expireAt: s.now().Add(time.Hour),
 		data:     dao,
 	}
 
@@ -509,7 +602,7 @@ func (s *Service) getProposal(ctx context.Context, id string) (*proposal.Proposa
 	defer s.mu.Unlock()
 
 	val, ok := s.cache[key]
-	if ok && time.Now().Before(val.expireAt) {
+	if ok && s.now().Before(val.expireAt) {
 		return val.data.(*proposal.Proposal), nil
 	}
 
@@ -519,7 +612,7 @@ func (s *Service) getProposal(ctx context.Context, id string) (*proposal.Proposa
 	}
 
 	s.cache[key] = cacheItem{
-		expireAt: time.Now().Add(time.Hour),
+		expireAt: s.now().Add(time.Hour),
 		data:     pr,
 	}
 
@@ -1,6 +1,7 @@
 package sender
 
 import (
+	"context"
 	"errors"
 	"testing"
 
@@ -195,7 +196,7 @@ func Test_getAllowedSendActions(t *testing.T) {
 				settings: tc.sp(ctrl),
 			}
 
-			actual, err := service.getAllowedSendActions(uuid.New())
+			actual, err := service.getAllowedSendActions(context.Background(), uuid.New())
 			if tc.wantErr {
 				require.Error(t, err)
 				return
@@ -206,3 +207,13 @@ func Test_getAllowedSendActions(t *testing.T) {
 		})
 	}
 }
+
+func Test_uniqueActions(t *testing.T) {
+	actual := uniqueActions([]SendQueue{
+		{Action: ProposalCreated},
+		{Action: ProposalVotingEnded},
+		{Action: ProposalCreated},
+	})
+
+	require.Equal(t, []Action{ProposalCreated, ProposalVotingEnded}, actual)
+}
@@ -2,10 +2,7 @@ package sender
 
 import (
 	"context"
-	"crypto/md5"
-	"encoding/hex"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -18,10 +15,11 @@ import (
 	"github.com/goverland-labs/goverland-core-sdk-go/dao"
 	"github.com/goverland-labs/goverland-core-sdk-go/proposal"
 	"github.com/goverland-labs/goverland-inbox-api-protocol/protobuf/inboxapi"
-	"github.com/rs/zerolog/log"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/api/option"
 	"google.golang.org/grpc"
-	"gorm.io/gorm"
 
 	"github.com/goverland-labs/goverland-inbox-push/internal/config"
 )
@@ -42,6 +40,10 @@ type SettingsProvider interface {
 	GetPushTokenList(ctx context.Context, in *inboxapi.GetPushTokenListRequest, opts ...grpc.CallOption) (*inboxapi.PushTokenListResponse, error)
 }
 
+type EventPublisher interface {
+	PublishJSON(ctx context.Context, subject string, obj any) error
+}
+
 type MessageSender interface {
 	Send(ctx context.Context, message *messaging.Message) (string, error)
 }
@@ -52,67 +54,71 @@ type CoreDataProvider interface {
 	GetProposal(ctx context.Context, id string) (*proposal.Proposal, error)
 }
 
-type DataManipulator interface {
-	Create(item *History) error
-	GetByHash(hash string) (*History, error)
-	MarkAsClicked(messageUUID uuid.UUID) error
-	QueueByFilters(_ context.Context, filters []Filter) ([]SendQueue, error)
-	CreateSendQueueRequest(_ context.Context, item *SendQueue) error
-	MarkAsSent(_ context.Context, ids []uint) error
-}
-
 type cacheItem struct {
 	expireAt time.Time
 	data     any
 }
 
 type Service struct {
-	repo          *Repo
+	repo          Storage
 	subscriptions SubscriptionsFinder
 	usrs          UsersFinder
 	settings      SettingsProvider
 	core          CoreDataProvider
 	sender        MessageSender
 
+	dedup       DedupPolicy
+	experiments *Experiments
+
 	cache map[string]cacheItem
 	mu    sync.Mutex
 
-	cfg       []byte
-	projectID string
+	// projects route messages to firebase projects, it is the same as sender in production
+	projects *FirebaseProjects
+	// clock returns the current time, time.Now is used if it is nil
+	clock func() time.Time
 }
 
+// NewService creates the service, firebase specific checks are enabled when ms is *FirebaseProjects
 func NewService(
-	r *Repo,
-	cfg config.Push,
+	r Storage,
+	ms MessageSender,
+	dedup DedupPolicy,
+	experiments *Experiments,
 	subs SubscriptionsFinder,
 	usrs UsersFinder,
 	sp SettingsProvider,
 	coreSDK *coresdk.Client,
 ) (*Service, error) {
-	data, err := json.Marshal(cfg)
-	if err != nil {
-		return nil, err
-	}
-
-	// todo: check if it can live days...
-	sender, err := makeSender(context.Background(), data, cfg.ProjectID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make sender: %w", err)
-	}
+	projects, _ := ms.(*FirebaseProjects)
 
 	return &Service{
 		repo:          r,
 		subscriptions: subs,
 		usrs:          usrs,
 		settings:      sp,
-		cfg:           data,
-		projectID:     cfg.ProjectID,
-		sender:        sender,
+		sender:        ms,
+		projects:      projects,
 		core:          coreSDK,
+		dedup:         dedup,
+		experiments:   experiments,
 		cache:         make(map[string]cacheItem),
 	}, nil
 }
 
+// SetClock replaces the source of the current time used for dedup windows, broadcasts and caches
+func (s *Service) SetClock(now func() time.Time) {
+	s.clock = now
+}
+
+func (s *Service) now() time.Time {
+	if s.clock == nil {
+		return time.Now()
+	}
+
+	return s.clock()
+}
+
 func (s *Service) GetToken(ctx context.Context, userID uuid.UUID) (string, error) {
 	response, err := s.settings.GetPushToken(ctx, &inboxapi.GetPushTokenRequest{UserId: userID.String()})
 	if err != nil {
@@ -139,42 +145,67 @@ func (s *Service) GetTokens(ctx context.Context, userID uuid.UUID) ([]TokenDetai
 	return tokens, nil
 }
 
-func (r request) hash() string {
-	summary := fmt.Sprintf(
-		"%s_%s_%s_%s_%s_%s",
-		r.userID.String(),
-		r.deviceUUID,
-		r.title,
-		r.body,
-		r.imageURL,
-		time.Now().Format("2006-01-02"),
-	)
-	hash := md5.Sum([]byte(summary))
-	return hex.EncodeToString(hash[:])
-}
-
-func (s *Service) Send(ctx context.Context, req request) error {
-	list, err := s.GetTokens(context.TODO(), req.userID)
+func (s *Service) Send(ctx context.Context, req request) (err error) {
+	ctx, span := tracer().Start(ctx, "send push", trace.WithLinks(req.links...), trace.WithAttributes(
+		attribute.String("push.user_id", req.userID.String()),
+		attribute.Int("push.template_id", int(req.template)),
+		attribute.Int("push.proposals", len(req.proposals)),
+	))
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	req.correlationID = CorrelationID(ctx)
+	ctx = withLogFields(ctx, func(c zerolog.Context) zerolog.Context {
+		return c.Str("user_id", req.userID.String())
+	})
+
+	list, err := s.GetTokens(ctx, req.userID)
 	if err != nil {
-		log.Warn().Err(err).Msgf("get token for user %s", req.userID.String())
+		logger(ctx).Warn().Err(err).Msg("get push tokens")
 
 		return nil
 	}
 
+	req = s.experiments.apply(ctx, req)
+
 	msgID := uuid.New()
 	for _, info := range list {
 		req.deviceUUID = info.DeviceUUID
-		item, err := s.repo.GetByHash(req.hash())
-		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
-			return fmt.Errorf("getByHash: %w", err)
+		if project, enabled := s.tokenProject(info.Token); !enabled {
+			logger(ctx).Info().
+				Str("device_uuid", info.DeviceUUID).
+				Str("project", project).
+				Msg("firebase project is disabled, push is skipped")
+
+			s.storeEvents(ctx, requestEvent(SubjectPushSkipped, req, msgID, info.DeviceUUID, ErrProjectDisabled))
+
+			continue
+		}
+
+		key := dedupKey(req)
+		reserved, err := s.reserveDedupKey(ctx, req, key)
+		if err != nil {
+			return fmt.Errorf("s.reserveDedupKey: %w", err)
 		}
-		if item != nil {
-			log.Warn().Msgf("duplicate sending push: %s %s", req.userID.String(), req.title)
+		if !reserved {
+			logger(ctx).Warn().
+				Str("device_uuid", info.DeviceUUID).
+				Str("title", req.title).
+				Msg("duplicate sending push")
 
 			continue
 		}
 
-		response, err := s.sender.Send(ctx, &messaging.Message{
+		customData := map[string]interface{}{
+			"id":        msgID,
+			"proposals": req.proposals,
+		}
+		if req.deepLink != "" {
+			customData["deep_link"] = req.deepLink
+		}
+
+		response, err := s.sendMessage(ctx, info.DeviceUUID, &messaging.Message{
 			Token: info.Token,
 			Notification: &messaging.Notification{
 				Title:    req.title,
@@ -186,53 +217,119 @@ func (s *Service) Send(ctx context.Context, req request) error {
 					Aps: &messaging.Aps{
 						MutableContent: true,
 					},
-					CustomData: map[string]interface{}{
-						"id":        msgID,
-						"proposals": req.proposals,
-					},
+					CustomData: customData,
 				},
 				FCMOptions: &messaging.APNSFCMOptions{
 					ImageURL: req.imageURL,
 				},
 			},
 		})
+		if err != nil {
+			metricFirebaseErrors.WithLabelValues(classifyError(err)).Inc()
+		}
+
 		if err != nil && firebaseerrs.IsNotFound(err) {
-			log.Warn().
-				Msgf("token not found for push token %s", req.userID.String())
+			logger(ctx).Warn().
+				Str("device_uuid", info.DeviceUUID).
+				Msg("push token not found")
+
+			s.releaseDedupKey(ctx, key)
+			s.storeEvents(ctx, requestEvent(SubjectPushExpired, req, msgID, info.DeviceUUID, err))
 
 			continue
 		}
 
 		if err != nil && !firebaseerrs.IsInternal(err) {
-			log.Error().
+			logger(ctx).Error().
 				Err(err).
+				Str("device_uuid", info.DeviceUUID).
 				Msg("send push by external client")
 
+			s.releaseDedupKey(ctx, key)
+			s.storeEvents(ctx, requestEvent(SubjectPushFailed, req, msgID, info.DeviceUUID, err))
+
 			return fmt.Errorf("send push by external client: %w", err)
 		}
 
+		sent := err == nil
+		event := requestEvent(SubjectPushSent, req, msgID, info.DeviceUUID, nil)
+		if !sent {
+			event = requestEvent(SubjectPushFailed, req, msgID, info.DeviceUUID, err)
+		}
+
 		payload, _ := json.Marshal(req.proposals)
-		if err = s.repo.Create(&History{
-			UserID: req.userID,
-			Message: Message{
-				ID:         msgID,
-				Title:      req.title,
-				Body:       req.body,
-				ImageURL:   req.imageURL,
-				Payload:    payload,
-				TemplateID: req.template,
-				DeviceUUID: info.DeviceUUID,
-			},
-			PushResponse: response,
-			Hash:         req.hash(),
-		}); err != nil {
-			log.Error().Err(err).Msg("create history log")
+		err = s.repo.Transaction(ctx, func(tx Storage) error {
+			err := tx.Create(ctx, &History{
+				UserID: req.userID,
+				Message: Message{
+					ID:           msgID,
+					Title:        req.title,
+					Body:         req.body,
+					ImageURL:     req.imageURL,
+					Payload:      payload,
+					TemplateID:   req.template,
+					DeviceUUID:   info.DeviceUUID,
+					ExperimentID: req.experimentID,
+					VariantID:    req.variantID,
+					DeepLink:     req.deepLink,
+				},
+				PushResponse:  response,
+				Hash:          key,
+				Action:        req.action(),
+				DaoID:         req.dao(),
+				CorrelationID: req.correlationID,
+			})
+			if err != nil {
+				return err
+			}
+
+			return tx.CreateOutboxMessages(ctx, []OutboxMessage{event})
+		})
+		if err != nil {
+			logger(ctx).Error().Err(err).Stringer("message_id", msgID).Msg("create history log")
+		}
+
+		if sent {
+			metricFunnelCounter.WithLabelValues(funnelStageSent).Inc()
 		}
 	}
 
 	return nil
 }
 
+// sendMessage sends the message to the device through firebase
+func (s *Service) sendMessage(ctx context.Context, deviceUUID string, msg *messaging.Message) (string, error) {
+	ctx, span := tracer().Start(ctx, "firebase send",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("push.device_uuid", deviceUUID)),
+	)
+
+	response, err := s.sender.Send(ctx, msg)
+	if err != nil {
+		span.SetAttributes(attribute.String("firebase.error_class", classifyError(err)))
+	}
+
+	endSpan(span, err)
+
+	return response, err
+}
+
+// tokenProject returns the firebase project of the token and whether it is enabled
+func (s *Service) tokenProject(token string) (string, bool) {
+	if s.projects == nil {
+		return config.DefaultPushProject, true
+	}
+
+	return s.projects.Project(token)
+}
+
+// storeEvents stores events which are not bound to any other state change
+func (s *Service) storeEvents(ctx context.Context, events ...OutboxMessage) {
+	if err := s.repo.CreateOutboxMessages(context.WithoutCancel(ctx), events); err != nil {
+		logger(ctx).Error().Err(err).Msg("store push events")
+	}
+}
+
 func makeSender(ctx context.Context, cfg []byte, projectID string) (MessageSender, error) {
 	authOpt := option.WithCredentialsJSON(cfg)
 	fapp, err := firebase.NewApp(context.Background(), &firebase.Config{
@@ -250,6 +347,29 @@ func makeSender(ctx context.Context, cfg []byte, projectID string) (MessageSende
 	return client, nil
 }
 
-func (s *Service) MarkAsClicked(id uuid.UUID) error {
-	return s.repo.MarkAsClicked(id)
+func (s *Service) MarkAsClicked(ctx context.Context, id uuid.UUID) error {
+	err := s.repo.MarkAsClicked(ctx, id)
+	if err == nil {
+		metricFunnelCounter.WithLabelValues(funnelStageOpened).Inc()
+	}
+
+	return err
+}
+
+func (s *Service) MarkAsDelivered(ctx context.Context, id uuid.UUID) error {
+	err := s.repo.MarkAsDelivered(ctx, id)
+	if err == nil {
+		metricFunnelCounter.WithLabelValues(funnelStageDelivered).Inc()
+	}
+
+	return err
+}
+
+func (s *Service) MarkAsDismissed(ctx context.Context, id uuid.UUID) error {
+	err := s.repo.MarkAsDismissed(ctx, id)
+	if err == nil {
+		metricFunnelCounter.WithLabelValues(funnelStageDismissed).Inc()
+	}
+
+	return err
 }
@@ -135,25 +135,19 @@ func TestGetTokens(t *testing.T) {
 	}
 }
 
-func TestRequestHashGeneration(t *testing.T) {
-	req := request{
-		body:       "body",
-		title:      "title",
-		imageURL:   "image",
-		userID:     uuid.MustParse("3fca2745-4adc-4fdb-b56c-ef07e38aea6a"),
-		deviceUUID: "uuid_1",
-		proposals:  []string{"str1", "str2", "str3"},
-		template:   1,
-	}
+func TestSendPassesContextToTokenLookup(t *testing.T) {
+	ctrl := gomock.NewController(t)
 
-	expected := "14e3642c4703478f89c1380ae92b2a3f"
+	sp := NewMockSettingsProvider(ctrl)
+	sp.EXPECT().
+		GetPushTokenList(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, _ *inboxapi.GetPushTokenListRequest, _ ...any) (*inboxapi.PushTokenListResponse, error) {
+			// the lookup carries the trace and the deadline of the send
+			require.Equal(t, "run-1", CorrelationID(ctx))
 
-	t.Run("hash generation", func(t *testing.T) {
-		actual := req.hash()
-		require.Equal(t, expected, actual)
-	})
+			return &inboxapi.PushTokenListResponse{}, nil
+		})
 
-	t.Run("twice generation", func(t *testing.T) {
-		require.Equal(t, req.hash(), req.hash())
-	})
+	service := &Service{settings: sp}
+	require.NoError(t, service.Send(withCorrelationID(context.Background(), "run-1"), request{userID: uuid.New()}))
 }
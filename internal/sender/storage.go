@@ -0,0 +1,63 @@
+package sender
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Storage keeps the state of the service. Repo stores it in postgres, MemoryStorage keeps it in memory.
+type Storage interface {
+	// Transaction runs fn with the storage bound to the single transaction
+	Transaction(ctx context.Context, fn func(tx Storage) error) error
+
+	Create(ctx context.Context, item *History) error
+	GetByHash(ctx context.Context, hash string) (*History, error)
+	MarkAsClicked(ctx context.Context, messageUUID uuid.UUID) error
+	MarkAsDelivered(ctx context.Context, messageUUID uuid.UUID) error
+	MarkAsDismissed(ctx context.Context, messageUUID uuid.UUID) error
+	MarkAsRead(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) error
+	HistoriesInBatches(ctx context.Context, filters []Filter, batchSize int, fn func([]History) error) error
+	Notifications(ctx context.Context, userID uuid.UUID, before *NotificationCursor, limit int) ([]History, error)
+	UnreadNotificationsCount(ctx context.Context, userID uuid.UUID) (int64, error)
+	ClickStats(ctx context.Context, query ClickStatsQuery) ([]ClickStats, error)
+	KnownUserIDs(ctx context.Context) ([]uuid.UUID, error)
+
+	QueueByFilters(ctx context.Context, filters []Filter) ([]SendQueue, error)
+	QueueItems(ctx context.Context, filters []Filter, limit int) ([]SendQueue, error)
+	PendingQueueStats(ctx context.Context) ([]PendingQueueStats, error)
+	CreateSendQueueRequest(ctx context.Context, item *SendQueue) (bool, error)
+	MarkAsSent(ctx context.Context, ids []uint) error
+	CancelQueueItems(ctx context.Context, ids []uint) ([]uint, error)
+	RequeueQueueItems(ctx context.Context, ids []uint) ([]uint, error)
+
+	CreateFanoutLog(ctx context.Context, list []FanoutLog) error
+	FanoutLogByFilters(ctx context.Context, filters []Filter) ([]FanoutLog, error)
+
+	ClaimInboxEvent(ctx context.Context, subject, key string, lockedUntil time.Time) (*InboxEvent, bool, error)
+	MarkInboxEvent(ctx context.Context, id uint, processErr error) error
+	DeleteInboxEventsBefore(ctx context.Context, before time.Time) (int64, error)
+
+	CreateDeadLetter(ctx context.Context, item *DeadLetter) error
+	GetDeadLetter(ctx context.Context, id uint) (*DeadLetter, error)
+	ListDeadLetters(ctx context.Context, subject string, limit int) ([]DeadLetter, error)
+	MarkDeadLetterReplayed(ctx context.Context, id uint) error
+
+	CreateOutboxMessages(ctx context.Context, list []OutboxMessage) error
+	PublishOutbox(ctx context.Context, limit int, publish func(OutboxMessage) error) (int, error)
+	DeleteOutboxPublishedBefore(ctx context.Context, before time.Time) error
+
+	ReserveDedupKey(ctx context.Context, key string, expiresAt *time.Time) (bool, error)
+	ReleaseDedupKey(ctx context.Context, key string) error
+	DeleteExpiredDedupKeys(ctx context.Context, now time.Time) (int64, error)
+
+	CreateBroadcast(ctx context.Context, item *Broadcast) error
+	GetBroadcast(ctx context.Context, id uint) (*Broadcast, error)
+	ListBroadcasts(ctx context.Context, limit int) ([]Broadcast, error)
+	UpdateBroadcast(ctx context.Context, id uint, in []BroadcastStatus, updates map[string]any) (bool, error)
+	ClaimDueBroadcast(ctx context.Context, now, staleBefore time.Time) (*Broadcast, error)
+
+	CreateAuditLog(ctx context.Context, item *AuditLog) error
+	ListAuditLog(ctx context.Context, action string, limit int) ([]AuditLog, error)
+}
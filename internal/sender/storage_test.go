@@ -0,0 +1,644 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/goverland-labs/goverland-inbox-push/pkg/migration"
+	"github.com/goverland-labs/goverland-inbox-push/resources"
+)
+
+func TestMemoryStorage(t *testing.T) {
+	testStorage(t, func(_ *testing.T) Storage {
+		return NewMemoryStorage()
+	})
+}
+
+// TestRepoStorage runs the suite against postgres. All data of the database is truncated,
+// so TEST_POSTGRES_DSN must point to the disposable one.
+func TestRepoStorage(t *testing.T) {
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN is not set")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{TranslateError: true})
+	require.NoError(t, err)
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	migrations, err := migration.Load(resources.Migrations)
+	require.NoError(t, err)
+	require.NoError(t, migration.NewRunner(sqlDB).Apply(context.Background(), migrations, nil))
+
+	testStorage(t, func(t *testing.T) Storage {
+		err := db.Exec(`
+			truncate histories, send_queue, fanout_log, event_inbox, dead_letters,
+				outbox, push_dedup, broadcasts, audit_log
+			restart identity
+		`).Error
+		require.NoError(t, err)
+
+		return NewRepo(db)
+	})
+}
+
+// testStorage checks the semantics Service relies on, each Storage implementation must pass it
+func testStorage(t *testing.T, newStorage func(t *testing.T) Storage) {
+	ctx := context.Background()
+	userID := uuid.New()
+	daoID := uuid.New()
+
+	queueItem := func(action Action) *SendQueue {
+		return &SendQueue{
+			UserID:     userID,
+			DaoID:      daoID,
+			ProposalID: "proposal",
+			Action:     action,
+		}
+	}
+
+	history := func(messageID uuid.UUID, device string) *History {
+		return &History{
+			UserID: userID,
+			Message: Message{
+				ID:         messageID,
+				Title:      "title",
+				TemplateID: templateIDOneDaoOneProposal,
+				DeviceUUID: device,
+			},
+			Hash:   uuid.NewString(),
+			Action: ProposalCreated,
+			DaoID:  &daoID,
+		}
+	}
+
+	t.Run("history hash lookup", func(t *testing.T) {
+		s := newStorage(t)
+
+		item := history(uuid.New(), "device")
+		require.NoError(t, s.Create(ctx, item))
+		require.NotZero(t, item.ID)
+
+		found, err := s.GetByHash(ctx, item.Hash)
+		require.NoError(t, err)
+		require.Equal(t, item.ID, found.ID)
+		require.Equal(t, item.Message.ID, found.Message.ID)
+		require.Equal(t, daoID, *found.DaoID)
+
+		_, err = s.GetByHash(ctx, "unknown")
+		require.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+		// the same push is sent again once the dedup window is over, so the hash is not unique
+		duplicate := history(uuid.New(), "device")
+		duplicate.Hash = item.Hash
+		require.NoError(t, s.Create(ctx, duplicate))
+		require.NotEqual(t, item.ID, duplicate.ID)
+
+		found, err = s.GetByHash(ctx, item.Hash)
+		require.NoError(t, err)
+		require.Contains(t, []uint{item.ID, duplicate.ID}, found.ID)
+
+		list, err := s.Notifications(ctx, item.UserID, nil, 10)
+		require.NoError(t, err)
+		require.Len(t, list, 2)
+	})
+
+	t.Run("pending queue items are unique", func(t *testing.T) {
+		s := newStorage(t)
+
+		first := queueItem(ProposalCreated)
+		created, err := s.CreateSendQueueRequest(ctx, first)
+		require.NoError(t, err)
+		require.True(t, created)
+
+		created, err = s.CreateSendQueueRequest(ctx, queueItem(ProposalCreated))
+		require.NoError(t, err)
+		require.False(t, created)
+
+		created, err = s.CreateSendQueueRequest(ctx, queueItem(ProposalVotingEnded))
+		require.NoError(t, err)
+		require.True(t, created)
+
+		// the same action may be queued again once the previous one is sent
+		require.NoError(t, s.MarkAsSent(withCorrelationID(ctx, "run-1"), []uint{first.ID}))
+
+		second := queueItem(ProposalCreated)
+		created, err = s.CreateSendQueueRequest(ctx, second)
+		require.NoError(t, err)
+		require.True(t, created)
+
+		// and once the pending one is cancelled
+		cancelled, err := s.CancelQueueItems(ctx, []uint{first.ID, second.ID})
+		require.NoError(t, err)
+		require.Equal(t, []uint{second.ID}, cancelled)
+
+		created, err = s.CreateSendQueueRequest(ctx, queueItem(ProposalCreated))
+		require.NoError(t, err)
+		require.True(t, created)
+
+		// the sent item is not requeued while the same one is pending
+		requeued, err := s.RequeueQueueItems(ctx, []uint{first.ID})
+		require.NoError(t, err)
+		require.Empty(t, requeued)
+	})
+
+	t.Run("sent marking", func(t *testing.T) {
+		s := newStorage(t)
+
+		item := queueItem(ProposalCreated)
+		_, err := s.CreateSendQueueRequest(ctx, item)
+		require.NoError(t, err)
+
+		require.NoError(t, s.MarkAsSent(withCorrelationID(ctx, "run-1"), []uint{item.ID}))
+		require.NoError(t, s.MarkAsSent(ctx, nil))
+
+		list, err := s.QueueByFilters(ctx, []Filter{AlreadySent()})
+		require.NoError(t, err)
+		require.Len(t, list, 1)
+		require.NotNil(t, list[0].SentAt)
+		require.Equal(t, "run-1", list[0].SentCorrelationID)
+
+		stats, err := s.PendingQueueStats(ctx)
+		require.NoError(t, err)
+		require.Empty(t, stats)
+
+		requeued, err := s.RequeueQueueItems(ctx, []uint{item.ID})
+		require.NoError(t, err)
+		require.Equal(t, []uint{item.ID}, requeued)
+
+		list, err = s.QueueByFilters(ctx, []Filter{AvailableForSending()})
+		require.NoError(t, err)
+		require.Len(t, list, 1)
+		require.Empty(t, list[0].SentCorrelationID)
+	})
+
+	t.Run("requeue of the same items in one batch", func(t *testing.T) {
+		s := newStorage(t)
+
+		ids := make([]uint, 0, 3)
+		for range 3 {
+			item := queueItem(ProposalCreated)
+			created, err := s.CreateSendQueueRequest(ctx, item)
+			require.NoError(t, err)
+			require.True(t, created)
+			require.NoError(t, s.MarkAsSent(ctx, []uint{item.ID}))
+
+			ids = append(ids, item.ID)
+		}
+		other := queueItem(ProposalVotingEnded)
+		_, err := s.CreateSendQueueRequest(ctx, other)
+		require.NoError(t, err)
+		require.NoError(t, s.MarkAsSent(ctx, []uint{other.ID}))
+
+		// only one of the items with the same key becomes pending
+		requeued, err := s.RequeueQueueItems(ctx, append([]uint{other.ID}, ids...))
+		require.NoError(t, err)
+		require.ElementsMatch(t, []uint{ids[0], other.ID}, requeued)
+
+		list, err := s.QueueByFilters(ctx, []Filter{AvailableForSending()})
+		require.NoError(t, err)
+		require.Len(t, list, 2)
+	})
+
+	t.Run("queue filters", func(t *testing.T) {
+		s := newStorage(t)
+
+		base := time.Now().Add(-time.Hour).Truncate(time.Microsecond)
+		items := make([]*SendQueue, 0, 3)
+		for idx, action := range []Action{ProposalCreated, ProposalVotingEnded, DelegateVotingVoted} {
+			item := queueItem(action)
+			item.CreatedAt = base.Add(time.Duration(idx) * time.Minute)
+			item.CorrelationID = string(action)
+			_, err := s.CreateSendQueueRequest(ctx, item)
+			require.NoError(t, err)
+
+			items = append(items, item)
+		}
+		other := &SendQueue{UserID: uuid.New(), DaoID: uuid.New(), ProposalID: "other", Action: ProposalCreated}
+		other.CreatedAt = base
+		_, err := s.CreateSendQueueRequest(ctx, other)
+		require.NoError(t, err)
+		require.NoError(t, s.MarkAsSent(ctx, []uint{items[2].ID}))
+
+		for name, tc := range map[string]struct {
+			filters  []Filter
+			expected []uint
+		}{
+			"no filters":    {nil, []uint{items[0].ID, items[1].ID, items[2].ID, other.ID}},
+			"user":          {[]Filter{UserIDIn(userID.String())}, []uint{items[0].ID, items[1].ID, items[2].ID}},
+			"dao":           {[]Filter{DaoIDIn(other.DaoID.String())}, []uint{other.ID}},
+			"proposal":      {[]Filter{ProposalIDIn("proposal")}, []uint{items[0].ID, items[1].ID, items[2].ID}},
+			"action in":     {[]Filter{ActionIn(string(ProposalCreated))}, []uint{items[0].ID, other.ID}},
+			"action not in": {[]Filter{ActionNotIn(string(ProposalCreated), string(DelegateVotingVoted))}, []uint{items[1].ID}},
+			"pending":       {[]Filter{UserIDIn(userID.String()), AvailableForSending()}, []uint{items[0].ID, items[1].ID}},
+			"sent":          {[]Filter{AlreadySent()}, []uint{items[2].ID}},
+			"ids":           {[]Filter{IDIn(items[1].ID, other.ID)}, []uint{items[1].ID, other.ID}},
+			"correlation":   {[]Filter{CorrelationIDIn(string(ProposalVotingEnded))}, []uint{items[1].ID}},
+			"created range": {[]Filter{CreatedAfter(base.Add(time.Minute)), CreatedBefore(base.Add(2 * time.Minute))}, []uint{items[1].ID}},
+		} {
+			t.Run(name, func(t *testing.T) {
+				list, err := s.QueueByFilters(ctx, tc.filters)
+				require.NoError(t, err)
+				require.ElementsMatch(t, tc.expected, queueIDs(list))
+			})
+		}
+
+		latest, err := s.QueueItems(ctx, []Filter{UserIDIn(userID.String())}, 2)
+		require.NoError(t, err)
+		require.Equal(t, []uint{items[2].ID, items[1].ID}, queueIDs(latest))
+	})
+
+	t.Run("transaction", func(t *testing.T) {
+		s := newStorage(t)
+		errRollback := errors.New("rollback")
+
+		err := s.Transaction(ctx, func(tx Storage) error {
+			if _, err := tx.CreateSendQueueRequest(ctx, queueItem(ProposalCreated)); err != nil {
+				return err
+			}
+
+			return errRollback
+		})
+		require.ErrorIs(t, err, errRollback)
+
+		list, err := s.QueueByFilters(ctx, nil)
+		require.NoError(t, err)
+		require.Empty(t, list)
+
+		err = s.Transaction(ctx, func(tx Storage) error {
+			if _, err := tx.CreateSendQueueRequest(ctx, queueItem(ProposalCreated)); err != nil {
+				return err
+			}
+
+			return tx.CreateOutboxMessages(ctx, []OutboxMessage{{Subject: "subject", Payload: "{}"}})
+		})
+		require.NoError(t, err)
+
+		list, err = s.QueueByFilters(ctx, nil)
+		require.NoError(t, err)
+		require.Len(t, list, 1)
+	})
+
+	t.Run("history marks and notifications", func(t *testing.T) {
+		s := newStorage(t)
+
+		first, second := uuid.New(), uuid.New()
+		for _, item := range []*History{
+			history(first, "device-1"),
+			history(first, "device-2"),
+			history(second, "device-1"),
+		} {
+			require.NoError(t, s.Create(ctx, item))
+		}
+
+		count, err := s.UnreadNotificationsCount(ctx, userID)
+		require.NoError(t, err)
+		require.EqualValues(t, 2, count)
+
+		require.NoError(t, s.MarkAsClicked(ctx, first))
+		require.NoError(t, s.MarkAsDelivered(ctx, first))
+		require.NoError(t, s.MarkAsDismissed(ctx, second))
+
+		count, err = s.UnreadNotificationsCount(ctx, userID)
+		require.NoError(t, err)
+		require.EqualValues(t, 1, count)
+
+		list, err := s.Notifications(ctx, userID, nil, 10)
+		require.NoError(t, err)
+		require.Len(t, list, 2)
+		require.Equal(t, second, list[0].Message.ID)
+		require.Nil(t, list[0].ClickedAt)
+		require.NotNil(t, list[0].DismissedAt)
+		require.Equal(t, first, list[1].Message.ID)
+		require.Equal(t, "device-1", list[1].Message.DeviceUUID)
+		require.NotNil(t, list[1].ClickedAt)
+		require.NotNil(t, list[1].DeliveredAt)
+
+		cursor := &NotificationCursor{CreatedAt: list[0].CreatedAt, ID: list[0].ID}
+		list, err = s.Notifications(ctx, userID, cursor, 10)
+		require.NoError(t, err)
+		require.Len(t, list, 1)
+		require.Equal(t, first, list[0].Message.ID)
+
+		require.NoError(t, s.MarkAsRead(ctx, userID, nil))
+		count, err = s.UnreadNotificationsCount(ctx, userID)
+		require.NoError(t, err)
+		require.Zero(t, count)
+
+		// read marks are not clicks
+		list, err = s.Notifications(ctx, userID, nil, 10)
+		require.NoError(t, err)
+		require.Len(t, list, 2)
+		require.NotNil(t, list[0].ReadAt)
+		require.Nil(t, list[0].ClickedAt)
+		require.NotNil(t, list[1].ReadAt)
+		require.NotNil(t, list[1].ClickedAt)
+
+		var batches [][]History
+		err = s.HistoriesInBatches(ctx, []Filter{TemplateIDIn(int(templateIDOneDaoOneProposal))}, 2, func(list []History) error {
+			batches = append(batches, append([]History(nil), list...))
+			return nil
+		})
+		require.NoError(t, err)
+		require.Len(t, batches, 2)
+		require.Len(t, batches[0], 2)
+		require.Len(t, batches[1], 1)
+
+		stats, err := s.ClickStats(ctx, ClickStatsQuery{
+			From:    time.Now().Add(-time.Hour),
+			To:      time.Now().Add(time.Hour),
+			GroupBy: GroupByDevice,
+		})
+		require.NoError(t, err)
+		// the message marked as read is not clicked
+		require.Equal(t, []ClickStats{
+			{Key: "device-1", Sends: 2, Clicks: 1, Delivered: 1, Dismissed: 1},
+			{Key: "device-2", Sends: 1, Clicks: 1, Delivered: 1},
+		}, withoutTimeToClick(stats))
+
+		users, err := s.KnownUserIDs(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []uuid.UUID{userID}, users)
+	})
+
+	t.Run("dedup keys", func(t *testing.T) {
+		s := newStorage(t)
+
+		expired := time.Now().Add(-time.Minute)
+		reserved, err := s.ReserveDedupKey(ctx, "expired", &expired)
+		require.NoError(t, err)
+		require.True(t, reserved)
+
+		// the expired key is taken over
+		later := time.Now().Add(time.Hour)
+		reserved, err = s.ReserveDedupKey(ctx, "expired", &later)
+		require.NoError(t, err)
+		require.True(t, reserved)
+
+		reserved, err = s.ReserveDedupKey(ctx, "expired", &later)
+		require.NoError(t, err)
+		require.False(t, reserved)
+
+		reserved, err = s.ReserveDedupKey(ctx, "forever", nil)
+		require.NoError(t, err)
+		require.True(t, reserved)
+
+		deleted, err := s.DeleteExpiredDedupKeys(ctx, later)
+		require.NoError(t, err)
+		require.EqualValues(t, 1, deleted)
+
+		reserved, err = s.ReserveDedupKey(ctx, "forever", nil)
+		require.NoError(t, err)
+		require.False(t, reserved)
+
+		require.NoError(t, s.ReleaseDedupKey(ctx, "forever"))
+		reserved, err = s.ReserveDedupKey(ctx, "forever", nil)
+		require.NoError(t, err)
+		require.True(t, reserved)
+	})
+
+	t.Run("inbox events", func(t *testing.T) {
+		s := newStorage(t)
+
+		event, claimed, err := s.ClaimInboxEvent(ctx, "subject", "key", time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		require.True(t, claimed)
+		require.Equal(t, 1, event.Attempts)
+		require.Equal(t, InboxProcessing, event.Status)
+
+		// the redelivery within the lease is not claimed
+		leased, claimed, err := s.ClaimInboxEvent(ctx, "subject", "key", time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		require.False(t, claimed)
+		require.Equal(t, event.ID, leased.ID)
+		require.Equal(t, 1, leased.Attempts)
+
+		require.NoError(t, s.MarkInboxEvent(ctx, event.ID, errors.New("failed")))
+
+		again, claimed, err := s.ClaimInboxEvent(ctx, "subject", "key", time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		require.True(t, claimed)
+		require.Equal(t, event.ID, again.ID)
+		require.Equal(t, 2, again.Attempts)
+		require.Equal(t, InboxProcessing, again.Status)
+		require.Equal(t, "failed", again.LastError)
+
+		require.NoError(t, s.MarkInboxEvent(ctx, event.ID, nil))
+
+		processed, claimed, err := s.ClaimInboxEvent(ctx, "subject", "key", time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		require.False(t, claimed)
+		require.Equal(t, InboxProcessed, processed.Status)
+		require.Equal(t, 2, processed.Attempts)
+		require.Nil(t, processed.LockedUntil)
+	})
+
+	t.Run("inbox event with expired lease", func(t *testing.T) {
+		s := newStorage(t)
+
+		event, claimed, err := s.ClaimInboxEvent(ctx, "subject", "key", time.Now().Add(-time.Second))
+		require.NoError(t, err)
+		require.True(t, claimed)
+
+		again, claimed, err := s.ClaimInboxEvent(ctx, "subject", "key", time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		require.True(t, claimed)
+		require.Equal(t, event.ID, again.ID)
+		require.Equal(t, 2, again.Attempts)
+		require.Equal(t, InboxProcessing, again.Status)
+	})
+
+	t.Run("delete old inbox events", func(t *testing.T) {
+		s := newStorage(t)
+
+		_, _, err := s.ClaimInboxEvent(ctx, "subject", "key", time.Now().Add(time.Hour))
+		require.NoError(t, err)
+
+		deleted, err := s.DeleteInboxEventsBefore(ctx, time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		require.Zero(t, deleted)
+
+		deleted, err = s.DeleteInboxEventsBefore(ctx, time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		require.EqualValues(t, 1, deleted)
+
+		// the deleted event is processed as a new one
+		event, claimed, err := s.ClaimInboxEvent(ctx, "subject", "key", time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		require.True(t, claimed)
+		require.Equal(t, 1, event.Attempts)
+	})
+
+	t.Run("outbox keeps the order", func(t *testing.T) {
+		s := newStorage(t)
+
+		require.NoError(t, s.CreateOutboxMessages(ctx, []OutboxMessage{
+			{Subject: "first", Payload: "{}"},
+			{Subject: "second", Payload: "{}"},
+			{Subject: "third", Payload: "{}"},
+		}))
+
+		var subjects []string
+		published, err := s.PublishOutbox(ctx, 10, func(msg OutboxMessage) error {
+			if msg.Subject == "second" {
+				return errors.New("nats is down")
+			}
+
+			subjects = append(subjects, msg.Subject)
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, 1, published)
+		require.Equal(t, []string{"first"}, subjects)
+
+		published, err = s.PublishOutbox(ctx, 1, func(msg OutboxMessage) error {
+			subjects = append(subjects, msg.Subject)
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, 1, published)
+		require.Equal(t, []string{"first", "second"}, subjects)
+	})
+
+	t.Run("outbox message failed max attempts does not block the rest", func(t *testing.T) {
+		s := newStorage(t)
+
+		require.NoError(t, s.CreateOutboxMessages(ctx, []OutboxMessage{
+			{Subject: "broken", Payload: "{}"},
+			{Subject: "next", Payload: "{}"},
+		}))
+
+		var subjects []string
+		publish := func(msg OutboxMessage) error {
+			if msg.Subject == "broken" {
+				return errors.New("payload is rejected")
+			}
+
+			subjects = append(subjects, msg.Subject)
+			return nil
+		}
+
+		for i := 0; i < outboxMaxAttempts; i++ {
+			published, err := s.PublishOutbox(ctx, 10, publish)
+			require.NoError(t, err)
+			require.Zero(t, published)
+		}
+
+		published, err := s.PublishOutbox(ctx, 10, publish)
+		require.NoError(t, err)
+		require.Equal(t, 1, published)
+		require.Equal(t, []string{"next"}, subjects)
+
+		published, err = s.PublishOutbox(ctx, 10, publish)
+		require.NoError(t, err)
+		require.Zero(t, published)
+	})
+
+	t.Run("outbox messages claimed by another publisher are skipped", func(t *testing.T) {
+		s := newStorage(t)
+
+		require.NoError(t, s.CreateOutboxMessages(ctx, []OutboxMessage{
+			{Subject: "first", Payload: "{}"},
+			{Subject: "second", Payload: "{}"},
+		}))
+
+		var subjects []string
+		published, err := s.PublishOutbox(ctx, 10, func(msg OutboxMessage) error {
+			subjects = append(subjects, msg.Subject)
+
+			// the other publisher runs while the batch is being published
+			other, err := s.PublishOutbox(ctx, 10, func(msg OutboxMessage) error {
+				subjects = append(subjects, "other "+msg.Subject)
+				return nil
+			})
+			require.NoError(t, err)
+			require.Zero(t, other)
+
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, 2, published)
+		require.Equal(t, []string{"first", "second"}, subjects)
+
+		published, err = s.PublishOutbox(ctx, 10, func(OutboxMessage) error {
+			return errors.New("nothing to publish")
+		})
+		require.NoError(t, err)
+		require.Zero(t, published)
+	})
+
+	t.Run("broadcast status transitions", func(t *testing.T) {
+		s := newStorage(t)
+
+		item := &Broadcast{Title: "title", Segment: BroadcastSegmentAll, Status: BroadcastDraft}
+		require.NoError(t, s.CreateBroadcast(ctx, item))
+
+		claimed, err := s.ClaimDueBroadcast(ctx, time.Now(), time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		require.Nil(t, claimed)
+
+		scheduledAt := time.Now().Add(-time.Minute)
+		updated, err := s.UpdateBroadcast(ctx, item.ID, []BroadcastStatus{BroadcastDraft}, map[string]any{
+			"status":       BroadcastScheduled,
+			"scheduled_at": scheduledAt,
+		})
+		require.NoError(t, err)
+		require.True(t, updated)
+
+		updated, err = s.UpdateBroadcast(ctx, item.ID, []BroadcastStatus{BroadcastDraft}, map[string]any{
+			"status": BroadcastCancelled,
+		})
+		require.NoError(t, err)
+		require.False(t, updated)
+
+		claimed, err = s.ClaimDueBroadcast(ctx, time.Now(), time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		require.Equal(t, item.ID, claimed.ID)
+		require.Equal(t, BroadcastSending, claimed.Status)
+		require.NotNil(t, claimed.StartedAt)
+
+		claimed, err = s.ClaimDueBroadcast(ctx, time.Now(), time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		require.Nil(t, claimed)
+
+		updated, err = s.UpdateBroadcast(ctx, item.ID, []BroadcastStatus{BroadcastSending}, map[string]any{
+			"processed": 3,
+		})
+		require.NoError(t, err)
+		require.True(t, updated)
+
+		found, err := s.GetBroadcast(ctx, item.ID)
+		require.NoError(t, err)
+		require.Equal(t, 3, found.Processed)
+		require.Equal(t, BroadcastSending, found.Status)
+
+		_, err = s.GetBroadcast(ctx, item.ID+1)
+		require.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+		// the broadcast without progress for the lease is claimed again
+		claimed, err = s.ClaimDueBroadcast(ctx, time.Now(), time.Now().Add(time.Minute))
+		require.NoError(t, err)
+		require.Equal(t, item.ID, claimed.ID)
+		require.Equal(t, BroadcastSending, claimed.Status)
+		require.Equal(t, 3, claimed.Processed)
+	})
+}
+
+func withoutTimeToClick(list []ClickStats) []ClickStats {
+	for idx := range list {
+		list[idx].TimeToClickP50, list[idx].TimeToClickP90, list[idx].TimeToClickP99 = nil, nil, nil
+	}
+
+	return list
+}
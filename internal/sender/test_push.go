@@ -0,0 +1,113 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"firebase.google.com/go/v4/messaging"
+	"github.com/google/uuid"
+)
+
+const (
+	testPushTitle = "[TEST] Goverland"
+	testPushBody  = "This is a test notification, please ignore it."
+
+	AuditTestPush = "push.test"
+)
+
+var ErrNoDevices = errors.New("no devices to send to")
+
+type TestPushRequest struct {
+	UserID uuid.UUID
+	// DeviceUUID limits the push to the single device of the user
+	DeviceUUID string
+}
+
+// TestPushResult is the firebase result for the device
+type TestPushResult struct {
+	DeviceUUID string `json:"device_uuid"`
+	// MessageID is the firebase message id when the push is accepted
+	MessageID  string `json:"message_id,omitempty"`
+	ErrorClass string `json:"error_class,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// SendTestPush sends the clearly marked test push to the user devices and returns
+// the firebase result for each device. Test pushes skip dedup and user limits and
+// are not stored in the history, so they never affect real notifications.
+func (s *Service) SendTestPush(ctx context.Context, actor string, req TestPushRequest) ([]TestPushResult, error) {
+	ctx = ensureCorrelationID(ctx)
+
+	results, err := s.sendTestPush(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	audit := newAuditLog(ctx, actor, AuditTestPush, map[string]any{
+		"user_id":     req.UserID,
+		"device_uuid": req.DeviceUUID,
+	}, nil)
+	if err := s.repo.CreateAuditLog(context.WithoutCancel(ctx), audit); err != nil {
+		logger(ctx).Error().Err(err).Msg("create test push audit log")
+	}
+
+	return results, nil
+}
+
+func (s *Service) sendTestPush(ctx context.Context, req TestPushRequest) ([]TestPushResult, error) {
+	tokens, err := s.GetTokens(ctx, req.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("s.GetTokens: %w", err)
+	}
+
+	if req.DeviceUUID != "" {
+		filtered := tokens[:0]
+		for _, info := range tokens {
+			if info.DeviceUUID == req.DeviceUUID {
+				filtered = append(filtered, info)
+			}
+		}
+		tokens = filtered
+	}
+
+	if len(tokens) == 0 {
+		return nil, ErrNoDevices
+	}
+
+	msgID := uuid.New()
+	results := make([]TestPushResult, 0, len(tokens))
+	for _, info := range tokens {
+		response, err := s.sendMessage(ctx, info.DeviceUUID, &messaging.Message{
+			Token: info.Token,
+			Notification: &messaging.Notification{
+				Title: testPushTitle,
+				Body:  testPushBody,
+			},
+			APNS: &messaging.APNSConfig{
+				Payload: &messaging.APNSPayload{
+					Aps: &messaging.Aps{
+						MutableContent: true,
+					},
+					CustomData: map[string]interface{}{
+						"id":   msgID,
+						"test": true,
+					},
+				},
+			},
+		})
+
+		result := TestPushResult{
+			DeviceUUID: info.DeviceUUID,
+			MessageID:  response,
+		}
+		if err != nil {
+			result.ErrorClass = classifyError(err)
+			result.Error = err.Error()
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,78 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"firebase.google.com/go/v4/messaging"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/goverland-labs/goverland-inbox-api-protocol/protobuf/inboxapi"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendTestPush(t *testing.T) {
+	tokens := &inboxapi.PushTokenListResponse{
+		Tokens: []*inboxapi.PushTokenDetails{
+			{Token: "token_1", DeviceUuid: "device_1"},
+			{Token: "token_2", DeviceUuid: "device_2"},
+		},
+	}
+
+	for name, tc := range map[string]struct {
+		device  string
+		sends   int
+		results []TestPushResult
+		err     error
+	}{
+		"all devices": {
+			sends: 2,
+			results: []TestPushResult{
+				{DeviceUUID: "device_1", MessageID: "projects/p/messages/1"},
+				{DeviceUUID: "device_2", ErrorClass: errorClassUnknown, Error: "unavailable"},
+			},
+		},
+		"single device": {
+			device: "device_1",
+			sends:  1,
+			results: []TestPushResult{
+				{DeviceUUID: "device_1", MessageID: "projects/p/messages/1"},
+			},
+		},
+		"unknown device": {
+			device: "device_3",
+			err:    ErrNoDevices,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			sp := NewMockSettingsProvider(ctrl)
+			sp.EXPECT().GetPushTokenList(gomock.Any(), gomock.Any()).Return(tokens, nil)
+
+			ms := NewMockMessageSender(ctrl)
+			ms.EXPECT().
+				Send(gomock.Any(), gomock.Any()).
+				Times(tc.sends).
+				DoAndReturn(func(_ context.Context, msg *messaging.Message) (string, error) {
+					require.Equal(t, testPushTitle, msg.Notification.Title)
+					require.Equal(t, true, msg.APNS.Payload.CustomData["test"])
+
+					if msg.Token == "token_2" {
+						return "", errors.New("unavailable")
+					}
+
+					return "projects/p/messages/1", nil
+				})
+
+			s := &Service{settings: sp, sender: ms}
+
+			results, err := s.sendTestPush(context.Background(), TestPushRequest{
+				UserID:     uuid.New(),
+				DeviceUUID: tc.device,
+			})
+			require.ErrorIs(t, err, tc.err)
+			require.Equal(t, tc.results, results)
+		})
+	}
+}
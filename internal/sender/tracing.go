@@ -0,0 +1,111 @@
+package sender
+
+import (
+	"context"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/goverland-labs/goverland-inbox-push/internal/sender"
+
+// propagator is used for nats headers and trace parents persisted on queue items
+var propagator = propagation.TraceContext{}
+
+// tracer returns the tracer of the current global provider
+func tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// messageContext returns the context with the remote span from nats message headers
+func messageContext(ctx context.Context, header nats.Header) context.Context {
+	if len(header) == 0 {
+		return ctx
+	}
+
+	return propagator.Extract(ctx, natsHeaderCarrier(header))
+}
+
+// natsHeaderCarrier looks up keys case-insensitively, as nats headers are case-sensitive
+// and publishers are not consistent about "traceparent" or "Traceparent"
+type natsHeaderCarrier nats.Header
+
+func (c natsHeaderCarrier) Get(key string) string {
+	if value := nats.Header(c).Get(key); value != "" {
+		return value
+	}
+
+	for k, values := range c {
+		if strings.EqualFold(k, key) && len(values) > 0 {
+			return values[0]
+		}
+	}
+
+	return ""
+}
+
+func (c natsHeaderCarrier) Set(key, value string) {
+	nats.Header(c).Set(key, value)
+}
+
+func (c natsHeaderCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+
+	return keys
+}
+
+// traceParent serializes the current span to persist it, empty if there is no span
+func traceParent(ctx context.Context) string {
+	carrier := propagation.MapCarrier{}
+	propagator.Inject(ctx, carrier)
+
+	return carrier.Get("traceparent")
+}
+
+// queueLinks links the asynchronous send to the spans queued the items
+func queueLinks(items []SendQueue) []trace.Link {
+	links := make([]trace.Link, 0, len(items))
+	for _, info := range items {
+		if info.TraceParent == "" {
+			continue
+		}
+
+		sc := trace.SpanContextFromContext(propagator.Extract(context.Background(), propagation.MapCarrier{
+			"traceparent": info.TraceParent,
+		}))
+		if !sc.IsValid() {
+			continue
+		}
+
+		links = append(links, trace.Link{SpanContext: sc})
+	}
+
+	return links
+}
+
+// endSpan records the error if any and ends the span
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+func itemAttributes(item Item) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("feed.id", item.FeedID.String()),
+		attribute.String("feed.dao_id", item.DaoID.String()),
+		attribute.String("feed.proposal_id", item.ProposalID),
+		attribute.String("feed.action", string(item.Action)),
+	}
+}
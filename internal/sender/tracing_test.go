@@ -0,0 +1,60 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const testTraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestMessageContext(t *testing.T) {
+	for name, header := range map[string]nats.Header{
+		"lowercase": {"traceparent": []string{testTraceParent}},
+		"canonical": {"Traceparent": []string{testTraceParent}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			sc := trace.SpanContextFromContext(messageContext(context.Background(), header))
+			require.True(t, sc.IsRemote())
+			require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID().String())
+		})
+	}
+
+	sc := trace.SpanContextFromContext(messageContext(context.Background(), nil))
+	require.False(t, sc.IsValid())
+}
+
+func TestQueueLinks(t *testing.T) {
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	ctx, span := tracer().Start(context.Background(), "fanout")
+	parent := traceParent(ctx)
+	endSpan(span, errors.New("failed"))
+
+	require.Empty(t, traceParent(context.Background()))
+
+	links := queueLinks([]SendQueue{
+		{TraceParent: parent},
+		{TraceParent: ""},
+		{TraceParent: "invalid"},
+	})
+	require.Len(t, links, 1)
+	require.Equal(t, span.SpanContext().TraceID(), links[0].SpanContext.TraceID())
+	require.Equal(t, span.SpanContext().SpanID(), links[0].SpanContext.SpanID())
+
+	spans := exp.GetSpans()
+	require.Len(t, spans, 1)
+	require.Equal(t, "fanout", spans[0].Name)
+	require.Len(t, spans[0].Events, 1)
+}
@@ -1,7 +1,10 @@
 package main
 
 import (
-	"github.com/caarlos0/env/v6"
+	"context"
+	"fmt"
+	"os"
+
 	_ "github.com/golang/mock/mockgen/model"
 	"github.com/rs/zerolog"
 	"github.com/s-larionov/process-manager"
@@ -14,30 +17,24 @@ import (
 
 const decimalDivisionPrecision = 32
 
-var (
-	cfg config.App
-)
-
 func init() {
 	decimal.DivisionPrecision = decimalDivisionPrecision
-	err := env.Parse(&cfg)
-	if err != nil {
-		panic(err)
-	}
-
-	level, err := zerolog.ParseLevel(cfg.LogLevel)
-	if err != nil {
-		panic(err)
-	}
-	zerolog.SetGlobalLevel(level)
 	process.SetLogger(&logger.ProcessManagerLogger{})
 }
 
 func main() {
-	app, err := internal.NewApplication(cfg)
+	cfg, err := config.Load()
 	if err != nil {
-		panic(err)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
 
-	app.Run()
+	// the level is validated by config.Load
+	level, _ := zerolog.ParseLevel(cfg.LogLevel)
+	zerolog.SetGlobalLevel(level)
+
+	if err := internal.RunCommand(context.Background(), cfg, os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 }
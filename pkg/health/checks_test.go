@@ -0,0 +1,62 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunChecks(t *testing.T) {
+	report := RunChecks(context.Background(), 50*time.Millisecond, map[string]Check{
+		"ok": func(context.Context) error {
+			return nil
+		},
+		"failed": func(context.Context) error {
+			return errors.New("connection refused")
+		},
+		// ignores the context, the probe must not wait for it
+		"stuck": func(context.Context) error {
+			time.Sleep(time.Second)
+			return nil
+		},
+	})
+
+	require.Equal(t, StatusFail, report.Status)
+	require.Equal(t, StatusOK, report.Checks["ok"].Status)
+	require.Equal(t, "connection refused", report.Checks["failed"].Error)
+	require.Equal(t, "timeout exceeded", report.Checks["stuck"].Error)
+	require.Less(t, report.Checks["stuck"].DurationMS, int64(500))
+}
+
+func TestReadinessHandler(t *testing.T) {
+	for name, tc := range map[string]struct {
+		err    error
+		status int
+	}{
+		"ready": {
+			status: http.StatusOK,
+		},
+		"not ready": {
+			err:    errors.New("ping failed"),
+			status: http.StatusServiceUnavailable,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			handler := ReadinessHandler(time.Second, map[string]Check{
+				"postgres": func(context.Context) error {
+					return tc.err
+				},
+			})
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			require.Equal(t, tc.status, rec.Code)
+			require.Contains(t, rec.Body.String(), `"postgres"`)
+		})
+	}
+}
@@ -14,10 +14,13 @@ import (
 
 const readHeaderTimeout = 30 * time.Second
 
-func NewHealthCheckServer(listen, path string, handler http.Handler) *http.Server {
+// NewHealthCheckServer serves handlers by their paths
+func NewHealthCheckServer(listen string, handlers map[string]http.Handler) *http.Server {
 	router := mux.NewRouter()
 	router.Use(middleware.Panic)
-	router.Handle(path, handler)
+	for path, handler := range handlers {
+		router.Handle(path, handler)
+	}
 
 	server := &http.Server{
 		Addr:              listen,
@@ -0,0 +1,351 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// baseSchemaFile is the initial schema which precedes all versioned migrations
+	baseSchemaFile    = "schema.sql"
+	baseSchemaVersion = 0
+)
+
+var fileNamePattern = regexp.MustCompile(`^V(\d+)_(\w+)\.sql$`)
+
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+// Load reads schema.sql and V<version>_<name>.sql files from the root of fsys ordered by version
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("read migrations: %w", err)
+	}
+
+	var list []Migration
+	versions := make(map[int]string)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		migration, ok, err := parseFileName(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+
+		if prev, exists := versions[migration.Version]; exists {
+			return nil, fmt.Errorf("duplicate migration version %d: %s and %s", migration.Version, prev, entry.Name())
+		}
+		versions[migration.Version] = entry.Name()
+
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("read migration %s: %w", entry.Name(), err)
+		}
+		migration.SQL = string(data)
+
+		list = append(list, migration)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Version < list[j].Version
+	})
+
+	return list, nil
+}
+
+func parseFileName(name string) (Migration, bool, error) {
+	if name == baseSchemaFile {
+		return Migration{Version: baseSchemaVersion, Name: "schema"}, true, nil
+	}
+
+	match := fileNamePattern.FindStringSubmatch(name)
+	if match == nil {
+		return Migration{}, false, nil
+	}
+
+	version, err := strconv.Atoi(match[1])
+	if err != nil {
+		return Migration{}, false, fmt.Errorf("invalid migration version %s: %w", name, err)
+	}
+
+	return Migration{Version: version, Name: match[2]}, true, nil
+}
+
+// lockID is the key of the postgres advisory lock held by the runner while it changes the schema
+const lockID = 7_245_301_894
+
+var (
+	ErrSchemaOutdated = errors.New("schema is outdated")
+	// ErrNoVersions is returned by Verify for the database without the schema_migrations table,
+	// e.g. migrated by hand before the runner, its schema version is unknown
+	ErrNoVersions = errors.New("schema_migrations table is missing")
+)
+
+// Runner applies migrations and keeps applied versions in the schema_migrations table.
+// Concurrent runners wait for each other on the advisory lock, so migrations are applied once.
+type Runner struct {
+	db *sql.DB
+}
+
+func NewRunner(db *sql.DB) *Runner {
+	return &Runner{db: db}
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+type conn interface {
+	execer
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+func initTable(ctx context.Context, db conn) error {
+	_, err := db.ExecContext(ctx, `
+		create table if not exists schema_migrations
+		(
+		    version    integer primary key,
+		    name       text not null,
+		    applied_at timestamp with time zone not null default now()
+		)`)
+	if err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	return nil
+}
+
+func applied(ctx context.Context, db conn) (map[int]bool, error) {
+	if err := initTable(ctx, db); err != nil {
+		return nil, err
+	}
+
+	return appliedVersions(ctx, db)
+}
+
+// tableExists reports whether schema_migrations is created without creating it
+func tableExists(ctx context.Context, db conn) (bool, error) {
+	rows, err := db.QueryContext(ctx, "select to_regclass('schema_migrations') is not null")
+	if err != nil {
+		return false, fmt.Errorf("check schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	var exists bool
+	if rows.Next() {
+		if err := rows.Scan(&exists); err != nil {
+			return false, fmt.Errorf("scan schema_migrations check: %w", err)
+		}
+	}
+
+	return exists, rows.Err()
+}
+
+func appliedVersions(ctx context.Context, db conn) (map[int]bool, error) {
+	rows, err := db.QueryContext(ctx, "select version from schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("select applied versions: %w", err)
+	}
+	defer rows.Close()
+
+	result := make(map[int]bool)
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return nil, fmt.Errorf("scan applied version: %w", err)
+		}
+
+		result[version] = true
+	}
+
+	return result, rows.Err()
+}
+
+func pending(ctx context.Context, db conn, migrations []Migration) ([]Migration, error) {
+	done, err := applied(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	return notApplied(migrations, done), nil
+}
+
+func notApplied(migrations []Migration, done map[int]bool) []Migration {
+	var result []Migration
+	for _, migration := range migrations {
+		if !done[migration.Version] {
+			result = append(result, migration)
+		}
+	}
+
+	return result
+}
+
+// Pending returns migrations which are not applied yet, it waits for the running migration if any
+func (r *Runner) Pending(ctx context.Context, migrations []Migration) ([]Migration, error) {
+	var list []Migration
+	err := r.withLock(ctx, func(c *sql.Conn) error {
+		var err error
+		list, err = pending(ctx, c, migrations)
+
+		return err
+	})
+
+	return list, err
+}
+
+// Verify fails with ErrSchemaOutdated if any of the migrations is not applied and with ErrNoVersions
+// if applied versions are not recorded at all.
+// Versions unknown to the binary are allowed, so the previous release keeps working during the rollout.
+// It only reads the schema, neither the table nor the lock is taken, so it works with a read-only role.
+func (r *Runner) Verify(ctx context.Context, migrations []Migration) error {
+	exists, err := tableExists(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("%w: apply migrations with migrate "+
+			"or mark the schema migrated by hand with migrate -baseline <version>", ErrNoVersions)
+	}
+
+	done, err := appliedVersions(ctx, r.db)
+	if err != nil {
+		return err
+	}
+
+	list := notApplied(migrations, done)
+
+	if len(list) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(list))
+	for _, migration := range list {
+		names = append(names, fmt.Sprintf("%d_%s", migration.Version, migration.Name))
+	}
+
+	return fmt.Errorf("%w: expected version %d, pending migrations: %s",
+		ErrSchemaOutdated, migrations[len(migrations)-1].Version, strings.Join(names, ", "))
+}
+
+// Baseline marks migrations up to the version as applied without running them.
+// It is used for databases which were migrated by hand before the runner.
+func (r *Runner) Baseline(ctx context.Context, migrations []Migration, version int) ([]Migration, error) {
+	var marked []Migration
+	err := r.withLock(ctx, func(c *sql.Conn) error {
+		list, err := pending(ctx, c, migrations)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range list {
+			if migration.Version > version {
+				break
+			}
+
+			if err := record(ctx, c, migration); err != nil {
+				return err
+			}
+
+			marked = append(marked, migration)
+		}
+
+		return nil
+	})
+
+	return marked, err
+}
+
+// Apply runs pending migrations in order, each one in its own transaction
+func (r *Runner) Apply(ctx context.Context, migrations []Migration, onApplied func(Migration)) error {
+	return r.withLock(ctx, func(c *sql.Conn) error {
+		// pending migrations are read under the lock, another runner could apply them while we waited
+		list, err := pending(ctx, c, migrations)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range list {
+			if err := apply(ctx, c, migration); err != nil {
+				return err
+			}
+
+			if onApplied != nil {
+				onApplied(migration)
+			}
+		}
+
+		return nil
+	})
+}
+
+// withLock holds the session advisory lock on the dedicated connection while fn runs
+func (r *Runner) withLock(ctx context.Context, fn func(c *sql.Conn) error) error {
+	c, err := r.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("get connection: %w", err)
+	}
+	defer c.Close()
+
+	if _, err := c.ExecContext(ctx, "select pg_advisory_lock($1)", lockID); err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	defer func() {
+		// the lock is released with the session anyway if unlock fails
+		_, _ = c.ExecContext(context.WithoutCancel(ctx), "select pg_advisory_unlock($1)", lockID)
+	}()
+
+	return fn(c)
+}
+
+func apply(ctx context.Context, c *sql.Conn, migration Migration) error {
+	tx, err := c.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin migration %d: %w", migration.Version, err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if _, err := tx.ExecContext(ctx, migration.SQL); err != nil {
+		return fmt.Errorf("apply migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+
+	if err := record(ctx, tx, migration); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit migration %d: %w", migration.Version, err)
+	}
+
+	return nil
+}
+
+func record(ctx context.Context, db execer, migration Migration) error {
+	_, err := db.ExecContext(ctx,
+		"insert into schema_migrations (version, name) values ($1, $2)",
+		migration.Version, migration.Name,
+	)
+	if err != nil {
+		return fmt.Errorf("record migration %d: %w", migration.Version, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,352 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	list, err := Load(fstest.MapFS{
+		"V10_push_dedup.sql":   {Data: []byte("create table push_dedup ();")},
+		"V2_push_extended.sql": {Data: []byte("alter table histories add clicked_at timestamp;")},
+		"schema.sql":           {Data: []byte("create table histories ();")},
+		"README.md":            {Data: []byte("not a migration")},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, list, 3)
+	require.Equal(t, Migration{Version: 0, Name: "schema", SQL: "create table histories ();"}, list[0])
+	require.Equal(t, 2, list[1].Version)
+	require.Equal(t, "push_extended", list[1].Name)
+	require.Equal(t, 10, list[2].Version)
+}
+
+func TestLoadDuplicateVersion(t *testing.T) {
+	_, err := Load(fstest.MapFS{
+		"V1_push_hash.sql":   {Data: []byte("select 1;")},
+		"V1_another_one.sql": {Data: []byte("select 2;")},
+	})
+	require.ErrorContains(t, err, "duplicate migration version 1")
+}
+
+// fakeDB is the state of the database behind the fake driver, the advisory lock is the mutex
+type fakeDB struct {
+	lock sync.Mutex
+
+	mu         sync.Mutex
+	table      bool
+	applied    map[int]bool
+	statements []string
+	executed   map[string]int
+}
+
+func (db *fakeDB) log() []string {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	return slices.Clone(db.statements)
+}
+
+var (
+	fakeDBsMu sync.Mutex
+	fakeDBs   = make(map[string]*fakeDB)
+)
+
+func init() {
+	sql.Register("migration-fake", fakeDriver{})
+}
+
+// openFake returns a pool to the new fake database, more pools to the same database are opened by its name
+func openFake(t *testing.T, table bool, applied ...int) (*fakeDB, func() *sql.DB) {
+	t.Helper()
+
+	db := &fakeDB{table: table, applied: make(map[int]bool), executed: make(map[string]int)}
+	for _, version := range applied {
+		db.applied[version] = true
+	}
+
+	fakeDBsMu.Lock()
+	fakeDBs[t.Name()] = db
+	fakeDBsMu.Unlock()
+
+	return db, func() *sql.DB {
+		pool, err := sql.Open("migration-fake", t.Name())
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			_ = pool.Close()
+		})
+
+		return pool
+	}
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	fakeDBsMu.Lock()
+	defer fakeDBsMu.Unlock()
+
+	db, ok := fakeDBs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown fake database %s", name)
+	}
+
+	return &fakeConn{db: db}, nil
+}
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("prepare is not supported")
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return fakeTx{}, nil
+}
+
+func (c *fakeConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	return fakeTx{}, nil
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	query = strings.Join(strings.Fields(query), " ")
+
+	// the lock is taken outside of the state mutex since the runner waits on it
+	switch {
+	case strings.HasPrefix(query, "select pg_advisory_lock"):
+		c.db.lock.Lock()
+	case strings.HasPrefix(query, "select pg_advisory_unlock"):
+		c.db.lock.Unlock()
+	}
+
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+
+	c.db.statements = append(c.db.statements, query)
+
+	switch {
+	case strings.Contains(query, "pg_advisory"):
+	case strings.HasPrefix(query, "create table if not exists schema_migrations"):
+		c.db.table = true
+	case strings.HasPrefix(query, "insert into schema_migrations"):
+		c.db.applied[int(args[0].Value.(int64))] = true
+	case query == "fail":
+		return nil, errors.New("syntax error")
+	default:
+		c.db.executed[query]++
+	}
+
+	return driver.RowsAffected(1), nil
+}
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+
+	c.db.statements = append(c.db.statements, query)
+
+	switch {
+	case strings.Contains(query, "to_regclass"):
+		return &fakeRows{values: [][]driver.Value{{c.db.table}}}, nil
+	case query == "select version from schema_migrations":
+		if !c.db.table {
+			return nil, errors.New(`relation "schema_migrations" does not exist`)
+		}
+
+		rows := &fakeRows{}
+		for version := range c.db.applied {
+			rows.values = append(rows.values, []driver.Value{int64(version)})
+		}
+
+		return rows, nil
+	default:
+		return nil, fmt.Errorf("unexpected query: %s", query)
+	}
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeRows struct {
+	values [][]driver.Value
+	idx    int
+}
+
+func (r *fakeRows) Columns() []string {
+	return []string{"value"}
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.idx >= len(r.values) {
+		return io.EOF
+	}
+
+	copy(dest, r.values[r.idx])
+	r.idx++
+
+	return nil
+}
+
+func testMigrations() []Migration {
+	return []Migration{
+		{Version: 0, Name: "schema", SQL: "create table histories ()"},
+		{Version: 1, Name: "push_hash", SQL: "alter table histories add hash text"},
+		{Version: 2, Name: "push_extended", SQL: "alter table histories add clicked_at timestamp"},
+	}
+}
+
+func TestRunnerApply(t *testing.T) {
+	db, open := openFake(t, true, 0)
+	runner := NewRunner(open())
+
+	var applied []int
+	err := runner.Apply(context.Background(), testMigrations(), func(item Migration) {
+		applied = append(applied, item.Version)
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []int{1, 2}, applied)
+	assert.Equal(t, map[string]int{
+		"alter table histories add hash text":            1,
+		"alter table histories add clicked_at timestamp": 1,
+	}, db.executed)
+	assert.Equal(t, map[int]bool{0: true, 1: true, 2: true}, db.applied)
+
+	// migrations are changed only under the lock
+	log := db.log()
+	require.NotEmpty(t, log)
+	assert.True(t, strings.HasPrefix(log[0], "select pg_advisory_lock"))
+	assert.True(t, strings.HasPrefix(log[len(log)-1], "select pg_advisory_unlock"))
+
+	applied = nil
+	require.NoError(t, runner.Apply(context.Background(), testMigrations(), func(item Migration) {
+		applied = append(applied, item.Version)
+	}))
+	assert.Empty(t, applied)
+}
+
+func TestRunnerApplyConcurrently(t *testing.T) {
+	db, open := openFake(t, false)
+
+	var wg sync.WaitGroup
+	errs := make([]error, 4)
+	for idx := range errs {
+		runner := NewRunner(open())
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			errs[idx] = runner.Apply(context.Background(), testMigrations(), nil)
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+
+	for _, migration := range testMigrations() {
+		assert.Equal(t, 1, db.executed[migration.SQL], migration.Name)
+	}
+}
+
+func TestRunnerApplyFailed(t *testing.T) {
+	db, open := openFake(t, true)
+	runner := NewRunner(open())
+
+	migrations := testMigrations()
+	migrations[1].SQL = "fail"
+
+	err := runner.Apply(context.Background(), migrations, nil)
+	require.ErrorContains(t, err, "apply migration 1_push_hash")
+
+	assert.Equal(t, map[int]bool{0: true}, db.applied)
+	assert.Zero(t, db.executed[migrations[2].SQL])
+
+	// the lock is released after the failure
+	require.NoError(t, runner.Apply(context.Background(), testMigrations(), nil))
+	assert.Equal(t, map[int]bool{0: true, 1: true, 2: true}, db.applied)
+}
+
+func TestRunnerVerify(t *testing.T) {
+	for name, tc := range map[string]struct {
+		table   bool
+		applied []int
+		errIs   error
+		err     string
+	}{
+		"missing table": {
+			errIs: ErrNoVersions,
+			err:   "migrate -baseline",
+		},
+		"pending migrations": {
+			table:   true,
+			applied: []int{0},
+			errIs:   ErrSchemaOutdated,
+			err:     "expected version 2, pending migrations: 1_push_hash, 2_push_extended",
+		},
+		"all applied": {
+			table:   true,
+			applied: []int{0, 1, 2},
+		},
+		"newer version is applied": {
+			table:   true,
+			applied: []int{0, 1, 2, 3},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			db, open := openFake(t, tc.table, tc.applied...)
+
+			err := NewRunner(open()).Verify(context.Background(), testMigrations())
+			if tc.err != "" {
+				require.ErrorIs(t, err, tc.errIs)
+				require.ErrorContains(t, err, tc.err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			// verify only reads the schema
+			assert.Equal(t, tc.table, db.table)
+			for _, statement := range db.log() {
+				assert.True(t, strings.HasPrefix(statement, "select "), statement)
+				assert.NotContains(t, statement, "pg_advisory")
+			}
+		})
+	}
+}
+
+func TestRunnerBaseline(t *testing.T) {
+	db, open := openFake(t, false)
+
+	marked, err := NewRunner(open()).Baseline(context.Background(), testMigrations(), 1)
+	require.NoError(t, err)
+
+	require.Len(t, marked, 2)
+	assert.Equal(t, map[int]bool{0: true, 1: true}, db.applied)
+	assert.Empty(t, db.executed)
+}
@@ -0,0 +1,21 @@
+create table push_dedup
+(
+    id         bigserial primary key,
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone,
+    key        text not null,
+    expires_at timestamp with time zone
+);
+
+create unique index idx_push_dedup_key
+    on push_dedup (key);
+
+create index idx_push_dedup_expires_at
+    on push_dedup (expires_at);
+
+-- the same push can be sent again once the dedup window is over
+drop index if exists idx_histories_hash;
+
+create index idx_histories_hash
+    on histories (hash);
@@ -0,0 +1,2 @@
+alter table send_queue
+    add trace_parent text;
@@ -0,0 +1,12 @@
+alter table send_queue
+    add correlation_id text;
+
+alter table send_queue
+    add sent_correlation_id text;
+
+alter table histories
+    add correlation_id text;
+
+create index if not exists idx_send_queue_correlation_id on send_queue (correlation_id);
+create index if not exists idx_send_queue_sent_correlation_id on send_queue (sent_correlation_id);
+create index if not exists idx_histories_correlation_id on histories (correlation_id);
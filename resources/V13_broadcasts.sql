@@ -0,0 +1,30 @@
+create table broadcasts
+(
+    id           bigserial primary key,
+    created_at   timestamp with time zone,
+    updated_at   timestamp with time zone,
+    deleted_at   timestamp with time zone,
+    title        text not null,
+    body         text not null,
+    image_url    text,
+    deep_link    text,
+    segment      text not null,
+    user_ids     jsonb,
+    dao_id       uuid,
+    status       text not null,
+    scheduled_at timestamp with time zone,
+    confirmed_at timestamp with time zone,
+    started_at   timestamp with time zone,
+    finished_at  timestamp with time zone,
+    recipients   integer not null default 0,
+    processed    integer not null default 0,
+    skipped      integer not null default 0,
+    failed       integer not null default 0,
+    error        text
+);
+
+create index idx_broadcasts_deleted_at
+    on broadcasts (deleted_at);
+
+create index idx_broadcasts_status_scheduled_at
+    on broadcasts (status, scheduled_at);
@@ -0,0 +1,18 @@
+create table audit_log
+(
+    id             bigserial primary key,
+    created_at     timestamp with time zone,
+    updated_at     timestamp with time zone,
+    deleted_at     timestamp with time zone,
+    actor          text not null,
+    action         text not null,
+    params         jsonb,
+    affected_ids   jsonb,
+    correlation_id text
+);
+
+create index idx_audit_log_deleted_at
+    on audit_log (deleted_at);
+
+create index idx_audit_log_action
+    on audit_log (action);
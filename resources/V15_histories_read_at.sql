@@ -0,0 +1,9 @@
+-- read marks of the notification center are kept apart from push clicks,
+-- so marking notifications as read does not count as clicks in analytics
+alter table histories
+    add read_at timestamp;
+
+-- clicked pushes are read
+update histories
+set read_at = clicked_at
+where clicked_at is not null;
@@ -0,0 +1,6 @@
+-- the event being processed is leased, redeliveries within the lease are not processed again
+alter table event_inbox
+    add locked_until timestamp with time zone;
+
+create index idx_event_inbox_updated_at
+    on event_inbox (updated_at);
@@ -0,0 +1,4 @@
+-- outbox messages are claimed for the lease and published after the claim is committed,
+-- so no row locks are held while publishing
+alter table outbox
+    add locked_until timestamp with time zone;
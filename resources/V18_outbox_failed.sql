@@ -0,0 +1,10 @@
+-- outbox messages which failed to publish outboxMaxAttempts times are marked failed
+-- and skipped, so they don't block the messages after them
+alter table outbox
+    add failed_at timestamp with time zone;
+
+drop index idx_outbox_pending;
+
+create index idx_outbox_pending
+    on outbox (id)
+    where published_at is null and failed_at is null;
@@ -0,0 +1,23 @@
+create table fanout_log
+(
+    id          bigserial
+        primary key,
+    created_at  timestamp with time zone,
+    updated_at  timestamp with time zone,
+    deleted_at  timestamp with time zone,
+    feed_id     text,
+    user_id     text,
+    dao_id      text,
+    proposal_id text,
+    action      text,
+    outcome     text
+);
+
+create index idx_fanout_log_deleted_at
+    on fanout_log (deleted_at);
+
+create index idx_fanout_log_dao_proposal
+    on fanout_log (dao_id, proposal_id);
+
+create index idx_fanout_log_user_id
+    on fanout_log (user_id);
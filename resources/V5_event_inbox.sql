@@ -0,0 +1,28 @@
+create table event_inbox
+(
+    id           bigserial
+        primary key,
+    created_at   timestamp with time zone,
+    updated_at   timestamp with time zone,
+    deleted_at   timestamp with time zone,
+    subject      text,
+    event_key    text,
+    status       text,
+    attempts     integer default 0,
+    last_error   text,
+    processed_at timestamp with time zone
+);
+
+create index idx_event_inbox_deleted_at
+    on event_inbox (deleted_at);
+
+create unique index idx_event_inbox_subject_event_key
+    on event_inbox (subject, event_key);
+
+-- the same action may be sent again once the previous one was delivered,
+-- so only pending queue items are deduplicated
+drop index idx_send_queue_user_dao_proposal_action;
+
+create unique index idx_send_queue_pending_user_dao_proposal_action
+    on send_queue (user_id, dao_id, proposal_id, action)
+    where sent_at is null and deleted_at is null;
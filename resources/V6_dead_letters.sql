@@ -0,0 +1,19 @@
+create table dead_letters
+(
+    id          bigserial
+        primary key,
+    created_at  timestamp with time zone,
+    updated_at  timestamp with time zone,
+    deleted_at  timestamp with time zone,
+    subject     text,
+    payload     text,
+    error       text,
+    deliveries  integer,
+    replayed_at timestamp with time zone
+);
+
+create index idx_dead_letters_deleted_at
+    on dead_letters (deleted_at);
+
+create index idx_dead_letters_subject
+    on dead_letters (subject);
@@ -0,0 +1,20 @@
+create table outbox
+(
+    id           bigserial
+        primary key,
+    created_at   timestamp with time zone,
+    updated_at   timestamp with time zone,
+    deleted_at   timestamp with time zone,
+    subject      text,
+    payload      text,
+    attempts     integer default 0,
+    last_error   text,
+    published_at timestamp with time zone
+);
+
+create index idx_outbox_deleted_at
+    on outbox (deleted_at);
+
+create index idx_outbox_pending
+    on outbox (id)
+    where published_at is null;
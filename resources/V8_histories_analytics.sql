@@ -0,0 +1,11 @@
+alter table histories
+    add action text;
+
+alter table histories
+    add dao_id text;
+
+create index idx_histories_created_at
+    on histories (created_at);
+
+create index idx_histories_user_id_created_at
+    on histories (user_id, created_at);
@@ -0,0 +1,5 @@
+alter table histories
+    add delivered_at timestamp;
+
+alter table histories
+    add dismissed_at timestamp;
@@ -0,0 +1,8 @@
+package resources
+
+import "embed"
+
+// Migrations are the schema migrations applied by the migration runner in version order
+//
+//go:embed *.sql
+var Migrations embed.FS
@@ -0,0 +1,19 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/goverland-labs/goverland-inbox-push/pkg/migration"
+)
+
+func TestMigrationsAreContiguous(t *testing.T) {
+	list, err := migration.Load(Migrations)
+	require.NoError(t, err)
+	require.NotEmpty(t, list)
+
+	for idx, item := range list {
+		require.Equal(t, idx, item.Version, "migration %s", item.Name)
+	}
+}